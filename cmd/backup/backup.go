@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/backup"
+	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// backup writes a snapshot of the database, lists snapshots or restores one, also while the server is running
+// usage: backup [-dir backups] [-list] [-restore gomovie-20261019-020000.sqlite]
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[gomovie-backup] ")
+	var dir string
+	var list bool
+	var restore string
+
+	flag.StringVar(&dir, "dir", config.Envs.BackupDir, "snapshot directory")
+	flag.BoolVar(&list, "list", false, "list snapshots instead of writing one")
+	flag.StringVar(&restore, "restore", "", "snapshot name in -dir or path of a snapshot file to restore")
+	flag.Parse()
+
+	dbStore, err := store.SetupDatabase(config.Envs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbStore.Close()
+	backups := backup.NewBackuper(dbStore.As(api.SystemActor(backup.JobName)), dir, config.Envs.BackupInterval, config.Envs.BackupKeep)
+
+	switch {
+	case list:
+		snapshots, err := backups.Snapshots()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range snapshots {
+			fmt.Printf("%s\t%s\t%d bytes\n", s.Name, s.CreatedAt.Local().Format("2006-01-02 15:04:05"), s.Size)
+		}
+	case restore != "":
+		saved, err := backups.Restore(restore)
+		if errors.Is(err, backup.ErrUnknownSnapshot) {
+			if _, statErr := os.Stat(restore); statErr == nil {
+				saved, err = backups.RestoreFile(restore)
+			}
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("restored %s, previous state saved as %s", restore, saved.Name)
+	default:
+		snapshot, err := backups.Snapshot()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote %s", snapshot.Name)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/export"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// export writes all movies with entries, viewings and ratings to a file or stdout
+// usage: export [-format json|csv|letterboxd] [-user alice] [-o gomovie.json]
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[gomovie-export] ")
+	var formatName string
+	var username string
+	var output string
+
+	flag.StringVar(&formatName, "format", "json", "export format: json, csv or letterboxd")
+	flag.StringVar(&username, "user", "", "user of a letterboxd export")
+	flag.StringVar(&output, "o", "", "output file, stdout if empty")
+	flag.Parse()
+
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dbStore, err := store.SetupDatabase(config.Envs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbStore.Close()
+
+	w := os.Stdout
+	if output != "" {
+		w, err = os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	err = export.Write(w, dbStore, format, username)
+	if output != "" {
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
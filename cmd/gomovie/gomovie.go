@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,11 +13,18 @@ import (
 
 	"github.com/jhachmer/go-cache"
 	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/backup"
 	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/events"
 	"github.com/jhachmer/gomovie/internal/handlers"
+	"github.com/jhachmer/gomovie/internal/library"
+	"github.com/jhachmer/gomovie/internal/poster"
+	"github.com/jhachmer/gomovie/internal/refresh"
 	"github.com/jhachmer/gomovie/internal/server"
 	"github.com/jhachmer/gomovie/internal/store"
+	"github.com/jhachmer/gomovie/internal/trash"
 	"github.com/jhachmer/gomovie/internal/util"
+	"github.com/jhachmer/gomovie/internal/webhook"
 )
 
 func main() {
@@ -59,9 +67,32 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 func setupServer(store store.Store) *server.Server {
 	movC := cache.NewTTLCache[string, *api.Movie](time.Second*15, time.Minute*60, nil)
 	serC := cache.NewTTLCache[string, *api.Series](time.Second*15, time.Minute*60, nil)
-	handler := handlers.NewHandler(store, movC, serC)
+	posters := poster.NewStore(config.Envs.PosterDir)
+	backups := backup.NewBackuper(store.As(api.SystemActor(backup.JobName)), config.Envs.BackupDir, config.Envs.BackupInterval, config.Envs.BackupKeep)
+	bus := events.NewBus(store)
+	handler := handlers.NewHandler(store, movC, serC, posters, backups, bus)
+	handler.TrashDays = config.Envs.TrashDays
 
-	return server.NewServer(config.Envs.Addr, handler)
+	refresher := refresh.NewRefresher(store.As(api.SystemActor(refresh.JobName)), api.MovieFromID, config.Envs.RefreshInterval, config.Envs.OmdbDailyQuota)
+	refresher.OnRefresh = func(m *api.Movie) {
+		movC.Delete(m.ImdbID)
+		handler.InvalidateIndex()
+		if err := posters.Fetch(context.Background(), m.ImdbID, m.Poster); err != nil && !errors.Is(err, poster.ErrNoPoster) {
+			slog.Warn("could not update poster", "id", m.ImdbID, "err", err.Error())
+		}
+	}
+
+	scanner := library.NewScanner(store.As(api.SystemActor(library.JobName)), config.Envs.LibraryDir, library.OMDbLookup, config.Envs.LibraryInterval, config.Envs.OmdbDailyQuota)
+	scanner.WriteNFO = config.Envs.LibraryWriteNFO
+	scanner.OnMatch = func(*api.Movie) {
+		handler.InvalidateIndex()
+	}
+
+	dispatcher := webhook.NewDispatcher(store, bus, time.Minute)
+
+	purger := trash.NewPurger(store.As(api.SystemActor(trash.JobName)), config.Envs.TrashDays)
+
+	return server.NewServer(config.Envs.Addr, handler, refresher, backups, scanner, dispatcher, purger)
 }
 
 func checkForValidConfig() {
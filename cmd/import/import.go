@@ -0,0 +1,195 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/importer"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// files collects a flag given more than once
+type files []string
+
+func (f *files) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *files) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// import reads exports of other services and stores them for a single user
+// usage: import -user alice [-dry-run] [-letterboxd letterboxd-alice.zip] [-imdb ratings.csv -imdb watchlist.csv]
+// [-csv list.csv [-mapping mapping.json] [-title-col Title ...]] [-json gomovie.json] [-workers 4] [-checkpoint import.checkpoint]
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[gomovie-import] ")
+	var username string
+	var letterboxdPath string
+	var imdbPaths files
+	var csvPaths files
+	var jsonPath string
+	var mappingPath string
+	var truthy string
+	var mapping importer.Mapping
+	var dryRun bool
+	var workers int
+	var checkpointPath string
+
+	flag.StringVar(&username, "user", "", "user the imported rows belong to")
+	flag.StringVar(&letterboxdPath, "letterboxd", "", "Letterboxd export zip")
+	flag.Var(&imdbPaths, "imdb", "IMDb ratings or watchlist csv, may be given more than once")
+	flag.Var(&csvPaths, "csv", "csv file read with the column mapping, may be given more than once")
+	flag.StringVar(&jsonPath, "json", "", "JSON export of gomovie, restored with all users")
+	flag.StringVar(&mappingPath, "mapping", "", "json file mapping csv columns, column flags override it")
+	flag.StringVar(&mapping.IMDbID, "imdb-col", "", "csv column of the IMDb id, by header or position like #1")
+	flag.StringVar(&mapping.Title, "title-col", "", "csv column of the title")
+	flag.StringVar(&mapping.Year, "year-col", "", "csv column of the release year")
+	flag.StringVar(&mapping.Watched, "watched-col", "", "csv column marking watched rows")
+	flag.StringVar(&mapping.WatchedAt, "watched-at-col", "", "csv column of the watch date")
+	flag.StringVar(&mapping.Rating, "rating-col", "", "csv column of the rating")
+	flag.StringVar(&mapping.User, "user-col", "", "csv column of the user a row belongs to")
+	flag.StringVar(&truthy, "truthy", "", "comma separated values of the watched column meaning watched")
+	flag.BoolVar(&dryRun, "dry-run", false, "report what would be imported without storing anything")
+	flag.IntVar(&workers, "workers", 4, "number of concurrent OMDb lookups")
+	flag.StringVar(&checkpointPath, "checkpoint", "", "file recording imported rows, a rerun resumes after them")
+	flag.Parse()
+
+	if username == "" {
+		log.Fatal("-user is required")
+	}
+	if letterboxdPath == "" && len(imdbPaths) == 0 && len(csvPaths) == 0 && jsonPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var items []importer.Item
+	var unmatched []importer.Unmatched
+	if letterboxdPath != "" {
+		zr, err := zip.OpenReader(letterboxdPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		i, u, err := importer.ReadLetterboxd(&zr.Reader)
+		zr.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		items, unmatched = append(items, i...), append(unmatched, u...)
+	}
+	for _, path := range imdbPaths {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		i, u, err := importer.ReadIMDb(f, filepath.Base(path))
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		items, unmatched = append(items, i...), append(unmatched, u...)
+	}
+	if jsonPath != "" {
+		f, err := os.Open(jsonPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		i, err := importer.ReadJSON(f, filepath.Base(jsonPath))
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		items = append(items, i...)
+	}
+	if len(csvPaths) > 0 {
+		m, err := csvMapping(mappingPath, mapping, truthy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, path := range csvPaths {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			i, u, err := importer.ReadCSV(f, filepath.Base(path), m)
+			f.Close()
+			if err != nil {
+				log.Fatal(err)
+			}
+			items, unmatched = append(items, i...), append(unmatched, u...)
+		}
+	}
+
+	dbStore, err := store.SetupDatabase(config.Envs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbStore.Close()
+
+	var target importer.Store = dbStore.As(api.SystemActor("import"))
+	if dryRun {
+		log.Print("dry run, nothing will be stored")
+		target = importer.DryRun(dbStore)
+	}
+	im := importer.NewImporter(target, importer.OMDbResolver(), username)
+	if checkpointPath != "" && !dryRun {
+		im.Checkpoint, err = importer.OpenCheckpoint(checkpointPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	im.Prefetch(items, workers)
+	report := im.Import(items)
+	report.AddUnmatched(unmatched)
+	if err := im.Checkpoint.Close(); err != nil {
+		log.Print(err)
+	}
+	// a finished import needs no checkpoint, rows left unmatched are retried on the next run
+	if im.Checkpoint != nil && len(report.Unmatched) == 0 {
+		if err := os.Remove(checkpointPath); err != nil {
+			log.Print(err)
+		}
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// csvMapping loads the mapping file if given, columns and truthy values set by flag take precedence
+func csvMapping(path string, flags importer.Mapping, truthy string) (importer.Mapping, error) {
+	var m importer.Mapping
+	if path != "" {
+		var err error
+		if m, err = importer.LoadMapping(path); err != nil {
+			return m, err
+		}
+	}
+	for _, c := range []struct {
+		dst *string
+		src string
+	}{
+		{&m.IMDbID, flags.IMDbID},
+		{&m.Title, flags.Title},
+		{&m.Year, flags.Year},
+		{&m.Watched, flags.Watched},
+		{&m.WatchedAt, flags.WatchedAt},
+		{&m.Rating, flags.Rating},
+		{&m.User, flags.User},
+	} {
+		if c.src != "" {
+			*c.dst = c.src
+		}
+	}
+	if truthy != "" {
+		m.Truthy = strings.Split(truthy, ",")
+	}
+	return m, m.Validate()
+}
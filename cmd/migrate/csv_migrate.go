@@ -1,143 +1,73 @@
 package main
 
 import (
-	"encoding/csv"
-	"fmt"
-	"io"
+	"flag"
 	"log"
-	"log/slog"
 	"os"
-	"strconv"
+	"path/filepath"
 
 	"github.com/jhachmer/gomovie/internal/api"
 	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/importer"
 	"github.com/jhachmer/gomovie/internal/store"
-	"github.com/jhachmer/gomovie/internal/util"
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
 )
 
-const (
-	WatchedCol = 0
-	TitleCol   = 1
-	YearCol    = 2
-	AddedByCol = 4
-)
-
-type CSVEntry struct {
-	Watched bool
-	Title   string
-	Year    string
-	AddedBy string
+// spreadsheet is the layout of the original movie list: a watched checkbox, title, year and the user who added it
+var spreadsheet = importer.Mapping{
+	Origin:   "Spreadsheet",
+	Watched:  "#1",
+	Title:    "#2",
+	Year:     "#3",
+	User:     "#5",
+	Truthy:   []string{"TRUE"},
+	NoHeader: true,
 }
 
-func (e *CSVEntry) String() string {
-	return fmt.Sprintf("Watched: %v, Title: %v, Year: %v", e.Watched, e.Title, e.Year)
-}
+// migrate imports the original spreadsheet export, rows without a user in column 5 belong to -user
+// usage: migrate -user alice [-file movie_list.csv] [-dry-run]
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[gomovie-migrate] ")
+	var path string
+	var username string
+	var dryRun bool
 
-func (e *CSVEntry) RetrieveMovieFromEntry() (*api.Movie, error) {
-	m, err := api.MovieFromTitleAndYear(e.Title, e.Year)
-	if err != nil {
-		return nil, err
-	}
-	return m, nil
-}
+	flag.StringVar(&path, "file", "movie_list.csv", "spreadsheet csv export")
+	flag.StringVar(&username, "user", "", "user of rows without one")
+	flag.BoolVar(&dryRun, "dry-run", false, "report what would be migrated without storing anything")
+	flag.Parse()
 
-func ReadCSV(reader io.Reader) ([]*CSVEntry, error) {
-	records, err := csv.NewReader(reader).ReadAll()
-	if err != nil {
-		return nil, err
-	}
-	var entries []*CSVEntry
-	for _, record := range records {
-		entry, err := UnmarshalEntry(record)
-		if err != nil {
-			slog.Error("Error unmarshalling entry", "entry", entry, "err", err)
-			continue
-		}
-		slog.Info("Found entry", "entry", entry)
-		entries = append(entries, entry)
+	if username == "" {
+		log.Fatal("-user is required")
 	}
-	return entries, nil
-}
 
-func main() {
-	reader, err := os.Open("movie_list.csv")
+	f, err := os.Open(path)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer util.CloseOrLog(reader)
-	_ = setup(reader)
-}
-
-func UnmarshalEntry(record []string) (*CSVEntry, error) {
-	year, err := strconv.Atoi(record[YearCol])
+	items, unmatched, err := importer.ReadCSV(f, filepath.Base(path), spreadsheet)
+	f.Close()
 	if err != nil {
-		slog.Error("Error parsing year", "record", record, "year", year, "err", err)
-		return nil, err
-	}
-	entry := &CSVEntry{
-		Watched: watchedCheckboxValue(record[WatchedCol]),
-		Title:   record[TitleCol],
-		Year:    strconv.Itoa(year),
-		AddedBy: record[AddedByCol],
+		log.Fatal(err)
 	}
-	return entry, nil
-}
-
-func watchedCheckboxValue(value string) bool {
-	return value == "TRUE"
-}
 
-type App struct {
-	store       store.Store
-	CSVContents []*CSVEntry
-}
-
-func setup(reader io.Reader) *App {
-	cfg := config.Envs
-	fmt.Printf("%#v\n", cfg)
-	dbStore, err := store.SetupDatabase(cfg)
+	dbStore, err := store.SetupDatabase(config.Envs)
 	if err != nil {
-		slog.Error("Setup database failed", "err", err.Error())
-		os.Exit(1)
-	}
-	records, err := ReadCSV(reader)
-	if err != nil {
-		slog.Error("Reading CSV failed", "err", err.Error())
-		os.Exit(1)
-	}
-	return &App{
-		store:       dbStore,
-		CSVContents: records,
+		log.Fatal(err)
 	}
-}
+	defer dbStore.Close()
 
-// TODO: Add Transaction
-func (a *App) MigrateCSVToDatabase() {
-	for _, entry := range a.CSVContents {
-		movie, err := entry.RetrieveMovieFromEntry()
-		if err != nil {
-			slog.Error("failed to query from api", "entry", entry.String(), "error", err.Error())
-			continue
-		}
-		movie, err = a.store.CreateMovie(movie)
-		if err != nil {
-			slog.Error("failed to create movie", "entry", entry.String(), "error", err.Error())
-			continue
-		}
-		slog.Info("Created movie", "imdb_id", movie.ImdbID)
-		entry := &api.Entry{
-			ID:      0,
-			Name:    entry.AddedBy,
-			Watched: entry.Watched,
-			Comment: []byte("Migrated from Spreadsheet"),
-		}
-		entry, err = a.store.CreateEntry(entry, movie)
-		if err != nil {
-			slog.Error("could not create entry", "entry", entry, "error", err.Error())
-			continue
-		}
-		slog.Info("Created entry", "entry", entry, "imdb_id", movie.ImdbID)
+	var target importer.Store = dbStore.As(api.SystemActor("migrate"))
+	if dryRun {
+		target = importer.DryRun(dbStore)
+	}
+	im := importer.NewImporter(target, importer.OMDbResolver(), username)
+	im.Prefetch(items, 4)
+	report := im.Import(items)
+	report.AddUnmatched(unmatched)
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/poster"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// posters downloads the posters of all movies in the database, which are not stored locally yet
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[gomovie-posters] ")
+	var dir string
+	var force bool
+
+	flag.StringVar(&dir, "dir", config.Envs.PosterDir, "poster directory")
+	flag.BoolVar(&force, "force", false, "download posters again even if already stored")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	dbStore, err := store.SetupDatabase(config.Envs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbStore.Close()
+	movies, err := dbStore.GetAllMovies()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	posters := poster.NewStore(dir)
+	var fetched, missing, failed int
+	for _, m := range movies {
+		if ctx.Err() != nil {
+			break
+		}
+		id := m.Movie.ImdbID
+		if force {
+			if err := posters.Remove(id); err != nil {
+				log.Printf("could not remove poster of %s: %v", id, err)
+			}
+		}
+		err := posters.Fetch(ctx, id, m.Movie.Poster)
+		switch {
+		case errors.Is(err, poster.ErrNoPoster):
+			missing++
+		case err != nil:
+			failed++
+			log.Printf("could not fetch poster of %s (%s): %v", m.Movie.Title, id, err)
+		default:
+			fetched++
+		}
+	}
+	log.Printf("%d posters stored, %d movies without poster, %d failed", fetched, missing, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
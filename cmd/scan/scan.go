@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/config"
+	"github.com/jhachmer/gomovie/internal/library"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// scan matches the video files of a media folder to movies on OMDb and adds them to the library list
+// usage: scan [-dir /media/movies] [-retry] [-write-nfo]
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[gomovie-scan] ")
+	var dir string
+	var retry bool
+	var writeNFO bool
+
+	flag.StringVar(&dir, "dir", config.Envs.LibraryDir, "media folder to scan")
+	flag.BoolVar(&retry, "retry", false, "look up unchanged files again which were ambiguous or unmatched")
+	flag.BoolVar(&writeNFO, "write-nfo", config.Envs.LibraryWriteNFO, "write an NFO next to every matched file which has none")
+	flag.Parse()
+	if dir == "" {
+		log.Fatal("no media folder, set LIBRARY_DIR or -dir")
+	}
+
+	dbStore, err := store.SetupDatabase(config.Envs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbStore.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	scanner := library.NewScanner(dbStore.As(api.SystemActor("scan")), dir, library.OMDbLookup, config.Envs.LibraryInterval, config.Envs.OmdbDailyQuota)
+	scanner.Retry = retry
+	scanner.WriteNFO = writeNFO
+	// the scan is recorded like a scheduled one, its requests count against the daily OMDb quota
+	run, report := scanner.ScanAndRecord(ctx)
+	report.Write(os.Stdout)
+	if run.Error != "" {
+		log.Fatal(run.Error)
+	}
+}
@@ -2,12 +2,31 @@ package api
 
 import (
 	"fmt"
+	"time"
+)
+
+// Rating sources used by OMDb
+const (
+	SourceIMDb           = "Internet Movie Database"
+	SourceRottenTomatoes = "Rotten Tomatoes"
+	SourceMetacritic     = "Metacritic"
 )
 
 // Rating holds rating data, which are pairs of Source and the actual rating value
+// Score is the value normalised to 0-100, nil if the value is not available
 type Rating struct {
-	Source string `json:"Source"`
-	Value  string `json:"Value"`
+	Source string   `json:"Source"`
+	Value  string   `json:"Value"`
+	Score  *float64 `json:"-"`
+}
+
+// RatingSnapshot is the value a rating source reported at a point in time
+type RatingSnapshot struct {
+	MediaID string
+	Source  string
+	Value   string
+	Score   *float64
+	TakenAt time.Time
 }
 
 // String method to implement Stringer interface
@@ -50,6 +69,11 @@ type Movie struct {
 	Type       string   `json:"Type"`
 	BoxOffice  string   `json:"BoxOffice"`
 	Website    string   `json:"Website"`
+
+	// typed values parsed from the strings above by Normalise
+	RuntimeMinutes int       `json:"-"`
+	ReleasedAt     time.Time `json:"-"`
+	BoxOfficeCents int64     `json:"-"`
 }
 
 func (m Movie) GetID() string {
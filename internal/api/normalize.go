@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotAvailable is the placeholder OMDb uses for missing values
+const NotAvailable = "N/A"
+
+// ReleasedLayout is the date format of the OMDb Released field
+const ReleasedLayout = "02 Jan 2006"
+
+// ErrNotAvailable is returned when parsing an empty or "N/A" value
+var ErrNotAvailable = errors.New("value not available")
+
+// ParseRatingScore converts an OMDb rating value to a score between 0 and 100
+// supported formats are fractions like "7.8/10" or "74/100" and percentages like "91%"
+func ParseRatingScore(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if isNotAvailable(value) {
+		return 0, ErrNotAvailable
+	}
+	var score float64
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(pct, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage %q: %w", value, err)
+		}
+		score = p
+	} else if num, den, ok := strings.Cut(value, "/"); ok {
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid rating %q: %w", value, err)
+		}
+		d, err := strconv.ParseFloat(den, 64)
+		if err != nil || d == 0 {
+			return 0, fmt.Errorf("invalid rating scale %q", value)
+		}
+		score = n / d * 100
+	} else {
+		return 0, fmt.Errorf("unknown rating format %q", value)
+	}
+	if score < 0 || score > 100 {
+		return 0, fmt.Errorf("rating %q out of range", value)
+	}
+	return score, nil
+}
+
+// ParseRuntime converts an OMDb runtime like "142 min" to minutes
+func ParseRuntime(runtime string) (int, error) {
+	runtime = strings.TrimSpace(runtime)
+	if isNotAvailable(runtime) {
+		return 0, ErrNotAvailable
+	}
+	minutes, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(runtime, "min")))
+	if err != nil {
+		return 0, fmt.Errorf("invalid runtime %q: %w", runtime, err)
+	}
+	return minutes, nil
+}
+
+// ParseReleased converts an OMDb release date like "14 Oct 1994"
+func ParseReleased(released string) (time.Time, error) {
+	released = strings.TrimSpace(released)
+	if isNotAvailable(released) {
+		return time.Time{}, ErrNotAvailable
+	}
+	t, err := time.Parse(ReleasedLayout, released)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid release date %q: %w", released, err)
+	}
+	return t, nil
+}
+
+// ParseBoxOffice converts an OMDb box office value like "$28,767,189" to cents
+func ParseBoxOffice(boxOffice string) (int64, error) {
+	boxOffice = strings.TrimSpace(boxOffice)
+	if isNotAvailable(boxOffice) {
+		return 0, ErrNotAvailable
+	}
+	amount := strings.ReplaceAll(strings.TrimPrefix(boxOffice, "$"), ",", "")
+	dollars, cents, _ := strings.Cut(amount, ".")
+	d, err := strconv.ParseInt(dollars, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid box office %q: %w", boxOffice, err)
+	}
+	var c int64
+	if cents != "" {
+		if len(cents) > 2 {
+			return 0, fmt.Errorf("invalid box office %q", boxOffice)
+		}
+		c, err = strconv.ParseInt((cents + "0")[:2], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid box office %q: %w", boxOffice, err)
+		}
+	}
+	return d*100 + c, nil
+}
+
+// Normalise fills the typed fields of the movie and its ratings from the raw OMDb strings
+// values which are missing or can not be parsed are left at their zero value
+func (m *Movie) Normalise() {
+	m.RuntimeMinutes, _ = ParseRuntime(m.Runtime)
+	m.ReleasedAt, _ = ParseReleased(m.Released)
+	m.BoxOfficeCents, _ = ParseBoxOffice(m.BoxOffice)
+	for i := range m.Ratings {
+		m.Ratings[i].Normalise()
+	}
+}
+
+// Normalise sets Score from Value, Score stays nil if the value can not be parsed
+func (r *Rating) Normalise() {
+	r.Score = nil
+	if score, err := ParseRatingScore(r.Value); err == nil {
+		r.Score = &score
+	}
+}
+
+// RatingScore returns the normalised score of the given source
+func (m Movie) RatingScore(source string) (float64, bool) {
+	for _, r := range m.Ratings {
+		if r.Source == source && r.Score != nil {
+			return *r.Score, true
+		}
+	}
+	return 0, false
+}
+
+func isNotAvailable(s string) bool {
+	return s == "" || strings.EqualFold(s, NotAvailable)
+}
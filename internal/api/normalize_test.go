@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/util"
+)
+
+func TestParseRatingScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    float64
+		wantErr error
+	}{
+		{name: "IMDb", value: "7.8/10", want: 78},
+		{name: "Rotten Tomatoes", value: "91%", want: 91},
+		{name: "Metacritic", value: "74/100", want: 74},
+		{name: "zero percent", value: "0%", want: 0},
+		{name: "surrounding whitespace", value: " 8.5/10 ", want: 85},
+		{name: "not available", value: "N/A", wantErr: ErrNotAvailable},
+		{name: "empty", value: "", wantErr: ErrNotAvailable},
+		{name: "zero scale", value: "5/0", wantErr: errAny},
+		{name: "out of range", value: "120%", wantErr: errAny},
+		{name: "unknown format", value: "great", wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRatingScore(tt.value)
+			checkErr(t, err, tt.wantErr)
+			if err == nil && !almostEqual(got, tt.want) {
+				t.Errorf("ParseRatingScore(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRuntime(t *testing.T) {
+	tests := []struct {
+		name    string
+		runtime string
+		want    int
+		wantErr error
+	}{
+		{name: "minutes", runtime: "142 min", want: 142},
+		{name: "without unit", runtime: "90", want: 90},
+		{name: "not available", runtime: "N/A", wantErr: ErrNotAvailable},
+		{name: "garbage", runtime: "two hours", wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRuntime(tt.runtime)
+			checkErr(t, err, tt.wantErr)
+			if got != tt.want {
+				t.Errorf("ParseRuntime(%q) = %v, want %v", tt.runtime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReleased(t *testing.T) {
+	tests := []struct {
+		name     string
+		released string
+		want     time.Time
+		wantErr  error
+	}{
+		{name: "date", released: "14 Oct 1994", want: time.Date(1994, 10, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "not available", released: "N/A", wantErr: ErrNotAvailable},
+		{name: "year only", released: "1994", wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReleased(tt.released)
+			checkErr(t, err, tt.wantErr)
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseReleased(%q) = %v, want %v", tt.released, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBoxOffice(t *testing.T) {
+	tests := []struct {
+		name      string
+		boxOffice string
+		want      int64
+		wantErr   error
+	}{
+		{name: "dollars", boxOffice: "$28,767,189", want: 2876718900},
+		{name: "with cents", boxOffice: "$1,234.5", want: 123450},
+		{name: "no separators", boxOffice: "$500", want: 50000},
+		{name: "not available", boxOffice: "N/A", wantErr: ErrNotAvailable},
+		{name: "too many decimals", boxOffice: "$1.234", wantErr: errAny},
+		{name: "garbage", boxOffice: "$lots", wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBoxOffice(tt.boxOffice)
+			checkErr(t, err, tt.wantErr)
+			if got != tt.want {
+				t.Errorf("ParseBoxOffice(%q) = %v, want %v", tt.boxOffice, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMovie_Normalise runs Normalise over payloads returned by OMDb
+func TestMovie_Normalise(t *testing.T) {
+	tests := []struct {
+		file         string
+		wantRuntime  int
+		wantReleased time.Time
+		wantBoxOff   int64
+		wantScores   map[string]float64
+	}{
+		{
+			file:         "tt0111161.json",
+			wantRuntime:  142,
+			wantReleased: time.Date(1994, 10, 14, 0, 0, 0, 0, time.UTC),
+			wantBoxOff:   2876718900,
+			wantScores:   map[string]float64{SourceIMDb: 93, SourceRottenTomatoes: 89, SourceMetacritic: 82},
+		},
+		{
+			file:         "tt0078748.json",
+			wantRuntime:  117,
+			wantReleased: time.Date(1979, 6, 22, 0, 0, 0, 0, time.UTC),
+			wantBoxOff:   8420610600,
+			wantScores:   map[string]float64{SourceIMDb: 85, SourceRottenTomatoes: 93, SourceMetacritic: 89},
+		},
+		{
+			file:         "tt0062622.json",
+			wantRuntime:  149,
+			wantReleased: time.Date(1968, 5, 12, 0, 0, 0, 0, time.UTC),
+			wantBoxOff:   6048124300,
+			wantScores:   map[string]float64{SourceIMDb: 83, SourceRottenTomatoes: 92},
+		},
+		{
+			file:       "tt31193180.json",
+			wantScores: map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "omdb", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m Movie
+			if err := util.UnmarshalTo(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			m.Normalise()
+			if m.RuntimeMinutes != tt.wantRuntime {
+				t.Errorf("RuntimeMinutes = %v, want %v", m.RuntimeMinutes, tt.wantRuntime)
+			}
+			if !m.ReleasedAt.Equal(tt.wantReleased) {
+				t.Errorf("ReleasedAt = %v, want %v", m.ReleasedAt, tt.wantReleased)
+			}
+			if m.BoxOfficeCents != tt.wantBoxOff {
+				t.Errorf("BoxOfficeCents = %v, want %v", m.BoxOfficeCents, tt.wantBoxOff)
+			}
+			for _, source := range []string{SourceIMDb, SourceRottenTomatoes, SourceMetacritic} {
+				got, ok := m.RatingScore(source)
+				want, wantOK := tt.wantScores[source]
+				if ok != wantOK || !almostEqual(got, want) {
+					t.Errorf("RatingScore(%s) = %v (%v), want %v (%v)", source, got, ok, want, wantOK)
+				}
+			}
+		})
+	}
+}
+
+// errAny marks test cases expecting any error other than ErrNotAvailable
+var errAny = errors.New("any error")
+
+func checkErr(t *testing.T, err, want error) {
+	t.Helper()
+	switch {
+	case want == nil && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case want == errAny && (err == nil || errors.Is(err, ErrNotAvailable)):
+		t.Fatalf("expected parse error, got %v", err)
+	case want != nil && want != errAny && !errors.Is(err, want):
+		t.Fatalf("expected error %v, got %v", want, err)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	const epsilon = 1e-9
+	return a-b < epsilon && b-a < epsilon
+}
@@ -92,7 +92,7 @@ func (r MovieTitleRequest) SendRequest() (any, error) {
 
 // Validate validates title and year in request
 // Title must not be empty
-// Year must be 4 digits
+// Year must be empty or 4 digits
 func (r MovieTitleRequest) Validate() error {
 	return validateTitle(r.title, r.year)
 }
@@ -127,7 +127,7 @@ func validateTitle(title, year string) error {
 	if len(title) < 1 {
 		return fmt.Errorf("title %s is not valid", title)
 	}
-	if len(year) != 4 {
+	if year != "" && len(year) != 4 {
 		return fmt.Errorf("year %s is not a valid year", year)
 	}
 	return nil
@@ -174,7 +174,9 @@ func buildRequestURL(r MediaRequest) (string, error) {
 		values := reqURL.Query()
 		values.Add("type", "movie")
 		values.Add("t", v.title)
-		values.Add("y", v.year)
+		if v.year != "" {
+			values.Add("y", v.year)
+		}
 		reqURL.RawQuery = values.Encode()
 		return reqURL.String(), nil
 	case MovieIDRequest:
@@ -229,6 +231,9 @@ func UnmarshalResponse[MT MediaType](requestURL string) (MT, error) {
 	if err != nil {
 		return media, err
 	}
+	if n, ok := any(&media).(interface{ Normalise() }); ok {
+		n.Normalise()
+	}
 	return media, nil
 }
 
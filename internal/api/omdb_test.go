@@ -51,6 +51,14 @@ func Test_buildRequestURL(t *testing.T) {
 			want:    "http://www.omdbapi.com/?apikey=TESTKEY&type=movie&t=TestMovie&y=1984",
 			wantErr: false,
 		},
+		{
+			name: "Title without Year",
+			args: args{r: MovieTitleRequest{
+				title: "TestMovie",
+			}},
+			want:    "http://www.omdbapi.com/?apikey=TESTKEY&type=movie&t=TestMovie",
+			wantErr: false,
+		},
 		{
 			name:    "Default case",
 			args:    args{r: OmdbMock{}},
@@ -111,6 +119,13 @@ func TestOmdbTitleRequest_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid, no year",
+			fields: fields{
+				title: "The Thing",
+			},
+			wantErr: false,
+		},
 		{
 			name: "no valid year, too long",
 			fields: fields{
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"encoding/json"
+	"html/template"
+	"math"
 	"slices"
+	"strings"
+	"time"
 )
 
 // Entry holds data regarding user submitted info
@@ -24,8 +29,21 @@ func NewEntry(name string, watched bool, comment string) *Entry {
 }
 
 type MovieInfoData struct {
-	Movie *Movie
-	Entry []*Entry
+	Movie       *Movie
+	Entry       []*Entry
+	MyRating    float64
+	GroupRating GroupRating
+	// Local is set when a file of the movie was found in the media library
+	Local bool
+	Tags  []string
+}
+
+// ExportRecord is a movie with everything users stored about it
+type ExportRecord struct {
+	Movie       *Movie
+	Entries     []*Entry
+	WatchEvents []*WatchEvent
+	Ratings     []*UserRating
 }
 
 type SeriesInfoData struct {
@@ -46,16 +64,86 @@ func SortMovieSlice(movies []*MovieInfoData) {
 	})
 }
 
+// WatchEvent records a single viewing of a movie by a user
+// WatchedAt is nil for viewings without a known date
+type WatchEvent struct {
+	ID        int64
+	Username  string
+	MediaID   string
+	WatchedAt *time.Time
+	Note      string
+}
+
+const (
+	MinStarScore  = 0.5
+	MaxStarScore  = 5.0
+	StarScoreStep = 0.5
+)
+
+// UserRating is the personal star rating a user gave a movie
+type UserRating struct {
+	Username string
+	MediaID  string
+	Score    float64
+	RatedAt  time.Time
+}
+
+// ValidStarScore reports if score is within 0.5 and 5 stars in half star steps
+func ValidStarScore(score float64) bool {
+	if score < MinStarScore || score > MaxStarScore {
+		return false
+	}
+	return math.Mod(score, StarScoreStep) == 0
+}
+
+// StarScores returns all valid star scores in ascending order
+func StarScores() []float64 {
+	var scores []float64
+	for s := MinStarScore; s <= MaxStarScore; s += StarScoreStep {
+		scores = append(scores, s)
+	}
+	return scores
+}
+
+// GroupRating is the average of all personal ratings of a movie
+type GroupRating struct {
+	Average float64
+	Count   int
+}
+
 // MovieInfoPage holds necessary data for the InfoHandler
 type MovieInfoPage struct {
-	Entries []*Entry
-	Movie   *Movie
-	Error   error
+	Entries     []*Entry
+	WatchEvents []*WatchEvent
+	UserRatings []*UserRating
+	MyRating    float64
+	GroupRating GroupRating
+	StarScores  []float64
+	// RatingTrends holds a sparkline of the score history keyed by rating source
+	RatingTrends map[string]template.HTML
+	Movie        *Movie
+	Today        string
+	Error        error
+	// Similar are unwatched movies of the user's list sharing genres, actors or directors with the movie
+	Similar []*Recommendation
+	Tags    []string
+	// AllTags are the tags in use, offered when adding a tag
+	AllTags []string
+	// Credits link directors, writers and countries, nil if the movie is not stored
+	Credits *MediaCredits
 }
 
 type MovieOverviewData struct {
 	Movies []*MovieInfoData
 	Error  error
+	// Collections are listed above the movies, Collection is the one shown
+	Collections []*Collection
+	Collection  *Collection
+	AllTags     []string
+	// Query is the search shown, SavedSearch is set if it is a saved one
+	Query         string
+	SavedSearch   *SavedSearch
+	SavedSearches []*SavedSearch
 }
 
 type SeriesOverviewData struct {
@@ -68,9 +156,30 @@ type LoginData struct {
 }
 
 type SearchParams struct {
-	Genres []string
-	Actors []string
-	Years  YearSearch
+	Genres         []string
+	Actors         []string
+	Years          YearSearch
+	MinMyRating    float64
+	MinGroupRating float64
+	// Runtime limits the runtime in minutes, zero values are unbounded
+	Runtime RuntimeSearch
+	// MinScores maps rating sources to the minimum normalised score
+	MinScores map[string]float64
+	// Username is needed to filter by personal ratings
+	Username string
+	// Tags matches movies with any of the tags, AllTags movies with all of them
+	Tags    []string
+	AllTags []string
+	// Directors, Writers, Languages and Countries match movies with any of the names
+	Directors []string
+	Writers   []string
+	Languages []string
+	Countries []string
+}
+
+type RuntimeSearch struct {
+	Min int
+	Max int
 }
 
 type YearSearch struct {
@@ -83,3 +192,399 @@ type WatchStats struct {
 	NumOfUnwatched int
 	TotalMovies    int
 }
+
+// CountStat holds how many movies belong to a named group, e.g. a genre
+type CountStat struct {
+	Name  string
+	Count int
+}
+
+// StaleParams selects movies due for a metadata refresh
+// incomplete movies and recent releases use RecentRefreshedBefore, all others RefreshedBefore
+type StaleParams struct {
+	RefreshedBefore       time.Time
+	RecentRefreshedBefore time.Time
+	ReleasedAfter         time.Time
+	Limit                 int
+}
+
+// JobRun records the outcome of a single run of a background job
+type JobRun struct {
+	ID         int64     `json:"ID"`
+	Job        string    `json:"Job"`
+	StartedAt  time.Time `json:"StartedAt"`
+	FinishedAt time.Time `json:"FinishedAt"`
+	Processed  int       `json:"Processed"`
+	Failed     int       `json:"Failed"`
+	Requests   int       `json:"Requests"`
+	Error      string    `json:"Error"`
+}
+
+// LibraryList is the entry name of movies found in the media library
+const LibraryList = "Library"
+
+// Library item states
+const (
+	LibraryMatched   = "matched"
+	LibraryAmbiguous = "ambiguous"
+	LibraryUnmatched = "unmatched"
+)
+
+// LibraryItem is a video file found in the media library and the movie it was matched to
+type LibraryItem struct {
+	// Path is relative to the library directory
+	Path    string `json:"path"`
+	MediaID string `json:"media_id,omitempty"`
+	Title   string `json:"title"`
+	Year    int    `json:"year,omitempty"`
+	Status  string `json:"status"`
+	// Reason explains why a file is ambiguous or unmatched
+	Reason    string    `json:"reason,omitempty"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Event types published when users change the lists
+const (
+	EventMovieAdded   = "movie.added"
+	EventMovieDeleted = "movie.deleted"
+	EventMovieWatched = "movie.watched"
+	EventEntryCreated = "entry.created"
+	EventEntryUpdated = "entry.updated"
+	EventEntryDeleted = "entry.deleted"
+	// EventRatingChanged is published when a user sets or removes a star rating
+	EventRatingChanged = "rating.changed"
+)
+
+// EventTypes lists all event types, webhooks subscribe to a subset of them
+var EventTypes = []string{
+	EventMovieAdded, EventMovieDeleted, EventMovieWatched,
+	EventEntryCreated, EventEntryUpdated, EventEntryDeleted,
+	EventRatingChanged,
+}
+
+// Event is something a user did, it is stored and sent to webhooks as json
+type Event struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Username string `json:"user,omitempty"`
+	MediaID  string `json:"imdb_id"`
+	Title    string `json:"title,omitempty"`
+	// Data holds details of the event type, like entry name or watch date
+	Data      map[string]any `json:"data,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Webhook is an endpoint events are posted to
+type Webhook struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Secret signs the payloads, it is only returned when the webhook is created
+	Secret string `json:"secret,omitempty"`
+	// Events are the subscribed event types, empty subscribes to all
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is an event queued for a webhook and the log of its attempts
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	WebhookID     int64      `json:"webhook_id"`
+	EventID       int64      `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+
+	// set on due deliveries to send them
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+	Event  *Event `json:"-"`
+}
+
+// PickParams constrain the unwatched movies the picker chooses from, zero values do not constrain
+type PickParams struct {
+	Genres []string
+	// MaxRuntime is in minutes
+	MaxRuntime int
+	// MinIMDb is the minimum normalised IMDb score between 0 and 100
+	MinIMDb float64
+	// Decade is the first year of a decade, like 1990
+	Decade int
+	// ExcludeUser leaves out movies this user put on the list, "not recommended by me"
+	ExcludeUser string
+}
+
+// PickCandidate is an unwatched movie the picker can choose
+type PickCandidate struct {
+	MediaID string `json:"imdb_id"`
+	Title   string `json:"title"`
+	// Members is the number of members with the movie on their list
+	Members int `json:"members"`
+	// LastPickedAt is when the movie was suggested last, zero if never
+	LastPickedAt time.Time `json:"last_picked_at"`
+}
+
+// MediaFeatures are the genres, actors and directors of a movie, used to find similar movies
+type MediaFeatures struct {
+	MediaID   string
+	Title     string
+	Genres    []string
+	Actors    []string
+	Directors []string
+}
+
+// Recommendation is a movie ranked by its similarity to other movies
+type Recommendation struct {
+	MediaID string
+	Title   string
+	Score   float64
+	// Because is the title of the highly rated movie adding most to the score
+	Because string
+	// Shared lists the directors, actors and genres in common with it
+	Shared []string
+}
+
+const (
+	PollOpen   = "open"
+	PollClosed = "closed"
+)
+
+// Poll lets members rank movies for a movie night, it is shared by its random token
+type Poll struct {
+	ID        int64
+	Token     string
+	Title     string
+	CreatedBy string
+	Status    string
+	// WinnerID and WinnerTitle are set once the poll is closed
+	WinnerID    string
+	WinnerTitle string
+	Options     []*PollOption
+	// Voters are the members who cast a ballot
+	Voters    []string
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+}
+
+// PollOption is a movie to vote on
+type PollOption struct {
+	MediaID string
+	Title   string
+}
+
+// Ballot ranks the options of a poll, most preferred first
+type Ballot struct {
+	Username string
+	Ranking  []string
+}
+
+// Collection is a smart list of the movies tagged with any, or all, of its tags
+type Collection struct {
+	ID        int64
+	Name      string
+	Tags      []string
+	MatchAll  bool
+	CreatedBy string
+	// Count is the number of movies in the collection
+	Count int
+}
+
+// Matches reports whether a movie with tags belongs to the collection, tags are compared case insensitive
+func (c *Collection) Matches(tags []string) bool {
+	found := 0
+	for _, tag := range c.Tags {
+		if slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			found++
+		}
+	}
+	if c.MatchAll {
+		return found == len(c.Tags)
+	}
+	return found > 0
+}
+
+// SearchParams returns the search for the movies of the collection
+func (c *Collection) SearchParams() SearchParams {
+	if c.MatchAll {
+		return SearchParams{AllTags: c.Tags}
+	}
+	return SearchParams{Tags: c.Tags}
+}
+
+// SavedSearch is a named search query of a user, its movies are searched anew every time
+type SavedSearch struct {
+	ID        int64
+	Username  string
+	Name      string
+	Query     string
+	CreatedAt time.Time
+}
+
+// Person is an actor, director or writer of stored media
+type Person struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Country is a production country of stored media
+type Country struct {
+	ID   int64
+	Name string
+}
+
+// MediaCredits are the stored people and countries of a movie, used to link their pages
+type MediaCredits struct {
+	Actors    []*Person
+	Directors []*Person
+	Writers   []*Person
+	Countries []*Country
+}
+
+// CreditPage lists the movies of a person or country in sections like "Directed" and "Wrote"
+type CreditPage struct {
+	Title    string
+	Sections []CreditSection
+	Error    error
+}
+
+type CreditSection struct {
+	Heading string
+	Movies  []*MovieInfoData
+}
+
+// PersonFilm is a stored movie a person worked on with how often the group watched it and its group rating
+type PersonFilm struct {
+	MediaID string
+	Title   string
+	Year    string
+	// Roles are Actor, Director and Writer
+	Roles       []string
+	Watches     int
+	RatingCount int
+	// AverageRating is the average star score of the group, zero without ratings
+	AverageRating float64
+}
+
+// PersonPage lists the films of a person, Watched counts those the group watched
+// and AverageRating is the average of their group ratings
+type PersonPage struct {
+	Person        *Person
+	Films         []*PersonFilm
+	Watched       int
+	AverageRating float64
+	Error         error
+}
+
+// PersonAlias is a name which was merged into a person, stored media credited with it is credited to the person
+type PersonAlias struct {
+	Alias    string `json:"alias"`
+	PersonID int64  `json:"person_id"`
+	Name     string `json:"name"`
+}
+
+// TrashedMovie is a deleted movie in the trash, Entries counts the entries deleted with it
+type TrashedMovie struct {
+	MediaID   string
+	Title     string
+	Year      string
+	Entries   int
+	DeletedAt time.Time
+}
+
+// TrashedEntry is a deleted entry of a movie which is not in the trash itself
+type TrashedEntry struct {
+	ID        int64
+	MediaID   string
+	Title     string
+	Name      string
+	Watched   bool
+	DeletedAt time.Time
+}
+
+// TrashPage lists the trash, RetentionDays is zero when the trash is only emptied by hand
+type TrashPage struct {
+	Movies        []*TrashedMovie
+	Entries       []*TrashedEntry
+	RetentionDays int
+	IsAdmin       bool
+	Error         error
+}
+
+// SystemActor returns the audit actor of changes made by a background job or command line tool
+func SystemActor(name string) string {
+	return "system:" + name
+}
+
+// Audit actions, named after the changed object and what happened to it
+const (
+	AuditMovieCreated      = "movie.created"
+	AuditMovieUpdated      = "movie.updated"
+	AuditMovieTrashed      = "movie.trashed"
+	AuditMovieRestored     = "movie.restored"
+	AuditMoviePurged       = "movie.purged"
+	AuditEntryCreated      = "entry.created"
+	AuditEntryUpdated      = "entry.updated"
+	AuditEntryTrashed      = "entry.trashed"
+	AuditEntryRestored     = "entry.restored"
+	AuditEntryPurged       = "entry.purged"
+	AuditWatchCreated      = "watch.created"
+	AuditWatchDeleted      = "watch.deleted"
+	AuditRatingSet         = "rating.set"
+	AuditRatingDeleted     = "rating.deleted"
+	AuditPickRecorded      = "pick.recorded"
+	AuditPollCreated       = "poll.created"
+	AuditPollVoted         = "poll.voted"
+	AuditPollClosed        = "poll.closed"
+	AuditTagsAdded         = "tags.added"
+	AuditTagRemoved        = "tag.removed"
+	AuditCollectionCreated = "collection.created"
+	AuditCollectionDeleted = "collection.deleted"
+	AuditSearchCreated     = "search.created"
+	AuditSearchDeleted     = "search.deleted"
+	AuditUserRegistered    = "user.registered"
+	AuditUserActiveChanged = "user.active_changed"
+	AuditBackupRestored    = "backup.restored"
+	AuditWebhookCreated    = "webhook.created"
+	AuditWebhookDeleted    = "webhook.deleted"
+	AuditPeopleMerged      = "people.merged"
+	AuditLibrarySaved      = "library.saved"
+	AuditLibraryDeleted    = "library.deleted"
+)
+
+// AuditEntry records a change a user made, Before and After are the changed object as JSON
+// and null when it did not exist before or does not exist afterwards
+type AuditEntry struct {
+	ID     int64  `json:"id"`
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// Target identifies the changed object, like the id of an entry or the name of a tag
+	Target    string          `json:"target"`
+	MediaID   string          `json:"imdb_id,omitempty"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit entries, empty fields match all entries
+// From is inclusive and To exclusive, at most Limit entries are returned
+type AuditFilter struct {
+	Actor   string
+	MediaID string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
@@ -74,3 +74,55 @@ func TestSortMovieSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestValidStarScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		score float64
+		want  bool
+	}{
+		{name: "half star", score: 0.5, want: true},
+		{name: "full stars", score: 5, want: true},
+		{name: "in between", score: 3.5, want: true},
+		{name: "zero", score: 0, want: false},
+		{name: "too high", score: 5.5, want: false},
+		{name: "not a half step", score: 3.7, want: false},
+		{name: "negative", score: -1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidStarScore(tt.score); got != tt.want {
+				t.Errorf("ValidStarScore(%v) = %v, want %v", tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStarScores(t *testing.T) {
+	scores := StarScores()
+	if len(scores) != 10 || scores[0] != MinStarScore || scores[len(scores)-1] != MaxStarScore {
+		t.Errorf("StarScores() = %v, want 10 scores from %v to %v", scores, MinStarScore, MaxStarScore)
+	}
+}
+
+func TestCollection_Matches(t *testing.T) {
+	tests := []struct {
+		name       string
+		collection Collection
+		tags       []string
+		want       bool
+	}{
+		{"any of one", Collection{Tags: []string{"halloween", "christmas"}}, []string{"Christmas"}, true},
+		{"any of none", Collection{Tags: []string{"halloween"}}, []string{"christmas"}, false},
+		{"all of all", Collection{Tags: []string{"halloween", "seen in cinema"}, MatchAll: true}, []string{"Seen in Cinema", "halloween", "x"}, true},
+		{"all of one", Collection{Tags: []string{"halloween", "seen in cinema"}, MatchAll: true}, []string{"halloween"}, false},
+		{"untagged", Collection{Tags: []string{"halloween"}}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.collection.Matches(tt.tags); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -49,3 +50,18 @@ func HashPassword(password string) (string, error) {
 	}
 	return string(hashedPassword), nil
 }
+
+type contextKey string
+
+const userKey contextKey = "user"
+
+// WithUser returns a copy of ctx carrying the name of the logged in user
+func WithUser(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, userKey, username)
+}
+
+// UserFromContext returns the name of the logged in user stored by WithUser
+func UserFromContext(ctx context.Context) (string, bool) {
+	username, ok := ctx.Value(userKey).(string)
+	return username, ok && username != ""
+}
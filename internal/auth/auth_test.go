@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -93,3 +94,19 @@ func TestHashPassword(t *testing.T) {
 		}
 	})
 }
+
+func TestUserFromContext(t *testing.T) {
+	t.Run("WithUser", func(t *testing.T) {
+		ctx := WithUser(context.Background(), "testuser")
+		username, ok := UserFromContext(ctx)
+		if !ok || username != "testuser" {
+			t.Fatalf("expected testuser, got %q (ok=%v)", username, ok)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		if _, ok := UserFromContext(context.Background()); ok {
+			t.Fatalf("expected no user in empty context")
+		}
+	})
+}
@@ -0,0 +1,246 @@
+// Package backup takes scheduled snapshots of the database while the server is running
+// and restores them, keeping a limited number of snapshots in a directory
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// JobName identifies backup runs in the job history
+const JobName = "backup"
+
+// nameLayout formats the time of a snapshot in its file name, names sort by time
+const nameLayout = "20060102-150405"
+
+var validName = regexp.MustCompile(`^gomovie-\d{8}-\d{6}(-pre-restore)?\.sqlite$`)
+
+// ErrUnknownSnapshot is returned for names which are not snapshots of the backup directory
+var ErrUnknownSnapshot = errors.New("unknown snapshot")
+
+// Store is the part of the store used by the Backuper
+type Store interface {
+	Backup(path string) error
+	Restore(path string) error
+	CreateJobRun(run *api.JobRun) error
+}
+
+// Snapshot is a database copy in the backup directory
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Backuper writes a snapshot every interval and removes all but the newest keep scheduled ones
+type Backuper struct {
+	store    Store
+	dir      string
+	interval time.Duration
+	keep     int
+	now      func() time.Time
+}
+
+// NewBackuper returns a Backuper storing snapshots in dir
+func NewBackuper(store Store, dir string, interval time.Duration, keep int) *Backuper {
+	return &Backuper{
+		store:    store,
+		dir:      dir,
+		interval: interval,
+		keep:     keep,
+		now:      time.Now,
+	}
+}
+
+// WithStore returns a copy of the Backuper using store, like a store auditing changes for another actor
+func (b *Backuper) WithStore(store Store) *Backuper {
+	c := *b
+	c.store = store
+	return &c
+}
+
+// Name implements the server Job interface
+func (b *Backuper) Name() string {
+	return JobName
+}
+
+// Run writes a snapshot every interval until ctx is cancelled
+// the first one is written once the newest existing snapshot is an interval old, so restarts do not add snapshots
+func (b *Backuper) Run(ctx context.Context) {
+	if b.interval <= 0 {
+		slog.Info("scheduled backups disabled", "job", JobName)
+		return
+	}
+	wait := time.Duration(0)
+	if snapshots, err := b.Snapshots(); err == nil && len(snapshots) > 0 {
+		wait = max(b.interval-b.now().Sub(snapshots[0].CreatedAt), 0)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		run := b.RunOnce(ctx)
+		slog.Info("backup finished", "job", JobName, "processed", run.Processed, "failed", run.Failed, "err", run.Error)
+		timer.Reset(b.interval)
+	}
+}
+
+// RunOnce writes a snapshot, removes old ones and records the run
+// Processed counts written and Failed counts snapshots which could not be removed
+func (b *Backuper) RunOnce(ctx context.Context) *api.JobRun {
+	run := &api.JobRun{Job: JobName, StartedAt: b.now()}
+	if _, err := b.Snapshot(); err != nil {
+		run.Error = err.Error()
+	} else {
+		run.Processed++
+		run.Failed = b.prune()
+	}
+	run.FinishedAt = b.now()
+	if err := b.store.CreateJobRun(run); err != nil {
+		slog.Error("could not record job run", "job", JobName, "err", err.Error())
+	}
+	return run
+}
+
+// Snapshot writes a snapshot of the database right away
+func (b *Backuper) Snapshot() (Snapshot, error) {
+	return b.snapshot("")
+}
+
+func (b *Backuper) snapshot(suffix string) (Snapshot, error) {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return Snapshot{}, err
+	}
+	// names are unique, a snapshot taken in the same second as an earlier one is named a second later
+	var name, path string
+	for t := b.now().UTC(); ; t = t.Add(time.Second) {
+		name = "gomovie-" + t.Format(nameLayout) + suffix + ".sqlite"
+		path = filepath.Join(b.dir, name)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+	}
+	// the snapshot is written under a temporary name, so a partial file is never listed
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := b.store.Backup(tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("could not write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	return b.stat(name)
+}
+
+// Snapshots returns all snapshots in the backup directory, newest first
+func (b *Backuper) Snapshots() ([]Snapshot, error) {
+	files, err := os.ReadDir(b.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, f := range files {
+		if f.IsDir() || !validName.MatchString(f.Name()) {
+			continue
+		}
+		snapshot, err := b.stat(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+func (b *Backuper) stat(name string) (Snapshot, error) {
+	info, err := os.Stat(filepath.Join(b.dir, name))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	created, err := time.Parse(nameLayout, name[len("gomovie-"):len("gomovie-")+len(nameLayout)])
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Name: name, Size: info.Size(), CreatedAt: created}, nil
+}
+
+// Restore replaces the database with the named snapshot of the backup directory
+// the current state is saved as pre-restore snapshot first, so a restore can be undone
+func (b *Backuper) Restore(name string) (Snapshot, error) {
+	if !validName.MatchString(name) {
+		return Snapshot{}, ErrUnknownSnapshot
+	}
+	path := filepath.Join(b.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return Snapshot{}, ErrUnknownSnapshot
+	}
+	return b.RestoreFile(path)
+}
+
+// RestoreFile replaces the database with the snapshot at path and returns the pre-restore snapshot
+func (b *Backuper) RestoreFile(path string) (Snapshot, error) {
+	saved, err := b.snapshot("-pre-restore")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := b.store.Restore(path); err != nil {
+		// nothing changed, the saved copy is not needed
+		os.Remove(filepath.Join(b.dir, saved.Name))
+		return Snapshot{}, err
+	}
+	slog.Info("database restored", "job", JobName, "snapshot", path, "saved", saved.Name)
+	return saved, nil
+}
+
+// prune removes scheduled snapshots beyond the newest keep and returns how many could not be removed
+// pre-restore snapshots are kept until removed by hand
+func (b *Backuper) prune() int {
+	if b.keep <= 0 {
+		return 0
+	}
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		slog.Warn("could not list snapshots", "job", JobName, "err", err.Error())
+		return 1
+	}
+	failed := 0
+	kept := 0
+	for _, s := range snapshots {
+		if isPreRestore(s.Name) {
+			continue
+		}
+		if kept++; kept <= b.keep {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.dir, s.Name)); err != nil {
+			slog.Warn("could not remove snapshot", "job", JobName, "snapshot", s.Name, "err", err.Error())
+			failed++
+		}
+	}
+	return failed
+}
+
+func isPreRestore(name string) bool {
+	return validName.FindStringSubmatch(name)[1] != ""
+}
@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+type fakeStore struct {
+	restored   []string
+	restoreErr error
+	runs       []*api.JobRun
+}
+
+func (f *fakeStore) Backup(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.New("output file already exists")
+	}
+	return os.WriteFile(path, []byte("SQLite format 3\x00"), 0o644)
+}
+
+func (f *fakeStore) Restore(path string) error {
+	if f.restoreErr != nil {
+		return f.restoreErr
+	}
+	f.restored = append(f.restored, filepath.Base(path))
+	return nil
+}
+
+func (f *fakeStore) CreateJobRun(run *api.JobRun) error {
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+// newBackuper returns a Backuper whose clock advances by half an hour whenever it is read
+func newBackuper(t *testing.T, store *fakeStore, keep int) *Backuper {
+	t.Helper()
+	b := NewBackuper(store, t.TempDir(), time.Hour, keep)
+	clock := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time {
+		clock = clock.Add(30 * time.Minute)
+		return clock
+	}
+	return b
+}
+
+func names(t *testing.T, b *Backuper) []string {
+	t.Helper()
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range snapshots {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestBackuper_RunOnce(t *testing.T) {
+	store := &fakeStore{}
+	b := newBackuper(t, store, 2)
+	for range 3 {
+		if run := b.RunOnce(context.Background()); run.Error != "" || run.Processed != 1 {
+			t.Fatalf("run = %+v, want one snapshot without error", run)
+		}
+	}
+	os.WriteFile(filepath.Join(b.dir, "notes.txt"), []byte("not a snapshot"), 0o644)
+
+	want := []string{"gomovie-20261001-160000.sqlite", "gomovie-20261001-143000.sqlite"}
+	if diff := cmp.Diff(want, names(t, b)); diff != "" {
+		t.Errorf("snapshots mismatch (-want +got):\n%s", diff)
+	}
+	if len(store.runs) != 3 {
+		t.Errorf("recorded runs = %d, want 3", len(store.runs))
+	}
+}
+
+func TestBackuper_Restore(t *testing.T) {
+	store := &fakeStore{}
+	b := newBackuper(t, store, 1)
+	snapshot, err := b.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := b.Restore(snapshot.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{snapshot.Name}, store.restored); diff != "" {
+		t.Errorf("restored mismatch (-want +got):\n%s", diff)
+	}
+	if saved.Name != "gomovie-20261001-130000-pre-restore.sqlite" {
+		t.Errorf("saved snapshot = %s, want a pre-restore snapshot", saved.Name)
+	}
+
+	// pre-restore snapshots are not pruned
+	b.RunOnce(context.Background())
+	want := []string{"gomovie-20261001-140000.sqlite", "gomovie-20261001-130000-pre-restore.sqlite"}
+	if diff := cmp.Diff(want, names(t, b)); diff != "" {
+		t.Errorf("snapshots mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("unknown snapshots", func(t *testing.T) {
+		for _, name := range []string{"../gomovie.sqlite", "gomovie-20261001-120000.sqlite", "notes.txt"} {
+			if _, err := b.Restore(name); !errors.Is(err, ErrUnknownSnapshot) {
+				t.Errorf("Restore(%q) error = %v, want ErrUnknownSnapshot", name, err)
+			}
+		}
+	})
+
+	t.Run("failed restore keeps no pre-restore snapshot", func(t *testing.T) {
+		store.restoreErr = errors.New("snapshot schema version does not match")
+		before := names(t, b)
+		if _, err := b.Restore(before[0]); err == nil {
+			t.Fatal("Restore() succeeded, want error")
+		}
+		if diff := cmp.Diff(before, names(t, b)); diff != "" {
+			t.Errorf("snapshots mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestBackuper_SnapshotSameSecond(t *testing.T) {
+	b := NewBackuper(&fakeStore{}, t.TempDir(), time.Hour, 0)
+	b.now = func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) }
+	for range 2 {
+		if _, err := b.Snapshot(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"gomovie-20261001-120001.sqlite", "gomovie-20261001-120000.sqlite"}
+	if diff := cmp.Diff(want, names(t, b)); diff != "" {
+		t.Errorf("snapshots mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,230 @@
+// Package chart renders small SVG charts on the server
+// the returned markup is meant to be inlined into templates, no JavaScript needed
+package chart
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	chartWidth  = 400
+	labelWidth  = 120
+	barHeight   = 22
+	barGap      = 6
+	pieRadius   = 90
+	histHeight  = 200
+	axisPadding = 20
+)
+
+// palette is cycled through when drawing bars and slices
+var palette = []string{
+	"#3498db", "#e74c3c", "#2ecc71", "#f1c40f",
+	"#9b59b6", "#1abc9c", "#e67e22", "#34495e",
+}
+
+// Point is a single labelled value
+type Point struct {
+	Label string
+	Value float64
+}
+
+// Bin is a histogram bucket holding all values in [Start, End)
+type Bin struct {
+	Start float64
+	End   float64
+	Count int
+}
+
+// Bar renders a horizontal bar chart with one bar per point
+// bars are scaled relative to the largest value
+func Bar(points []Point) template.HTML {
+	if len(points) == 0 {
+		return empty()
+	}
+	height := len(points)*(barHeight+barGap) + barGap
+	maxVal := maxValue(points)
+	scale := float64(chartWidth-labelWidth-40) / maxVal
+
+	var b strings.Builder
+	open(&b, "bar", chartWidth, height)
+	for i, p := range points {
+		y := barGap + i*(barHeight+barGap)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`,
+			labelWidth-6, y+barHeight-6, esc(p.Label))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%s" height="%d" fill="%s"/>`,
+			labelWidth, y, num(p.Value*scale), barHeight, color(i))
+		fmt.Fprintf(&b, `<text x="%s" y="%d">%s</text>`,
+			num(float64(labelWidth)+p.Value*scale+4), y+barHeight-6, num(p.Value))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// Pie renders a pie chart with a legend next to it
+// points with a value of zero or less are left out
+func Pie(points []Point) template.HTML {
+	var total float64
+	var slices []Point
+	for _, p := range points {
+		if p.Value > 0 {
+			total += p.Value
+			slices = append(slices, p)
+		}
+	}
+	if total == 0 {
+		return empty()
+	}
+	cx, cy := float64(pieRadius+10), float64(pieRadius+10)
+	height := 2*pieRadius + 20
+	if h := len(slices)*(barHeight+barGap) + barGap; h > height {
+		height = h
+	}
+
+	var b strings.Builder
+	open(&b, "pie", chartWidth, height)
+	if len(slices) == 1 {
+		fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="%d" fill="%s"/>`, num(cx), num(cy), pieRadius, color(0))
+	} else {
+		angle := -math.Pi / 2
+		for i, p := range slices {
+			sweep := p.Value / total * 2 * math.Pi
+			x1, y1 := cx+pieRadius*math.Cos(angle), cy+pieRadius*math.Sin(angle)
+			x2, y2 := cx+pieRadius*math.Cos(angle+sweep), cy+pieRadius*math.Sin(angle+sweep)
+			large := 0
+			if sweep > math.Pi {
+				large = 1
+			}
+			fmt.Fprintf(&b, `<path d="M%s,%s L%s,%s A%d,%d 0 %d,1 %s,%s Z" fill="%s"/>`,
+				num(cx), num(cy), num(x1), num(y1), pieRadius, pieRadius, large, num(x2), num(y2), color(i))
+			angle += sweep
+		}
+	}
+	legendX := 2*pieRadius + 40
+	for i, p := range slices {
+		y := barGap + i*(barHeight+barGap)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="14" height="14" fill="%s"/>`, legendX, y+4, color(i))
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s (%s%%)</text>`,
+			legendX+20, y+barHeight-6, esc(p.Label), num(p.Value/total*100))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// Bins sorts values into buckets of the given width
+// the first bucket starts at the smallest value rounded down to a multiple of width
+func Bins(values []float64, width float64) []Bin {
+	if len(values) == 0 || width <= 0 {
+		return nil
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	start := math.Floor(lo/width) * width
+	bins := make([]Bin, int((hi-start)/width)+1)
+	for i := range bins {
+		bins[i].Start = start + float64(i)*width
+		bins[i].End = bins[i].Start + width
+	}
+	for _, v := range values {
+		bins[int((v-start)/width)].Count++
+	}
+	return bins
+}
+
+// Histogram renders values as vertical bars of buckets with the given width
+// each bucket is labelled with its lower bound
+func Histogram(values []float64, width float64) template.HTML {
+	bins := Bins(values, width)
+	if len(bins) == 0 {
+		return empty()
+	}
+	maxCount := 0
+	for _, bin := range bins {
+		maxCount = max(maxCount, bin.Count)
+	}
+	slot := float64(chartWidth-2*axisPadding) / float64(len(bins))
+	scale := float64(histHeight-2*axisPadding) / float64(maxCount)
+	baseline := histHeight - axisPadding
+
+	var b strings.Builder
+	open(&b, "histogram", chartWidth, histHeight)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`,
+		axisPadding, baseline, chartWidth-axisPadding, baseline)
+	for i, bin := range bins {
+		x := float64(axisPadding) + float64(i)*slot
+		h := float64(bin.Count) * scale
+		fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"><title>%s-%s: %d</title></rect>`,
+			num(x+1), num(float64(baseline)-h), num(slot-2), num(h), color(0),
+			num(bin.Start), num(bin.End), bin.Count)
+		fmt.Fprintf(&b, `<text x="%s" y="%d" text-anchor="middle">%s</text>`,
+			num(x+slot/2), histHeight-4, num(bin.Start))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// Sparkline renders a small line of values without axes or labels, scaled to [lo, hi]
+// fewer than two values render nothing
+func Sparkline(values []float64, lo, hi float64) template.HTML {
+	if len(values) < 2 || hi <= lo {
+		return ""
+	}
+	const width, height = 100, 20
+	step := float64(width) / float64(len(values)-1)
+	points := make([]string, len(values))
+	for i, v := range values {
+		v = math.Max(lo, math.Min(hi, v))
+		y := float64(height) - (v-lo)/(hi-lo)*float64(height)
+		points[i] = num(float64(i)*step) + "," + num(y)
+	}
+	last := values[len(values)-1]
+	trend := color(2)
+	if last < values[0] {
+		trend = color(1)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" class="chart chart-sparkline" width="%d" height="%d" viewBox="-2 -2 %d %d">`,
+		width, height, width+4, height+4)
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), trend)
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func open(b *strings.Builder, class string, width, height int) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" class="chart chart-%s" width="%d" height="%d" viewBox="0 0 %d %d" font-size="12" font-family="sans-serif">`,
+		class, width, height, width, height)
+}
+
+func empty() template.HTML {
+	return template.HTML(`<svg xmlns="http://www.w3.org/2000/svg" class="chart chart-empty" width="400" height="30" viewBox="0 0 400 30" font-size="12" font-family="sans-serif"><text x="200" y="20" text-anchor="middle">no data</text></svg>`)
+}
+
+func maxValue(points []Point) float64 {
+	m := 0.0
+	for _, p := range points {
+		m = math.Max(m, p.Value)
+	}
+	if m == 0 {
+		return 1
+	}
+	return m
+}
+
+func color(i int) string {
+	return palette[i%len(palette)]
+}
+
+// num formats floats with at most two decimals so the output stays stable
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+func esc(s string) string {
+	return template.HTMLEscapeString(s)
+}
@@ -0,0 +1,148 @@
+package chart
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed reading golden file: %v", err)
+	}
+	if diff := cmp.Diff(string(want), got); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+	}
+}
+
+func TestBar(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+	}{
+		{
+			name:   "bar_genres",
+			points: []Point{{"Drama", 12}, {"Horror", 7}, {"Sci-Fi", 3}},
+		},
+		{
+			name:   "bar_escaping",
+			points: []Point{{"<b>Action & Adventure</b>", 1}},
+		},
+		{
+			name:   "bar_empty",
+			points: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, string(Bar(tt.points)))
+		})
+	}
+}
+
+func TestPie(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+	}{
+		{
+			name:   "pie_watched",
+			points: []Point{{"Watched", 3}, {"Unwatched", 1}},
+		},
+		{
+			name:   "pie_single_slice",
+			points: []Point{{"Watched", 5}, {"Unwatched", 0}},
+		},
+		{
+			name:   "pie_large_slice",
+			points: []Point{{"A", 9}, {"B", 1}, {"C", 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, string(Pie(tt.points)))
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	years := []float64{1968, 1979, 1982, 1982, 1994, 1999, 2001, 2019}
+	assertGolden(t, "histogram_decades", string(Histogram(years, 10)))
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+	}{
+		{name: "sparkline_rising", values: []float64{70, 74, 78, 81}},
+		{name: "sparkline_falling", values: []float64{92, 88, 85}},
+		{name: "sparkline_clamped", values: []float64{-10, 50, 150}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, string(Sparkline(tt.values, 0, 100)))
+		})
+	}
+	if got := Sparkline([]float64{80}, 0, 100); got != "" {
+		t.Errorf("Sparkline() with a single value = %q, want empty", got)
+	}
+}
+
+func TestBins(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		width  float64
+		want   []Bin
+	}{
+		{
+			name:   "decades",
+			values: []float64{1971, 1979, 1980, 1999},
+			width:  10,
+			want: []Bin{
+				{Start: 1970, End: 1980, Count: 2},
+				{Start: 1980, End: 1990, Count: 1},
+				{Start: 1990, End: 2000, Count: 1},
+			},
+		},
+		{
+			name:   "single value",
+			values: []float64{2005},
+			width:  10,
+			want:   []Bin{{Start: 2000, End: 2010, Count: 1}},
+		},
+		{
+			name:   "no values",
+			values: nil,
+			width:  10,
+			want:   nil,
+		},
+		{
+			name:   "invalid width",
+			values: []float64{1, 2},
+			width:  0,
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Bins(tt.values, tt.width)); diff != "" {
+				t.Errorf("Bins() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -29,6 +31,30 @@ type Config struct {
 	AdminPW    string
 	DbType     string
 	DbConfig   DBConfig
+	// PosterDir is where downloaded posters are stored
+	PosterDir string
+
+	// RefreshInterval is the time between background refreshes of stale movies
+	RefreshInterval time.Duration
+	// OmdbDailyQuota is the number of OMDb requests background jobs may send per day, 0 disables them
+	OmdbDailyQuota int
+
+	// BackupDir is where database snapshots are stored
+	BackupDir string
+	// BackupInterval is the time between scheduled snapshots, 0 disables them
+	BackupInterval time.Duration
+	// BackupKeep is the number of scheduled snapshots kept, 0 keeps all
+	BackupKeep int
+
+	// TrashDays is how long deleted movies and entries stay in the trash, 0 keeps them until purged by hand
+	TrashDays int
+
+	// LibraryDir is the media folder scanned for movie files, empty disables scans
+	LibraryDir string
+	// LibraryInterval is the time between scans of the media folder
+	LibraryInterval time.Duration
+	// LibraryWriteNFO writes NFO files for matched movies which have none
+	LibraryWriteNFO bool
 
 	Valid bool
 }
@@ -75,6 +101,73 @@ func initConfig() Config {
 			}
 		}
 	}
+	posterDir, err := GetEnv("POSTER_DIR", "./posters")
+	if err != nil {
+		valid = false
+	}
+	refreshInterval, err := GetEnv("REFRESH_INTERVAL", "1h")
+	if err != nil {
+		valid = false
+	}
+	interval, err := time.ParseDuration(refreshInterval)
+	if err != nil || interval <= 0 {
+		valid = false
+	}
+	dailyQuota, err := GetEnv("OMDB_DAILY_QUOTA", "500")
+	if err != nil {
+		valid = false
+	}
+	quota, err := strconv.Atoi(dailyQuota)
+	if err != nil || quota < 0 {
+		valid = false
+	}
+	backupDir, err := GetEnv("BACKUP_DIR", "./backups")
+	if err != nil {
+		valid = false
+	}
+	backupInterval, err := GetEnv("BACKUP_INTERVAL", "24h")
+	if err != nil {
+		valid = false
+	}
+	backupEvery, err := time.ParseDuration(backupInterval)
+	if err != nil || backupEvery < 0 {
+		valid = false
+	}
+	backupKeep, err := GetEnv("BACKUP_KEEP", "7")
+	if err != nil {
+		valid = false
+	}
+	keep, err := strconv.Atoi(backupKeep)
+	if err != nil || keep < 0 {
+		valid = false
+	}
+	trashDays, err := GetEnv("TRASH_DAYS", "30")
+	if err != nil {
+		valid = false
+	}
+	days, err := strconv.Atoi(trashDays)
+	if err != nil || days < 0 {
+		valid = false
+	}
+	// the library is optional, a missing LIBRARY_DIR disables scans
+	libraryDir, _ := GetEnv("LIBRARY_DIR", "")
+	libraryInterval, err := GetEnv("LIBRARY_INTERVAL", "6h")
+	if err != nil {
+		valid = false
+	}
+	libraryEvery, err := time.ParseDuration(libraryInterval)
+	if err != nil || libraryEvery <= 0 {
+		valid = false
+	}
+	libraryWriteNFO, err := GetEnv("LIBRARY_WRITE_NFO", "false")
+	if err != nil {
+		valid = false
+	}
+	writeNFO, err := strconv.ParseBool(libraryWriteNFO)
+	if err != nil {
+		valid = false
+	}
+
 	return Config{
 		Addr:       addr,
 		OmdbApiKey: omdbKey,
@@ -83,7 +176,22 @@ func initConfig() Config {
 		AdminPW:    adminPw,
 		DbConfig:   dbConfig,
 		DbType:     dbType,
-		Valid:      valid,
+		PosterDir:  posterDir,
+
+		RefreshInterval: interval,
+		OmdbDailyQuota:  quota,
+
+		BackupDir:      backupDir,
+		BackupInterval: backupEvery,
+		BackupKeep:     keep,
+
+		TrashDays: days,
+
+		LibraryDir:      libraryDir,
+		LibraryInterval: libraryEvery,
+		LibraryWriteNFO: writeNFO,
+
+		Valid: valid,
 	}
 }
 
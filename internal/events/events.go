@@ -0,0 +1,76 @@
+// Package events records what users do with their lists and passes the events on to subscribers
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before events are dropped for it
+const subscriberBuffer = 64
+
+// Store is the part of the store used by the Bus
+type Store interface {
+	CreateEvent(event *api.Event) error
+}
+
+// Bus stores published events, which queues them for webhooks, and hands them to subscribers
+type Bus struct {
+	store Store
+	now   func() time.Time
+
+	mu   sync.Mutex
+	subs map[chan *api.Event]struct{}
+}
+
+// NewBus returns a Bus storing events in store
+func NewBus(store Store) *Bus {
+	return &Bus{
+		store: store,
+		now:   time.Now,
+		subs:  make(map[chan *api.Event]struct{}),
+	}
+}
+
+// Publish stores the event and sends it to all subscribers
+// publishing on a nil Bus does nothing, so events are optional for callers
+func (b *Bus) Publish(e *api.Event) error {
+	if b == nil {
+		return nil
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = b.now()
+	}
+	if err := b.store.CreateEvent(e); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		select {
+		case sub <- e:
+		default:
+			// the subscriber is busy, it has to catch up from the store
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving every event published from now on
+// cancel must be called once the events are no longer read
+func (b *Bus) Subscribe() (events <-chan *api.Event, cancel func()) {
+	sub := make(chan *api.Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+		})
+	}
+}
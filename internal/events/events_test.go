@@ -0,0 +1,84 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+type fakeStore struct {
+	events []*api.Event
+	err    error
+}
+
+func (f *fakeStore) CreateEvent(e *api.Event) error {
+	if f.err != nil {
+		return f.err
+	}
+	e.ID = int64(len(f.events) + 1)
+	f.events = append(f.events, e)
+	return nil
+}
+
+func TestBus_Publish(t *testing.T) {
+	store := &fakeStore{}
+	bus := NewBus(store)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	bus.now = func() time.Time { return now }
+
+	events, cancel := bus.Subscribe()
+	if err := bus.Publish(&api.Event{Type: api.EventEntryCreated, MediaID: "tt0078748"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if e.ID != 1 || !e.CreatedAt.Equal(now) {
+			t.Errorf("event = %+v, want stored event with time", e)
+		}
+	default:
+		t.Fatal("subscriber received no event")
+	}
+
+	cancel()
+	cancel()
+	if err := bus.Publish(&api.Event{Type: api.EventEntryDeleted}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("cancelled subscriber received %+v", e)
+	default:
+	}
+
+	t.Run("store errors are returned and nothing is sent", func(t *testing.T) {
+		store.err = errors.New("database is locked")
+		events, cancel := bus.Subscribe()
+		defer cancel()
+		if err := bus.Publish(&api.Event{Type: api.EventMovieAdded}); err == nil {
+			t.Error("Publish() succeeded, want error")
+		}
+		if len(events) != 0 {
+			t.Errorf("subscriber received %d events, want none", len(events))
+		}
+	})
+
+	t.Run("slow subscribers do not block", func(t *testing.T) {
+		store.err = nil
+		_, cancel := bus.Subscribe()
+		defer cancel()
+		for range subscriberBuffer + 1 {
+			if err := bus.Publish(&api.Event{Type: api.EventMovieWatched}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("nil bus", func(t *testing.T) {
+		var bus *Bus
+		if err := bus.Publish(&api.Event{Type: api.EventMovieAdded}); err != nil {
+			t.Errorf("Publish() error = %v, want nil", err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+var csvHeader = []string{
+	"imdb_id", "title", "year", "genre", "director", "runtime_minutes",
+	"user", "watched", "watch_count", "last_watched", "rating", "rated_at", "comment",
+}
+
+// writeCSV writes a row per movie and user with an entry, viewing or rating of it
+// movies nobody added are written once without user
+func writeCSV(w io.Writer, src Source) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	err := src.ExportMovies(func(record *api.ExportRecord) error {
+		for _, user := range users(record) {
+			if err := cw.Write(csvRow(record, user)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// users returns everyone who stored something about the movie in alphabetical order
+func users(record *api.ExportRecord) []string {
+	var users []string
+	for _, e := range record.Entries {
+		users = append(users, e.Name)
+	}
+	for _, e := range record.WatchEvents {
+		users = append(users, e.Username)
+	}
+	for _, r := range record.Ratings {
+		users = append(users, r.Username)
+	}
+	if len(users) == 0 {
+		return []string{""}
+	}
+	slices.Sort(users)
+	return slices.Compact(users)
+}
+
+func csvRow(record *api.ExportRecord, user string) []string {
+	mov := record.Movie
+	var watched bool
+	var comment string
+	for _, e := range record.Entries {
+		if e.Name == user {
+			watched = watched || e.Watched
+			comment = string(e.Comment)
+		}
+	}
+	viewings := userViewings(record, user)
+	var lastWatched string
+	for _, e := range viewings {
+		if e.WatchedAt != nil {
+			lastWatched = e.WatchedAt.Format(time.DateOnly)
+		}
+	}
+	var rating, ratedAt string
+	if r := userRating(record, user); r != nil {
+		rating = score(r.Score)
+		ratedAt = r.RatedAt.UTC().Format(time.DateOnly)
+	}
+	var runtime string
+	if mov.RuntimeMinutes > 0 {
+		runtime = strconv.Itoa(mov.RuntimeMinutes)
+	}
+	return []string{
+		mov.ImdbID, mov.Title, mov.Year, mov.Genre, mov.Director, runtime,
+		user, strconv.FormatBool(watched || len(viewings) > 0), strconv.Itoa(len(viewings)), lastWatched, rating, ratedAt, comment,
+	}
+}
+
+// userViewings returns the viewings of a user, undated ones first and the others oldest first
+func userViewings(record *api.ExportRecord, user string) []*api.WatchEvent {
+	var viewings []*api.WatchEvent
+	for _, e := range record.WatchEvents {
+		if e.Username == user {
+			viewings = append(viewings, e)
+		}
+	}
+	slices.SortStableFunc(viewings, func(a, b *api.WatchEvent) int {
+		switch {
+		case a.WatchedAt == nil && b.WatchedAt == nil:
+			return 0
+		case a.WatchedAt == nil:
+			return -1
+		case b.WatchedAt == nil:
+			return 1
+		}
+		return a.WatchedAt.Compare(*b.WatchedAt)
+	})
+	return viewings
+}
+
+func userRating(record *api.ExportRecord, user string) *api.UserRating {
+	for _, r := range record.Ratings {
+		if r.Username == user {
+			return r
+		}
+	}
+	return nil
+}
+
+func score(s float64) string {
+	return strconv.FormatFloat(s, 'f', -1, 64)
+}
+
+var letterboxdHeader = []string{"imdbID", "Title", "Year", "Rating", "WatchedDate", "Rewatch", "Review"}
+
+// writeLetterboxd writes the films of a user for the Letterboxd importer
+// every viewing becomes a diary row carrying the rating, later viewings are marked as rewatch
+// rated or watched movies without a logged viewing get a single undated row, watchlist entries are left out
+func writeLetterboxd(w io.Writer, src Source, user string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(letterboxdHeader); err != nil {
+		return err
+	}
+	err := src.ExportMovies(func(record *api.ExportRecord) error {
+		for _, row := range letterboxdRows(record, user) {
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func letterboxdRows(record *api.ExportRecord, user string) [][]string {
+	mov := record.Movie
+	var rating string
+	if r := userRating(record, user); r != nil {
+		rating = score(r.Score)
+	}
+	var rows [][]string
+	for i, e := range userViewings(record, user) {
+		var watchedAt string
+		if e.WatchedAt != nil {
+			watchedAt = e.WatchedAt.Format(time.DateOnly)
+		}
+		rows = append(rows, []string{mov.ImdbID, mov.Title, mov.Year, rating, watchedAt, strconv.FormatBool(i > 0), e.Note})
+	}
+	if len(rows) > 0 {
+		return rows
+	}
+	watched := rating != ""
+	for _, e := range record.Entries {
+		watched = watched || e.Name == user && e.Watched
+	}
+	if !watched {
+		return nil
+	}
+	return [][]string{{mov.ImdbID, mov.Title, mov.Year, rating, "", "false", ""}}
+}
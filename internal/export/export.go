@@ -0,0 +1,77 @@
+// Package export writes all movies with their entries, viewings and personal ratings
+// as JSON document, flat CSV or csv for the Letterboxd importer
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// Format is an export file format
+type Format string
+
+const (
+	// JSON keeps everything and can be imported again
+	JSON Format = "json"
+	// CSV has a row per movie and user for spreadsheets
+	CSV Format = "csv"
+	// Letterboxd has a row per viewing of a single user in the Letterboxd import format
+	Letterboxd Format = "letterboxd"
+)
+
+// ErrNoUser is returned for Letterboxd exports without a user
+var ErrNoUser = errors.New("letterboxd export needs a user")
+
+// ParseFormat returns the format with the given name, an empty name selects JSON
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case "":
+		return JSON, nil
+	case JSON, CSV, Letterboxd:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", name)
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	if f == JSON {
+		return "application/json"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename returns the suggested name of an export file
+func (f Format) Filename() string {
+	switch f {
+	case JSON:
+		return "gomovie.json"
+	case Letterboxd:
+		return "gomovie-letterboxd.csv"
+	}
+	return "gomovie.csv"
+}
+
+// Source streams the records to export, implemented by the store
+type Source interface {
+	ExportMovies(fn func(*api.ExportRecord) error) error
+}
+
+// Write streams all records of src to w, username selects the user of Letterboxd exports
+func Write(w io.Writer, src Source, format Format, username string) error {
+	switch format {
+	case JSON:
+		return writeJSON(w, src)
+	case CSV:
+		return writeCSV(w, src)
+	case Letterboxd:
+		if username == "" {
+			return ErrNoUser
+		}
+		return writeLetterboxd(w, src, username)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
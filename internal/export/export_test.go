@@ -0,0 +1,137 @@
+package export
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// records is a Source of fixed records
+type records []*api.ExportRecord
+
+func (r records) ExportMovies(fn func(*api.ExportRecord) error) error {
+	for _, record := range r {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func date(s string) *time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return &t
+}
+
+var testRecords = records{
+	{
+		Movie: &api.Movie{ImdbID: "tt0078748", Title: "Alien", Year: "1979", Genre: "Horror, Sci-Fi", Director: "Ridley Scott"},
+		Entries: []*api.Entry{
+			api.NewEntry("alice", true, "scary, \"very\""),
+		},
+		WatchEvents: []*api.WatchEvent{
+			{Username: "alice", MediaID: "tt0078748", WatchedAt: date("2024-06-02"), Note: "director's cut"},
+			{Username: "alice", MediaID: "tt0078748", WatchedAt: date("2024-05-09")},
+			{Username: "bob", MediaID: "tt0078748"},
+		},
+		Ratings: []*api.UserRating{
+			{Username: "alice", MediaID: "tt0078748", Score: 4.5, RatedAt: time.Date(2024, 6, 2, 20, 0, 0, 0, time.UTC)},
+		},
+	},
+	{
+		Movie:   &api.Movie{ImdbID: "tt31193180", Title: "Sinners", Year: "2025"},
+		Entries: []*api.Entry{api.NewEntry("alice", false, "")},
+	},
+	{
+		Movie: &api.Movie{ImdbID: "tt0062622", Title: "2001: A Space Odyssey", Year: "1968"},
+	},
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var buf strings.Builder
+	if err := Write(&buf, testRecords, JSON, ""); err != nil {
+		t.Fatal(err)
+	}
+	var got records
+	err := Read(strings.NewReader(buf.String()), func(record *api.ExportRecord) error {
+		got = append(got, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(testRecords, got); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+	}{
+		{"empty", `{"version":1,"movies":[]}`, false},
+		{"unknown fields", `{"version":1,"generator":{"name":"gomovie"},"movies":[{"movie":{"imdbID":"tt0078748"},"tags":[]}]}`, false},
+		{"newer version", `{"version":2,"movies":[]}`, true},
+		{"movie without id", `{"version":1,"movies":[{"movie":{"Title":"Alien"}}]}`, true},
+		{"invalid date", `{"version":1,"movies":[{"movie":{"imdbID":"tt0078748"},"watch_events":[{"user":"alice","watched_at":"09.05.2024"}]}]}`, true},
+		{"no object", `[]`, true},
+		{"truncated", `{"version":1,"movies":[{"movie":{"imdbID":"tt0078748"}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Read(strings.NewReader(tt.doc), func(*api.ExportRecord) error { return nil })
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrite_CSV(t *testing.T) {
+	var buf strings.Builder
+	if err := Write(&buf, testRecords, CSV, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := "imdb_id,title,year,genre,director,runtime_minutes,user,watched,watch_count,last_watched,rating,rated_at,comment\n" +
+		"tt0078748,Alien,1979,\"Horror, Sci-Fi\",Ridley Scott,,alice,true,2,2024-06-02,4.5,2024-06-02,\"scary, \"\"very\"\"\"\n" +
+		"tt0078748,Alien,1979,\"Horror, Sci-Fi\",Ridley Scott,,bob,true,1,,,,\n" +
+		"tt31193180,Sinners,2025,,,,alice,false,0,,,,\n" +
+		"tt0062622,2001: A Space Odyssey,1968,,,,,false,0,,,,\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("csv mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrite_Letterboxd(t *testing.T) {
+	var buf strings.Builder
+	if err := Write(&buf, testRecords, Letterboxd, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	want := "imdbID,Title,Year,Rating,WatchedDate,Rewatch,Review\n" +
+		"tt0078748,Alien,1979,4.5,2024-05-09,false,\n" +
+		"tt0078748,Alien,1979,4.5,2024-06-02,true,director's cut\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("letterboxd mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := Write(&buf, testRecords, Letterboxd, ""); !errors.Is(err, ErrNoUser) {
+		t.Errorf("Write() without user error = %v, want ErrNoUser", err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"": JSON, "json": JSON, "csv": CSV, "letterboxd": Letterboxd} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) succeeded, want error")
+	}
+}
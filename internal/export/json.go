@@ -0,0 +1,180 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// Version is written to JSON exports, Read rejects newer documents
+const Version = 1
+
+// jsonRecord is a movie of a JSON export, the movie is kept in the OMDb format
+type jsonRecord struct {
+	Movie       *api.Movie  `json:"movie"`
+	Entries     []jsonEntry `json:"entries"`
+	WatchEvents []jsonWatch `json:"watch_events"`
+	Ratings     []jsonScore `json:"ratings"`
+}
+
+type jsonEntry struct {
+	User    string `json:"user"`
+	Watched bool   `json:"watched"`
+	Comment string `json:"comment"`
+}
+
+type jsonWatch struct {
+	User string `json:"user"`
+	// WatchedAt is a date like 2024-05-09, empty for undated viewings
+	WatchedAt string `json:"watched_at,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+type jsonScore struct {
+	User    string    `json:"user"`
+	Score   float64   `json:"score"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+// writeJSON writes a document of the form {"version": 1, "exported_at": ..., "movies": [...]}
+// with one movie per line, every record is encoded as soon as it is read
+func writeJSON(w io.Writer, src Source) error {
+	_, err := fmt.Fprintf(w, "{\"version\":%d,\"exported_at\":%q,\"movies\":[", Version, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	sep := "\n"
+	err = src.ExportMovies(func(record *api.ExportRecord) error {
+		data, err := json.Marshal(toJSON(record))
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		sep = ",\n"
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n]}\n")
+	return err
+}
+
+func toJSON(record *api.ExportRecord) jsonRecord {
+	out := jsonRecord{
+		Movie:       record.Movie,
+		Entries:     []jsonEntry{},
+		WatchEvents: []jsonWatch{},
+		Ratings:     []jsonScore{},
+	}
+	for _, e := range record.Entries {
+		out.Entries = append(out.Entries, jsonEntry{User: e.Name, Watched: e.Watched, Comment: string(e.Comment)})
+	}
+	for _, e := range record.WatchEvents {
+		watch := jsonWatch{User: e.Username, Note: e.Note}
+		if e.WatchedAt != nil {
+			watch.WatchedAt = e.WatchedAt.Format(time.DateOnly)
+		}
+		out.WatchEvents = append(out.WatchEvents, watch)
+	}
+	for _, r := range record.Ratings {
+		out.Ratings = append(out.Ratings, jsonScore{User: r.Username, Score: r.Score, RatedAt: r.RatedAt.UTC()})
+	}
+	return out
+}
+
+func fromJSON(record jsonRecord) (*api.ExportRecord, error) {
+	if record.Movie == nil || record.Movie.ImdbID == "" {
+		return nil, fmt.Errorf("movie without IMDb id")
+	}
+	out := &api.ExportRecord{Movie: record.Movie}
+	for _, e := range record.Entries {
+		out.Entries = append(out.Entries, api.NewEntry(e.User, e.Watched, e.Comment))
+	}
+	for _, e := range record.WatchEvents {
+		event := &api.WatchEvent{Username: e.User, MediaID: record.Movie.ImdbID, Note: e.Note}
+		if e.WatchedAt != "" {
+			t, err := time.Parse(time.DateOnly, e.WatchedAt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid watch date %q", record.Movie.ImdbID, e.WatchedAt)
+			}
+			event.WatchedAt = &t
+		}
+		out.WatchEvents = append(out.WatchEvents, event)
+	}
+	for _, r := range record.Ratings {
+		out.Ratings = append(out.Ratings, &api.UserRating{Username: r.User, MediaID: record.Movie.ImdbID, Score: r.Score, RatedAt: r.RatedAt})
+	}
+	return out, nil
+}
+
+// Read decodes a JSON export and calls fn for every movie, movies are decoded one at a time
+func Read(r io.Reader, fn func(*api.ExportRecord) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case "version":
+			var version int
+			if err := dec.Decode(&version); err != nil {
+				return err
+			}
+			if version > Version {
+				return fmt.Errorf("export version %d is newer than the supported version %d", version, Version)
+			}
+		case "movies":
+			if err := readMovies(dec, fn); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func readMovies(dec *json.Decoder, fn func(*api.ExportRecord) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		var record jsonRecord
+		if err := dec.Decode(&record); err != nil {
+			return err
+		}
+		out, err := fromJSON(record)
+		if err != nil {
+			return err
+		}
+		if err := fn(out); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != want {
+		return fmt.Errorf("invalid export: expected %q, got %v", want, token)
+	}
+	return nil
+}
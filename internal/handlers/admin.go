@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/jhachmer/gomovie/internal/auth"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,6 +22,34 @@ type Credentials struct {
 	Password string `json:"password"`
 }
 
+// requireAdmin reports if the logged in user is an admin and answers the request with 403 otherwise
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	username, _ := auth.UserFromContext(r.Context())
+	isAdmin, err := h.store.IsAdmin(username)
+	if err != nil {
+		slog.Error("error checking admin", "handler", "require_admin", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if !isAdmin {
+		http.Error(w, "admin only", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// ownerOrAdmin reports whether username is the owner of something or an admin
+func (h *Handler) ownerOrAdmin(owner, username string) bool {
+	if owner == username {
+		return true
+	}
+	isAdmin, err := h.store.IsAdmin(username)
+	if err != nil {
+		slog.Error("error checking admin", "handler", "owner_or_admin", "err", err.Error())
+	}
+	return isAdmin
+}
+
 func (h *Handler) AdminLoginHandler(w http.ResponseWriter, r *http.Request) {
 	var creds Credentials
 	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
@@ -35,6 +66,12 @@ func (h *Handler) AdminLoginHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	// admin endpoints check the logged in user, so the admin is logged in on the site as well
+	if err := setSessionCookie(w, creds.Username); err != nil {
+		slog.Error("error creating token", "handler", "admin_login", "err", err.Error())
+		http.Error(w, "could not log in", http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -61,7 +98,11 @@ func (h *Handler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(users)
 }
 
+// ToggleActiveHandler activates or deactivates an account, admins only
 func (h *Handler) ToggleActiveHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
 	var request struct {
 		UserID int `json:"userId"`
 		Active int `json:"active"`
@@ -72,7 +113,11 @@ func (h *Handler) ToggleActiveHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.store.ToggleUserActive(request.Active, request.UserID)
+	err := h.storeFor(r).ToggleUserActive(request.Active, request.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		slog.Error("error toggling user status", "handler", "toggle_active", "err", err.Error())
 		http.Error(w, "Database update failed", http.StatusInternalServerError)
@@ -82,6 +127,22 @@ func (h *Handler) ToggleActiveHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetJobRunsHandler returns the last run of every background job, admins only
+func (h *Handler) GetJobRunsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	runs, err := h.store.GetLastJobRuns()
+	if err != nil {
+		slog.Error("error getting job runs", "handler", "get_job_runs", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
 func (h *Handler) AdminHandler(w http.ResponseWriter, r *http.Request) {
 	renderTemplate(w, "admin", nil)
 }
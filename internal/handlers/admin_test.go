@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func TestHandler_ToggleActiveHandler(t *testing.T) {
+	h, s := newTestHandler(t)
+	if err := s.CreateUser("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	var aliceID, bossID int
+	if err := s.DB.QueryRow(`SELECT UserID FROM useraccounts WHERE Username = 'alice'`).Scan(&aliceID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DB.QueryRow(`SELECT UserID FROM useraccounts WHERE Username = 'boss'`).Scan(&bossID); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		user       string
+		userID     int
+		active     int
+		wantCode   int
+		wantActive int
+	}{
+		{name: "user deactivating the admin", user: "alice", userID: bossID, active: 0, wantCode: http.StatusForbidden, wantActive: 1},
+		{name: "unknown user", user: "boss", userID: 999, active: 1, wantCode: http.StatusNotFound},
+		{name: "admin", user: "boss", userID: aliceID, active: 1, wantCode: http.StatusOK, wantActive: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"userId": %d, "active": %d}`, tt.userID, tt.active)
+			w := serve(h.ToggleActiveHandler, "PUT", tt.user, body, nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantActive == 0 {
+				return
+			}
+			var active int
+			if err := s.DB.QueryRow(`SELECT Active FROM useraccounts WHERE UserID = ?`, tt.userID).Scan(&active); err != nil {
+				t.Fatal(err)
+			}
+			if active != tt.wantActive {
+				t.Errorf("active = %d, want %d", active, tt.wantActive)
+			}
+		})
+	}
+
+	entries, err := s.GetAuditEntries(api.AuditFilter{Actor: "boss", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != api.AuditUserActiveChanged {
+		t.Errorf("got audit entries %v, want one %s by boss", entries, api.AuditUserActiveChanged)
+	}
+}
+
+func TestHandler_AdminLoginHandler(t *testing.T) {
+	h, s := newTestHandler(t)
+	if err := s.As("setup").CreateUser("root", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DB.Exec(`UPDATE useraccounts SET Active = 1, IsAdmin = 1 WHERE Username = 'root'`); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		password   string
+		wantCode   int
+		wantCookie bool
+	}{
+		{name: "wrong password", password: "guess", wantCode: http.StatusUnauthorized},
+		{name: "admin", password: "secret", wantCode: http.StatusOK, wantCookie: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"username": "root", "password": %q}`, tt.password)
+			w := serve(h.AdminLoginHandler, "POST", "", body, nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+			gotCookie := strings.HasPrefix(w.Header().Get("Set-Cookie"), "gomovie=")
+			if gotCookie != tt.wantCookie {
+				t.Errorf("session cookie set = %v, want %v", gotCookie, tt.wantCookie)
+			}
+		})
+	}
+}
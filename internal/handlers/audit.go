@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+const (
+	// defaultAuditLimit is the number of audit entries returned without a limit
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// storeFor returns the store to change data with on behalf of the logged in user, who is audited as actor
+func (h *Handler) storeFor(r *http.Request) store.Store {
+	username, _ := auth.UserFromContext(r.Context())
+	return h.store.As(username)
+}
+
+// GetAuditLogHandler returns audit entries as JSON, newest first
+// query values user, film, from, to and limit filter them, from and to are dates or RFC 3339 times
+func (h *Handler) GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries, err := h.store.GetAuditEntries(filter)
+	if err != nil {
+		slog.Error("error getting audit log", "handler", "get_audit_log", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// parseAuditFilter reads the audit filter from query values
+// a date given as to includes the whole day
+func parseAuditFilter(values url.Values) (api.AuditFilter, error) {
+	filter := api.AuditFilter{
+		Actor:   values.Get("user"),
+		MediaID: values.Get("film"),
+		Limit:   defaultAuditLimit,
+	}
+	if film := filter.MediaID; film != "" && !validPath.MatchString(film) {
+		return filter, fmt.Errorf("not a valid film id: %s", film)
+	}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		value := values.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, value); err != nil {
+				return filter, fmt.Errorf("not a valid %s time: %s", bound.name, value)
+			}
+			if bound.name == "to" {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+		*bound.t = t
+	}
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("not a valid limit: %s", value)
+		}
+		filter.Limit = min(limit, maxAuditLimit)
+	}
+	return filter, nil
+}
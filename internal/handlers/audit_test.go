@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func Test_parseAuditFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    api.AuditFilter
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  api.AuditFilter{Limit: defaultAuditLimit},
+		},
+		{
+			name:  "user and film",
+			query: "user=alice&film=tt0133093",
+			want:  api.AuditFilter{Actor: "alice", MediaID: "tt0133093", Limit: defaultAuditLimit},
+		},
+		{
+			name:  "dates include the whole last day",
+			query: "from=2024-03-01&to=2024-03-31",
+			want: api.AuditFilter{
+				From:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+				Limit: defaultAuditLimit,
+			},
+		},
+		{
+			name:  "times are taken as they are",
+			query: "to=2024-03-31T12:00:00Z",
+			want:  api.AuditFilter{To: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), Limit: defaultAuditLimit},
+		},
+		{
+			name:  "limit is capped",
+			query: "limit=5000",
+			want:  api.AuditFilter{Limit: maxAuditLimit},
+		},
+		{
+			name:    "invalid film",
+			query:   "film=matrix",
+			wantErr: true,
+		},
+		{
+			name:    "invalid date",
+			query:   "from=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "invalid limit",
+			query:   "limit=0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseAuditFilter(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAuditFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.Actor != tt.want.Actor || got.MediaID != tt.want.MediaID ||
+				!got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) || got.Limit != tt.want.Limit) {
+				t.Errorf("parseAuditFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
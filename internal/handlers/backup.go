@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/jhachmer/gomovie/internal/backup"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// GetBackupsHandler lists all database snapshots, newest first
+func (h *Handler) GetBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	snapshots, err := h.backups.Snapshots()
+	if err != nil {
+		slog.Error("error listing snapshots", "handler", "get_backups", "err", err.Error())
+		http.Error(w, "could not list snapshots", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+// CreateBackupHandler writes a snapshot of the database right away
+func (h *Handler) CreateBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	snapshot, err := h.backups.Snapshot()
+	if err != nil {
+		slog.Error("error writing snapshot", "handler", "create_backup", "err", err.Error())
+		http.Error(w, "could not write snapshot", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// RestoreBackupHandler replaces the database with a snapshot given by name in a json body {"name": ...}
+// the previous state is kept as pre-restore snapshot, which is returned
+func (h *Handler) RestoreBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	saved, err := h.backups.WithStore(h.storeFor(r)).Restore(request.Name)
+	switch {
+	case errors.Is(err, backup.ErrUnknownSnapshot):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrSchemaVersion):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("error restoring snapshot", "handler", "restore_backup", "snapshot", request.Name, "err", err.Error())
+		http.Error(w, "could not restore snapshot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.InvalidateIndex()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
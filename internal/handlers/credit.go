@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// PersonHandler lists the stored movies a person acted in, directed or wrote
+// with how often the group watched them and their group rating
+func (h *Handler) PersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	person, err := h.store.GetPerson(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "person not found", http.StatusNotFound)
+		return
+	}
+	page := api.PersonPage{Person: &api.Person{}}
+	if err != nil {
+		page.Error = fmt.Errorf("error getting person")
+		slog.Error("error getting person", "handler", "person", "err", err.Error())
+		renderTemplate(w, "person", page)
+		return
+	}
+	page.Person = person
+	if page.Films, err = h.store.GetPersonFilms(id); err != nil {
+		page.Error = fmt.Errorf("error getting films")
+		slog.Error("error getting person films", "handler", "person", "err", err.Error())
+		renderTemplate(w, "person", page)
+		return
+	}
+	page.Watched, page.AverageRating = summariseFilms(page.Films)
+	renderTemplate(w, "person", page)
+}
+
+// summariseFilms counts the watched films and averages the group ratings of the rated ones
+func summariseFilms(films []*api.PersonFilm) (int, float64) {
+	var watched, rated int
+	var sum float64
+	for _, f := range films {
+		if f.Watches > 0 {
+			watched++
+		}
+		if f.RatingCount > 0 {
+			rated++
+			sum += f.AverageRating
+		}
+	}
+	if rated == 0 {
+		return watched, 0
+	}
+	return watched, sum / float64(rated)
+}
+
+// GetPersonAliasesHandler lists the names merged into people
+func (h *Handler) GetPersonAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	aliases, err := h.store.GetPersonAliases()
+	if err != nil {
+		slog.Error("error getting aliases", "handler", "get_person_aliases", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aliases)
+}
+
+// MergePeopleHandler merges duplicate names of a person from a json body {"alias": ..., "name": ...}
+// everything credited to alias is credited to name afterwards and alias is kept to merge refreshed media
+func (h *Handler) MergePeopleHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	var request struct {
+		Alias string `json:"alias"`
+		Name  string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Alias == request.Name {
+		http.Error(w, "a person cannot be merged into itself", http.StatusBadRequest)
+		return
+	}
+	var people [2]*api.Person
+	for i, name := range []string{request.Alias, request.Name} {
+		p, err := h.store.GetPersonByName(name)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "no person named "+name, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("error getting person", "handler", "merge_people", "err", err.Error())
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		people[i] = p
+	}
+	if err := h.storeFor(r).MergePeople(people[0], people[1]); err != nil {
+		slog.Error("error merging people", "handler", "merge_people", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	h.InvalidateIndex()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(people[1])
+}
+
+// CountryHandler lists the stored movies produced in a country
+func (h *Handler) CountryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	country, err := h.store.GetCountry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "country not found", http.StatusNotFound)
+		return
+	}
+	page := api.CreditPage{}
+	if err != nil {
+		page.Error = fmt.Errorf("error getting country")
+		slog.Error("error getting country", "handler", "country", "err", err.Error())
+		renderTemplate(w, "credits", page)
+		return
+	}
+	page.Title = country.Name
+	movies, err := h.store.GetCountryMovies(id)
+	if err != nil {
+		page.Error = fmt.Errorf("error getting movies")
+		slog.Error("error getting country movies", "handler", "country", "err", err.Error())
+		renderTemplate(w, "credits", page)
+		return
+	}
+	if len(movies) > 0 {
+		page.Sections = []api.CreditSection{{Heading: "Produced in " + country.Name, Movies: movies}}
+	}
+	renderTemplate(w, "credits", page)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func Test_summariseFilms(t *testing.T) {
+	tests := []struct {
+		name        string
+		films       []*api.PersonFilm
+		wantWatched int
+		wantAverage float64
+	}{
+		{
+			name: "no films",
+		},
+		{
+			name: "unrated films are not averaged",
+			films: []*api.PersonFilm{
+				{Watches: 2, RatingCount: 2, AverageRating: 4.5},
+				{Watches: 0, RatingCount: 1, AverageRating: 3},
+				{Watches: 1},
+			},
+			wantWatched: 2,
+			wantAverage: 3.75,
+		},
+		{
+			name:        "nothing rated",
+			films:       []*api.PersonFilm{{Watches: 1}, {}},
+			wantWatched: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watched, average := summariseFilms(tt.films)
+			if watched != tt.wantWatched || average != tt.wantAverage {
+				t.Errorf("summariseFilms() = %d, %v, want %d, %v", watched, average, tt.wantWatched, tt.wantAverage)
+			}
+		})
+	}
+}
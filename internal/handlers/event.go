@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+// publish records an event done by the logged in user, a failure does not fail the request
+func (h *Handler) publish(r *http.Request, eventType, mediaID, title string, data map[string]any) {
+	username, _ := auth.UserFromContext(r.Context())
+	if title == "" {
+		title = h.movieTitle(mediaID)
+	}
+	event := &api.Event{
+		Type:     eventType,
+		Username: username,
+		MediaID:  mediaID,
+		Title:    title,
+		Data:     data,
+	}
+	if err := h.events.Publish(event); err != nil {
+		slog.Error("error publishing event", "type", eventType, "id", mediaID, "err", err.Error())
+	}
+}
+
+// movieTitle returns the title of a cached or stored movie without asking OMDb
+func (h *Handler) movieTitle(id string) string {
+	if mov, ok := h.movCache.Get(id); ok {
+		return mov.Title
+	}
+	if mov, err := h.store.GetMovieByID(id); err == nil {
+		return mov.Title
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/export"
+)
+
+// ExportHandler streams all movies with entries, viewings and ratings as download
+// query values: format (json, csv or letterboxd) and user for Letterboxd exports, defaulting to the logged in user
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	username := r.URL.Query().Get("user")
+	if username == "" {
+		username, _ = auth.UserFromContext(r.Context())
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.Filename()))
+	err = export.Write(w, h.store, format, username)
+	if errors.Is(err, export.ErrNoUser) {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// the status is sent with the first movie, a failure afterwards leaves a truncated file
+	if err != nil {
+		slog.Error("error writing export", "handler", "export", "format", format, "err", err.Error())
+	}
+}
@@ -6,10 +6,18 @@ import (
 	"log/slog"
 	"net/http"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jhachmer/go-cache"
 
 	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/backup"
+	"github.com/jhachmer/gomovie/internal/events"
+	"github.com/jhachmer/gomovie/internal/picker"
+	"github.com/jhachmer/gomovie/internal/poster"
+	"github.com/jhachmer/gomovie/internal/recommend"
 	"github.com/jhachmer/gomovie/internal/store"
 )
 
@@ -21,19 +29,45 @@ type Handler struct {
 	store    store.Store
 	movCache *cache.TTLCache[string, *api.Movie]
 	serCache *cache.TTLCache[string, *api.Series]
+	posters  *poster.Store
+	backups  *backup.Backuper
+	events   *events.Bus
+	picker   *picker.Picker
+
+	// posterMisses holds the ids of movies whose poster could not be downloaded
+	posterMisses *cache.TTLCache[string, bool]
+
+	// TrashDays is how long deleted movies and entries stay in the trash, shown on the trash page
+	TrashDays int
+
+	// index caches the recommendation index of the library, built at indexBuilt
+	indexMu    sync.Mutex
+	index      *recommend.Index
+	indexBuilt time.Time
+
+	// done is closed to end event streams on shutdown
+	done        chan struct{}
+	stopStreams sync.Once
 }
 
-func NewHandler(store store.Store, movC *cache.TTLCache[string, *api.Movie], serC *cache.TTLCache[string, *api.Series]) *Handler {
+func NewHandler(store store.Store, movC *cache.TTLCache[string, *api.Movie], serC *cache.TTLCache[string, *api.Series], posters *poster.Store, backups *backup.Backuper, bus *events.Bus) *Handler {
 	return &Handler{
-		store:    store,
-		movCache: movC,
-		serCache: serC,
+		store:        store,
+		movCache:     movC,
+		serCache:     serC,
+		posters:      posters,
+		posterMisses: cache.NewTTLCache[string, bool](time.Minute, posterMissTTL, nil),
+		backups:      backups,
+		events:       bus,
+		picker:       picker.New(),
+		done:         make(chan struct{}),
 	}
 }
 
 func (h *Handler) Close() {
 	h.movCache.Close()
 	h.serCache.Close()
+	h.posterMisses.Close()
 	h.store.Close()
 }
 
@@ -58,7 +92,7 @@ func (h *Handler) getMovie(id string) (*api.Movie, error) {
 var templates *template.Template
 
 func InitTemplates() {
-	funcMap := template.FuncMap{"perc": perc}
+	funcMap := template.FuncMap{"perc": perc, "join": strings.Join}
 	templates = template.Must(template.New("gomovie").Funcs(funcMap).ParseFiles(
 		"./templates/index.html",
 		"./templates/info.html",
@@ -67,7 +101,14 @@ func InitTemplates() {
 		"./templates/error.html",
 		"./templates/register.html",
 		"./templates/admin.html",
-		"./templates/stats.html"))
+		"./templates/stats.html",
+		"./templates/pick.html",
+		"./templates/recommendations.html",
+		"./templates/polls.html",
+		"./templates/poll.html",
+		"./templates/credits.html",
+		"./templates/person.html",
+		"./templates/trash.html"))
 }
 
 func perc(num1, num2 int) float32 {
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/go-cache"
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// newTestHandler returns a handler on a fresh database in a temporary directory with the admin "boss"
+func newTestHandler(t *testing.T) (*Handler, *store.SQLiteStorage) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.NewSQLiteStore(db)
+	if err := s.InitDatabaseTables(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO useraccounts (Username, PasswordHash, Active, IsAdmin) VALUES ('boss', '', 1, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(s,
+		cache.NewTTLCache[string, *api.Movie](time.Minute, time.Minute, nil),
+		cache.NewTTLCache[string, *api.Series](time.Minute, time.Minute, nil),
+		nil, nil, nil)
+	t.Cleanup(h.Close)
+	return h, s
+}
+
+// serve calls the handler as the logged in user with the form body and path values
+func serve(handler http.HandlerFunc, method, user, form string, values map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", strings.NewReader(form))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range values {
+		r.SetPathValue(k, v)
+	}
+	r = r.WithContext(auth.WithUser(r.Context(), user))
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/chart"
+	"github.com/jhachmer/gomovie/internal/poster"
 )
 
 func (h *Handler) InfoIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -37,9 +44,88 @@ func (h *Handler) InfoIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	data.Entries = entries
+	watchEvents, err := h.store.GetWatchEvents(id)
+	if err != nil {
+		data.Error = fmt.Errorf("error getting watch history")
+		slog.Error("error getting watch events", "handler", "info_id", "err", err.Error())
+		renderTemplate(w, "info", data)
+		return
+	}
+	data.WatchEvents = watchEvents
+	userRatings, err := h.store.GetUserRatings(id)
+	if err != nil {
+		data.Error = fmt.Errorf("error getting ratings")
+		slog.Error("error getting user ratings", "handler", "info_id", "err", err.Error())
+		renderTemplate(w, "info", data)
+		return
+	}
+	data.UserRatings = userRatings
+	username, _ := auth.UserFromContext(r.Context())
+	for _, rating := range userRatings {
+		data.GroupRating.Average += rating.Score / float64(len(userRatings))
+		data.GroupRating.Count++
+		if rating.Username == username {
+			data.MyRating = rating.Score
+		}
+	}
+	history, err := h.store.GetRatingHistory(id)
+	if err != nil {
+		data.Error = fmt.Errorf("error getting rating history")
+		slog.Error("error getting rating history", "handler", "info_id", "err", err.Error())
+		renderTemplate(w, "info", data)
+		return
+	}
+	data.RatingTrends = ratingTrends(history)
+	// similar movies are a hint, the page works without them
+	if data.Similar, err = h.similarMovies(r, id); err != nil {
+		slog.Error("error getting similar movies", "handler", "info_id", "err", err.Error())
+	}
+	mediaTags, err := h.store.GetMediaTags()
+	if err != nil {
+		data.Error = fmt.Errorf("error getting tags")
+		slog.Error("error getting tags", "handler", "info_id", "err", err.Error())
+		renderTemplate(w, "info", data)
+		return
+	}
+	data.Tags = mediaTags[id]
+	if data.AllTags, err = h.tagNames(); err != nil {
+		data.Error = fmt.Errorf("error getting tags")
+		slog.Error("error getting tag names", "handler", "info_id", "err", err.Error())
+		renderTemplate(w, "info", data)
+		return
+	}
+	credits, err := h.store.GetMediaCredits(id)
+	if err != nil {
+		data.Error = fmt.Errorf("error getting credits")
+		slog.Error("error getting credits", "handler", "info_id", "err", err.Error())
+		renderTemplate(w, "info", data)
+		return
+	}
+	// movies which are not stored have no credits to link
+	if len(credits.Actors) > 0 || len(credits.Directors) > 0 || len(credits.Writers) > 0 || len(credits.Countries) > 0 {
+		data.Credits = credits
+	}
+	data.StarScores = api.StarScores()
+	data.Today = time.Now().Format(time.DateOnly)
 	renderTemplate(w, "info", data)
 }
 
+// ratingTrends draws a sparkline of the score history of every rating source
+// snapshots without a score are skipped
+func ratingTrends(snapshots []*api.RatingSnapshot) map[string]template.HTML {
+	scores := make(map[string][]float64)
+	for _, snapshot := range snapshots {
+		if snapshot.Score != nil {
+			scores[snapshot.Source] = append(scores[snapshot.Source], *snapshot.Score)
+		}
+	}
+	trends := make(map[string]template.HTML, len(scores))
+	for source, values := range scores {
+		trends[source] = chart.Sparkline(values, 0, 100)
+	}
+	return trends
+}
+
 func (h *Handler) CreateMovieHandler(w http.ResponseWriter, r *http.Request) {
 	data := api.MovieInfoPage{}
 	id := r.PathValue("imdb")
@@ -50,12 +136,15 @@ func (h *Handler) CreateMovieHandler(w http.ResponseWriter, r *http.Request) {
 		slog.Error("error getting movies", "handler", "create_movie", "err", err.Error())
 		renderTemplate(w, "info", data)
 	}
-	_, err = h.store.CreateMovie(mov)
+	_, err = h.storeFor(r).CreateMovie(mov)
 	if err != nil {
 		//http.Error(w, err.Error(), http.StatusInternalServerError)
 		data.Error = fmt.Errorf("error saving movie: %w", err)
 		slog.Error("error saving movie", "handler", "create_movie", "err", err.Error())
 		renderTemplate(w, "info", data)
+	} else {
+		h.InvalidateIndex()
+		h.publish(r, api.EventMovieAdded, id, mov.Title, nil)
 	}
 	http.Redirect(w, r, fmt.Sprintf("/films/%s", id), http.StatusSeeOther)
 
@@ -80,7 +169,7 @@ func (h *Handler) UpdateMovieHandler(w http.ResponseWriter, r *http.Request) {
 		slog.Error("error getting movie", "handler", "update_movie", "err", err.Error())
 		return
 	}
-	_, err = h.store.UpdateMovie(updatedMovie)
+	_, err = h.storeFor(r).UpdateMovie(updatedMovie)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("error getting movie: %s", err.Error()), http.StatusInternalServerError)
 		slog.Error("error updating movie", "handler", "update_movie", "err", err.Error())
@@ -88,17 +177,28 @@ func (h *Handler) UpdateMovieHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	h.movCache.Delete(id)
 	h.movCache.Set(id, updatedMovie)
+	h.InvalidateIndex()
+	if err := h.posters.Fetch(r.Context(), id, updatedMovie.Poster); err != nil && !errors.Is(err, poster.ErrNoPoster) {
+		slog.Warn("error updating poster", "handler", "update_movie", "err", err.Error())
+	}
 }
 
 func (h *Handler) DeleteMovieHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("imdb")
-	err := h.store.DeleteMedia(id)
+	title := h.movieTitle(id)
+	err := h.storeFor(r).DeleteMedia(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "movie not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("error deleting movie: %s", err.Error()), http.StatusInternalServerError)
 		slog.Error("error deleting movie", "handler", "delete_movie", "err", err.Error())
 		return
 	}
 	h.movCache.Delete(id)
+	h.InvalidateIndex()
+	h.publish(r, api.EventMovieDeleted, id, title, nil)
 }
 
 func (h *Handler) CreateEntryHandler(w http.ResponseWriter, r *http.Request) {
@@ -123,12 +223,17 @@ func (h *Handler) CreateEntryHandler(w http.ResponseWriter, r *http.Request) {
 		renderTemplate(w, "info", data)
 	}
 	entry := api.NewEntry(name, watched, comment)
-	_, err = h.store.CreateEntry(entry, mov)
+	_, err = h.storeFor(r).CreateEntry(entry, mov)
 	if err != nil {
 		// http.Error(w, err.Error(), http.StatusInternalServerError)
 		data.Error = fmt.Errorf("error creating entry: %w", err)
 		slog.Error("error creating entry", "handler", "create_movie", "err", err.Error())
 		renderTemplate(w, "info", data)
+	} else {
+		h.publish(r, api.EventEntryCreated, id, mov.Title, map[string]any{"name": name, "watched": watched})
+		if watched {
+			h.publish(r, api.EventMovieWatched, id, mov.Title, map[string]any{"name": name})
+		}
 	}
 	entries, err := h.store.GetEntries(id)
 	if err != nil {
@@ -157,23 +262,35 @@ func (h *Handler) UpdateEntryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid JSON payload", http.StatusBadRequest)
 		return
 	}
-	_, err = h.store.UpdateEntry(movieId, payload.Name, payload.Comment, payload.Watched)
+	// the previous state tells if the update marks the movie as watched
+	wasWatched := false
+	if entries, err := h.store.GetEntries(movieId); err == nil {
+		for _, e := range entries {
+			wasWatched = wasWatched || e.Watched
+		}
+	}
+	_, err = h.storeFor(r).UpdateEntry(movieId, payload.Name, payload.Comment, payload.Watched)
 	if err != nil {
 		slog.Error("error updating entry", "handler", "update_entry", "err", err.Error())
 		http.Error(w, "error updating entry", http.StatusInternalServerError)
 		return
 	}
+	h.publish(r, api.EventEntryUpdated, movieId, "", map[string]any{"name": payload.Name, "watched": payload.Watched})
+	if payload.Watched && !wasWatched {
+		h.publish(r, api.EventMovieWatched, movieId, "", map[string]any{"name": payload.Name})
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(payload)
 }
 
 func (h *Handler) DeleteEntryHandler(w http.ResponseWriter, r *http.Request) {
 	movieId := r.PathValue("imdb")
-	err := h.store.DeleteEntry(movieId)
+	err := h.storeFor(r).DeleteEntry(movieId)
 	if err != nil {
 		slog.Error("error deleting entry", "handler", "delete_entry", "err", err.Error())
 		http.Error(w, "error deleting entry", http.StatusInternalServerError)
 		return
 	}
+	h.publish(r, api.EventEntryDeleted, movieId, "", nil)
 	w.WriteHeader(http.StatusNoContent)
 }
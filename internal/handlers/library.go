@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// GetLibraryHandler lists all files found in the media library with the movie they were matched to
+func (h *Handler) GetLibraryHandler(w http.ResponseWriter, r *http.Request) {
+	items, err := h.store.GetLibraryItems()
+	if err != nil {
+		slog.Error("error getting library", "handler", "get_library", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if items == nil {
+		items = []*api.LibraryItem{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// attachLibrary marks every movie with a file in the media library as available locally
+func (h *Handler) attachLibrary(movies []*api.MovieInfoData) error {
+	local, err := h.store.GetLocalMediaIDs()
+	if err != nil {
+		return err
+	}
+	for _, m := range movies {
+		m.Local = local[m.Movie.ImdbID]
+	}
+	return nil
+}
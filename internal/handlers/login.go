@@ -34,11 +34,19 @@ func (h *Handler) CheckLoginHandler(w http.ResponseWriter, r *http.Request) {
 		renderTemplate(w, "index", data)
 		return
 	}
-	tokenString, err := auth.CreateToken(username)
-	if err != nil {
+	if err := setSessionCookie(w, username); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	http.Redirect(w, r, "/overview", http.StatusSeeOther)
+}
+
+// setSessionCookie logs the user in by setting the cookie with a token read by the Authenticate middleware
+func setSessionCookie(w http.ResponseWriter, username string) error {
+	tokenString, err := auth.CreateToken(username)
+	if err != nil {
+		return err
+	}
 	cookie := http.Cookie{
 		Name:  "gomovie",
 		Value: tokenString,
@@ -50,7 +58,7 @@ func (h *Handler) CheckLoginHandler(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 	}
 	http.SetCookie(w, &cookie)
-	http.Redirect(w, r, "/overview", http.StatusSeeOther)
+	return nil
 }
 
 func (h *Handler) RegisterSiteHandler(w http.ResponseWriter, r *http.Request) {
@@ -65,7 +73,8 @@ func (h *Handler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	username := r.FormValue("username")
 	password := r.FormValue("password")
-	err = h.store.CreateUser(username, password)
+	// registering is not done by a logged in user, so the new user is the actor
+	err = h.store.As(username).CreateUser(username, password)
 	if err != nil {
 		data := api.LoginData{Error: fmt.Errorf("error creating user %w", err)}
 		renderTemplate(w, "register", data)
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
 	"github.com/jhachmer/gomovie/internal/util"
 )
 
@@ -23,10 +25,11 @@ func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 
 // HomeHandler handles requests to /overview route
 // lists all movies retrieved from database on the overview page
+// or only those of the collection given by the query value "collection"
 // movies retrieved get sorted before being passed to tempalte render
 func (h *Handler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 	data := api.MovieOverviewData{}
-	movies, err := h.store.GetAllMovies()
+	movies, err := h.overviewMovies(r, &data)
 	api.SortMovieSlice(movies)
 	data.Movies = movies
 	if err != nil {
@@ -34,15 +37,53 @@ func (h *Handler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 		slog.Error("error getting movies:", "handler", "home", "err", err)
 		data.Error = err
 	}
+	if err := h.attachRatings(r, movies); err != nil {
+		slog.Error("error getting ratings", "handler", "home", "err", err)
+		data.Error = err
+	}
+	if err := h.attachLibrary(movies); err != nil {
+		slog.Error("error getting library", "handler", "home", "err", err)
+		data.Error = err
+	}
+	if err := h.attachTags(movies); err != nil {
+		slog.Error("error getting tags", "handler", "home", "err", err)
+		data.Error = err
+	}
+	if err := h.attachCollections(&data); err != nil {
+		slog.Error("error getting collections", "handler", "home", "err", err)
+		data.Error = err
+	}
+	if err := h.attachSavedSearches(r, &data); err != nil {
+		slog.Error("error getting saved searches", "handler", "home", "err", err)
+		data.Error = err
+	}
 	renderTemplate(w, "overview", data)
 }
 
+// overviewMovies returns all movies, or the movies of the requested collection which is set in data
+func (h *Handler) overviewMovies(r *http.Request, data *api.MovieOverviewData) ([]*api.MovieInfoData, error) {
+	value := r.URL.Query().Get("collection")
+	if value == "" {
+		return h.store.GetAllMovies()
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid collection: %s", value)
+	}
+	c, err := h.store.GetCollection(id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting collection: %w", err)
+	}
+	data.Collection = c
+	return h.store.SearchMovie(c.SearchParams())
+}
+
 // SearchHandler handles requests to /search route
 // template HTML must have form with "query" input field
 // input strings gets parsed into SearchParams type by parseSearchQuery function
 // SearchParams are used in DB query
 //
-// Allowed search types are: Genre, Actors and Year
+// Allowed search types are described at parseSearchQuery
 // Different search types must be separated by a semicolon
 // Search values are separated from the search type by colons
 // Example string:
@@ -56,7 +97,18 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		slog.Error("error parsing form", "handler", "search", "err", err.Error())
 		renderTemplate(w, "overview", data)
 	}
-	query := r.FormValue("query")
+	h.renderSearch(w, r, data, r.FormValue("query"))
+}
+
+// renderSearch renders the overview with the movies found by query
+func (h *Handler) renderSearch(w http.ResponseWriter, r *http.Request, data api.MovieOverviewData, query string) {
+	data.Query = query
+	if err := h.attachSavedSearches(r, &data); err != nil {
+		data.Error = fmt.Errorf("error getting saved searches: %w", err)
+		slog.Error("error getting saved searches", "handler", "search", "err", err.Error())
+		renderTemplate(w, "overview", data)
+		return
+	}
 	sp, err := parseSearchQuery(query)
 	if err != nil {
 		data.Error = fmt.Errorf("error parsing search query: %w", err)
@@ -64,6 +116,7 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		renderTemplate(w, "overview", data)
 		return
 	}
+	sp.Username, _ = auth.UserFromContext(r.Context())
 	movs, err := h.store.SearchMovie(sp)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -73,18 +126,47 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	data.Movies = movs
+	if err := h.attachRatings(r, movs); err != nil {
+		data.Error = fmt.Errorf("error getting ratings: %w", err)
+		slog.Error("error getting ratings", "handler", "search", "err", err.Error())
+	}
+	if err := h.attachLibrary(movs); err != nil {
+		data.Error = fmt.Errorf("error getting library: %w", err)
+		slog.Error("error getting library", "handler", "search", "err", err.Error())
+	}
+	if err := h.attachTags(movs); err != nil {
+		data.Error = fmt.Errorf("error getting tags: %w", err)
+		slog.Error("error getting tags", "handler", "search", "err", err.Error())
+	}
+	if err := h.attachCollections(&data); err != nil {
+		data.Error = fmt.Errorf("error getting collections: %w", err)
+		slog.Error("error getting collections", "handler", "search", "err", err.Error())
+	}
 	renderTemplate(w, "overview", data)
 }
 
+// scoreSources maps search types to rating sources
+var scoreSources = map[string]string{
+	"imdb":       api.SourceIMDb,
+	"rt":         api.SourceRottenTomatoes,
+	"metacritic": api.SourceMetacritic,
+}
+
 // parseSearchQuery evaluates the search input for validity
 // Splits String according to the rules and builds SearchParams instance
 // which gets based to the database query
 //
-// Allowed search types are: Genre, Actors and Year
+// Allowed search types are: Genre, Actors, Director, Writer, Language, Country, Year, Runtime,
+// IMDb, RT, Metacritic, MyRating, GroupRating, Tag and AllTags
 // Different search types must be separated by a semicolon
 // Search values are separated from the search type by colons
+// Runtime is a range of minutes where either bound may be left empty
+// IMDb, RT and Metacritic are minimum scores normalised to 0-100
+// MyRating and GroupRating are minimum star scores
+// Tag matches movies with any of the tags, AllTags movies with all of them
+// Director, Writer, Language and Country match movies with any of the names like Genre and Actors
 // Example string:
-// genre:horror,thriller;actors:Hans Albers, Keeanu Reeves
+// genre:horror,thriller;actors:Hans Albers, Keeanu Reeves;runtime:,120;imdb:75;tag:halloween
 func parseSearchQuery(query string) (api.SearchParams, error) {
 	var sp api.SearchParams
 	if query == "" {
@@ -114,9 +196,73 @@ func parseSearchQuery(query string) (api.SearchParams, error) {
 				subVals[i] = strings.TrimSpace(subVals[i])
 			}
 			sp.Actors = subVals
+		case "director", "writer", "language", "country":
+			subVals := strings.Split(values, ",")
+			for i := range subVals {
+				subVals[i] = strings.TrimSpace(subVals[i])
+			}
+			switch searchType {
+			case "director":
+				sp.Directors = subVals
+			case "writer":
+				sp.Writers = subVals
+			case "language":
+				sp.Languages = subVals
+			default:
+				sp.Countries = subVals
+			}
 		case "year":
 			yearParams := strings.Split(values, ",")
 			sp.Years = api.YearSearch{StartYear: yearParams[0], EndYear: yearParams[1]}
+		case "runtime":
+			bounds := strings.Split(values, ",")
+			if len(bounds) != 2 {
+				return sp, fmt.Errorf("runtime needs a minimum and maximum: %s", values)
+			}
+			for i, bound := range bounds {
+				bound = strings.TrimSpace(bound)
+				if bound == "" {
+					continue
+				}
+				minutes, err := strconv.Atoi(bound)
+				if err != nil || minutes < 0 {
+					return sp, fmt.Errorf("invalid runtime: %s", bound)
+				}
+				if i == 0 {
+					sp.Runtime.Min = minutes
+				} else {
+					sp.Runtime.Max = minutes
+				}
+			}
+		case "imdb", "rt", "metacritic":
+			score, err := strconv.ParseFloat(strings.TrimSpace(values), 64)
+			if err != nil || score < 0 || score > 100 {
+				return sp, fmt.Errorf("invalid score: %s", values)
+			}
+			if sp.MinScores == nil {
+				sp.MinScores = make(map[string]float64)
+			}
+			sp.MinScores[scoreSources[searchType]] = score
+		case "myrating", "grouprating":
+			score, err := strconv.ParseFloat(strings.TrimSpace(values), 64)
+			if err != nil || !api.ValidStarScore(score) {
+				return sp, fmt.Errorf("invalid rating: %s", values)
+			}
+			if searchType == "myrating" {
+				sp.MinMyRating = score
+			} else {
+				sp.MinGroupRating = score
+			}
+		case "tag", "alltags":
+			tags, err := parseTags(values)
+			if err != nil {
+				return sp, err
+			}
+			if searchType == "tag" {
+				sp.Tags = tags
+			} else {
+				sp.AllTags = tags
+			}
 		default:
 			return sp, fmt.Errorf("invalid search type: %s", searchType)
 		}
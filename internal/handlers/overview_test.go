@@ -59,6 +59,70 @@ func Test_parseSearchQuery(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid rating filters",
+			args: args{query: "myrating:4;grouprating:3.5"},
+			want: api.SearchParams{
+				MinMyRating:    4,
+				MinGroupRating: 3.5,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid rating",
+			args:    args{query: "myrating:7"},
+			want:    api.SearchParams{},
+			wantErr: true,
+		},
+		{
+			name: "valid runtime and scores",
+			args: args{query: "runtime:90,120;imdb:75;rt:80"},
+			want: api.SearchParams{
+				Runtime:   api.RuntimeSearch{Min: 90, Max: 120},
+				MinScores: map[string]float64{api.SourceIMDb: 75, api.SourceRottenTomatoes: 80},
+			},
+			wantErr: false,
+		},
+		{
+			name: "open runtime range",
+			args: args{query: "runtime:,100"},
+			want: api.SearchParams{
+				Runtime: api.RuntimeSearch{Max: 100},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid score",
+			args:    args{query: "metacritic:120"},
+			want:    api.SearchParams{},
+			wantErr: true,
+		},
+		{
+			name: "any and all tags",
+			args: args{query: "tag:Halloween, halloween,christmas;alltags:seen in cinema,needs subtitles"},
+			want: api.SearchParams{
+				Tags:    []string{"Halloween", "christmas"},
+				AllTags: []string{"seen in cinema", "needs subtitles"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid tag",
+			args:    args{query: "tag:a/b"},
+			want:    api.SearchParams{},
+			wantErr: true,
+		},
+		{
+			name: "credits",
+			args: args{query: "director:Ridley Scott;writer:Dan O'Bannon, Walter Hill;language:German;country:UK,USA"},
+			want: api.SearchParams{
+				Directors: []string{"Ridley Scott"},
+				Writers:   []string{"Dan O'Bannon", "Walter Hill"},
+				Languages: []string{"German"},
+				Countries: []string{"UK", "USA"},
+			},
+			wantErr: false,
+		},
 		{
 			name:    "empty query",
 			args:    args{query: ""},
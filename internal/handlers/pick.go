@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+type PickPage struct {
+	Genres  []string
+	Decades []int
+	Error   error
+}
+
+// PickResult is the movie chosen for tonight and the number of movies it was chosen from
+type PickResult struct {
+	Movie      *api.Movie `json:"movie"`
+	Members    int        `json:"members"`
+	Candidates int        `json:"candidates"`
+}
+
+// PickPageHandler shows the form to pick a movie for tonight, offering the genres and decades of stored movies
+func (h *Handler) PickPageHandler(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.store.GetGenreCounts()
+	if err != nil {
+		slog.Error("error getting genres", "handler", "pick_page", "err", err.Error())
+		renderTemplate(w, "pick", PickPage{Error: err})
+		return
+	}
+	years, err := h.store.GetReleaseYears()
+	if err != nil {
+		slog.Error("error getting release years", "handler", "pick_page", "err", err.Error())
+		renderTemplate(w, "pick", PickPage{Error: err})
+		return
+	}
+	page := PickPage{}
+	for _, genre := range genres {
+		page.Genres = append(page.Genres, genre.Name)
+	}
+	slices.Sort(page.Genres)
+	for _, year := range years {
+		if decade := year / 10 * 10; !slices.Contains(page.Decades, decade) {
+			page.Decades = append(page.Decades, decade)
+		}
+	}
+	slices.Sort(page.Decades)
+	renderTemplate(w, "pick", page)
+}
+
+// PickHandler randomly picks an unwatched movie matching the form values
+// "genre" (repeatable), "max_runtime" in minutes, "min_imdb" on the IMDb scale of 0 - 10, "decade" like 1990,
+// "not_mine" to skip movies on the list of the logged in user and "weighted" to favour movies on several lists
+// and avoid recent suggestions
+func (h *Handler) PickHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "pick", "err", err.Error())
+		return
+	}
+	params, err := parsePickParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.FormValue("not_mine") != "" {
+		params.ExcludeUser = username
+	}
+	candidates, err := h.store.GetPickCandidates(params)
+	if err != nil {
+		http.Error(w, "error getting candidates", http.StatusInternalServerError)
+		slog.Error("error getting pick candidates", "handler", "pick", "err", err.Error())
+		return
+	}
+	pick := h.picker.Pick(candidates, r.FormValue("weighted") != "")
+	if pick == nil {
+		http.Error(w, "no unwatched movie matches", http.StatusNotFound)
+		return
+	}
+	movie, err := h.getMovie(pick.MediaID)
+	if err != nil {
+		http.Error(w, "error getting movie", http.StatusInternalServerError)
+		slog.Error("error getting movie", "handler", "pick", "id", pick.MediaID, "err", err.Error())
+		return
+	}
+	if err := h.storeFor(r).RecordPick(username, pick.MediaID, time.Now()); err != nil {
+		slog.Error("error recording pick", "handler", "pick", "id", pick.MediaID, "err", err.Error())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PickResult{
+		Movie:      movie,
+		Members:    pick.Members,
+		Candidates: len(candidates),
+	})
+}
+
+// parsePickParams reads the constraints of a pick from the parsed form
+func parsePickParams(r *http.Request) (api.PickParams, error) {
+	params := api.PickParams{}
+	for _, genre := range r.Form["genre"] {
+		if genre != "" {
+			params.Genres = append(params.Genres, genre)
+		}
+	}
+	if value := r.FormValue("max_runtime"); value != "" {
+		runtime, err := strconv.Atoi(value)
+		if err != nil || runtime < 0 {
+			return params, fmt.Errorf("not a valid runtime")
+		}
+		params.MaxRuntime = runtime
+	}
+	if value := r.FormValue("min_imdb"); value != "" {
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil || rating < 0 || rating > 10 {
+			return params, fmt.Errorf("not a valid IMDb rating")
+		}
+		// ratings are stored normalised to 0 - 100
+		params.MinIMDb = rating * 10
+	}
+	if value := r.FormValue("decade"); value != "" {
+		decade, err := strconv.Atoi(value)
+		if err != nil || decade < 0 || decade%10 != 0 {
+			return params, fmt.Errorf("not a valid decade")
+		}
+		params.Decade = decade
+	}
+	return params, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func Test_parsePickParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		form    string
+		want    api.PickParams
+		wantErr bool
+	}{
+		{
+			name: "empty form",
+			form: "",
+			want: api.PickParams{},
+		},
+		{
+			name: "all constraints",
+			form: "genre=Horror&genre=&genre=Drama&max_runtime=120&min_imdb=7.5&decade=1980",
+			want: api.PickParams{Genres: []string{"Horror", "Drama"}, MaxRuntime: 120, MinIMDb: 75, Decade: 1980},
+		},
+		{
+			name:    "rating above 10",
+			form:    "min_imdb=75",
+			wantErr: true,
+		},
+		{
+			name:    "not a decade",
+			form:    "decade=1985",
+			wantErr: true,
+		},
+		{
+			name:    "runtime not a number",
+			form:    "max_runtime=long",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/pick", strings.NewReader(tt.form))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parsePickParams(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePickParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parsePickParams() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/poll"
+)
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 12
+)
+
+type PollsPage struct {
+	Polls []*api.Poll
+	// Candidates are the unwatched movies a new poll can offer
+	Candidates []*api.PickCandidate
+	Error      error
+}
+
+type PollPage struct {
+	Poll *api.Poll
+	// MyRanks holds the rank the logged in user gave every option
+	MyRanks  map[string]int
+	Ranks    []int
+	CanClose bool
+	// Rounds numbers the rounds of the instant-runoff tally,
+	// TallyRows hold one row per option and a final row of exhausted ballots
+	Rounds    []int
+	TallyRows []TallyRow
+	Error     error
+}
+
+// TallyRow holds the votes of an option in every round, empty once it is eliminated
+type TallyRow struct {
+	Title  string
+	Counts []string
+}
+
+// PollsHandler lists all polls and offers the unwatched movies for a new one
+func (h *Handler) PollsHandler(w http.ResponseWriter, r *http.Request) {
+	page := PollsPage{}
+	polls, err := h.store.GetPolls()
+	if err != nil {
+		page.Error = fmt.Errorf("error getting polls")
+		slog.Error("error getting polls", "handler", "polls", "err", err.Error())
+		renderTemplate(w, "polls", page)
+		return
+	}
+	page.Polls = polls
+	candidates, err := h.store.GetPickCandidates(api.PickParams{})
+	if err != nil {
+		page.Error = fmt.Errorf("error getting unwatched movies")
+		slog.Error("error getting pick candidates", "handler", "polls", "err", err.Error())
+		renderTemplate(w, "polls", page)
+		return
+	}
+	page.Candidates = candidates
+	renderTemplate(w, "polls", page)
+}
+
+// CreatePollHandler creates a poll from form values "title" and "media_id" (repeated) and redirects to its shared link
+func (h *Handler) CreatePollHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "create_poll", "err", err.Error())
+		return
+	}
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		http.Error(w, "a poll needs a title", http.StatusBadRequest)
+		return
+	}
+	newPoll := &api.Poll{
+		Token:     pollToken(),
+		Title:     title,
+		CreatedBy: username,
+		CreatedAt: time.Now(),
+	}
+	for _, id := range r.Form["media_id"] {
+		if !validPath.MatchString(id) {
+			http.Error(w, "not a valid id", http.StatusBadRequest)
+			return
+		}
+		if !slices.ContainsFunc(newPoll.Options, func(o *api.PollOption) bool { return o.MediaID == id }) {
+			newPoll.Options = append(newPoll.Options, &api.PollOption{MediaID: id})
+		}
+	}
+	if len(newPoll.Options) < minPollOptions || len(newPoll.Options) > maxPollOptions {
+		http.Error(w, fmt.Sprintf("a poll needs %d to %d movies", minPollOptions, maxPollOptions), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.storeFor(r).CreatePoll(newPoll); err != nil {
+		http.Error(w, "error saving poll", http.StatusInternalServerError)
+		slog.Error("error saving poll", "handler", "create_poll", "err", err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/polls/%s", newPoll.Token), http.StatusSeeOther)
+}
+
+// PollHandler shows the ballot of the logged in user while the poll is open and the tally once it is closed
+func (h *Handler) PollHandler(w http.ResponseWriter, r *http.Request) {
+	username, _ := auth.UserFromContext(r.Context())
+	p, ballots, ok := h.pollWithBallots(w, r, "poll")
+	if !ok {
+		return
+	}
+	page := PollPage{
+		Poll:     p,
+		MyRanks:  make(map[string]int),
+		CanClose: p.Status == api.PollOpen && h.ownerOrAdmin(p.CreatedBy, username),
+	}
+	for rank := range p.Options {
+		page.Ranks = append(page.Ranks, rank+1)
+	}
+	for _, ballot := range ballots {
+		if ballot.Username == username {
+			for rank, id := range ballot.Ranking {
+				page.MyRanks[id] = rank + 1
+			}
+		}
+	}
+	if p.Status == api.PollClosed {
+		page.Rounds, page.TallyRows = tallyRows(p, tally(p, ballots))
+	}
+	renderTemplate(w, "poll", page)
+}
+
+// VotePollHandler saves the ballot of the logged in user, form values "rank_<imdb id>" rank the options from 1,
+// options without a rank are left off the ballot
+func (h *Handler) VotePollHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	p, err := h.store.GetPoll(r.PathValue("token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "poll not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "error getting poll", http.StatusInternalServerError)
+		slog.Error("error getting poll", "handler", "vote_poll", "err", err.Error())
+		return
+	}
+	if p.Status != api.PollOpen {
+		http.Error(w, "poll is closed", http.StatusConflict)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "vote_poll", "err", err.Error())
+		return
+	}
+	ranking, err := parseRanking(r, p.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.storeFor(r).SaveBallot(p.ID, &api.Ballot{Username: username, Ranking: ranking}); err != nil {
+		http.Error(w, "error saving ballot", http.StatusInternalServerError)
+		slog.Error("error saving ballot", "handler", "vote_poll", "err", err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/polls/%s", p.Token), http.StatusSeeOther)
+}
+
+// ClosePollHandler tallies the ballots and marks the winner as watched for the voters given as form values "attendee",
+// only the creator of the poll or an admin may close it
+func (h *Handler) ClosePollHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	p, ballots, ok := h.pollWithBallots(w, r, "close_poll")
+	if !ok {
+		return
+	}
+	if !h.ownerOrAdmin(p.CreatedBy, username) {
+		http.Error(w, "only the creator or an admin can close the poll", http.StatusForbidden)
+		return
+	}
+	if len(ballots) == 0 {
+		http.Error(w, "nobody has voted yet", http.StatusConflict)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "close_poll", "err", err.Error())
+		return
+	}
+	var attendees []string
+	for _, attendee := range r.Form["attendee"] {
+		if slices.Contains(p.Voters, attendee) && !slices.Contains(attendees, attendee) {
+			attendees = append(attendees, attendee)
+		}
+	}
+	winner := tally(p, ballots).Winner
+	err := h.storeFor(r).ClosePoll(p.ID, winner, attendees, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "poll is closed", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "error closing poll", http.StatusInternalServerError)
+		slog.Error("error closing poll", "handler", "close_poll", "err", err.Error())
+		return
+	}
+	if len(attendees) > 0 {
+		h.publish(r, api.EventMovieWatched, winner, "", map[string]any{
+			"watched_at": time.Now().Format(time.DateOnly),
+			"note":       p.Title,
+			"attendees":  attendees,
+		})
+	}
+	http.Redirect(w, r, fmt.Sprintf("/polls/%s", p.Token), http.StatusSeeOther)
+}
+
+// pollWithBallots loads the poll of the token path value and its ballots, writing the error response on failure
+func (h *Handler) pollWithBallots(w http.ResponseWriter, r *http.Request, handler string) (*api.Poll, []*api.Ballot, bool) {
+	p, err := h.store.GetPoll(r.PathValue("token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "poll not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	if err != nil {
+		http.Error(w, "error getting poll", http.StatusInternalServerError)
+		slog.Error("error getting poll", "handler", handler, "err", err.Error())
+		return nil, nil, false
+	}
+	ballots, err := h.store.GetBallots(p.ID)
+	if err != nil {
+		http.Error(w, "error getting ballots", http.StatusInternalServerError)
+		slog.Error("error getting ballots", "handler", handler, "err", err.Error())
+		return nil, nil, false
+	}
+	return p, ballots, true
+}
+
+// parseRanking orders the options by their rank form values, ranks have to be unique
+func parseRanking(r *http.Request, options []*api.PollOption) ([]string, error) {
+	ranked := make(map[int]string)
+	for _, option := range options {
+		value := r.FormValue("rank_" + option.MediaID)
+		if value == "" {
+			continue
+		}
+		rank, err := strconv.Atoi(value)
+		if err != nil || rank < 1 || rank > len(options) {
+			return nil, fmt.Errorf("not a valid rank: %s", value)
+		}
+		if _, ok := ranked[rank]; ok {
+			return nil, fmt.Errorf("rank %d is given twice", rank)
+		}
+		ranked[rank] = option.MediaID
+	}
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("rank at least one movie")
+	}
+	ranks := make([]int, 0, len(ranked))
+	for rank := range ranked {
+		ranks = append(ranks, rank)
+	}
+	slices.Sort(ranks)
+	ranking := make([]string, len(ranks))
+	for i, rank := range ranks {
+		ranking[i] = ranked[rank]
+	}
+	return ranking, nil
+}
+
+func tally(p *api.Poll, ballots []*api.Ballot) *poll.Result {
+	options := make([]string, len(p.Options))
+	for i, option := range p.Options {
+		options[i] = option.MediaID
+	}
+	rankings := make([][]string, len(ballots))
+	for i, ballot := range ballots {
+		rankings[i] = ballot.Ranking
+	}
+	return poll.Tally(options, rankings)
+}
+
+// tallyRows lays out the rounds of a tally as table rows
+func tallyRows(p *api.Poll, result *poll.Result) ([]int, []TallyRow) {
+	rows := make([]TallyRow, 0, len(p.Options)+1)
+	for _, option := range p.Options {
+		row := TallyRow{Title: option.Title}
+		for _, round := range result.Rounds {
+			if count, ok := round.Counts[option.MediaID]; ok {
+				row.Counts = append(row.Counts, strconv.Itoa(count))
+			} else {
+				row.Counts = append(row.Counts, "")
+			}
+		}
+		rows = append(rows, row)
+	}
+	exhausted := TallyRow{Title: "No choice left"}
+	numbers := make([]int, len(result.Rounds))
+	for i, round := range result.Rounds {
+		exhausted.Counts = append(exhausted.Counts, strconv.Itoa(round.Exhausted))
+		numbers[i] = i + 1
+	}
+	return numbers, append(rows, exhausted)
+}
+
+// pollToken returns a random token for the shared link of a poll
+func pollToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func Test_parseRanking(t *testing.T) {
+	options := []*api.PollOption{{MediaID: "tt0000001"}, {MediaID: "tt0000002"}, {MediaID: "tt0000003"}}
+	tests := []struct {
+		name    string
+		form    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "all ranked",
+			form: "rank_tt0000001=3&rank_tt0000002=1&rank_tt0000003=2",
+			want: []string{"tt0000002", "tt0000003", "tt0000001"},
+		},
+		{
+			name: "unranked options and gaps",
+			form: "rank_tt0000001=3&rank_tt0000002=&rank_tt0000003=1",
+			want: []string{"tt0000003", "tt0000001"},
+		},
+		{
+			name:    "rank given twice",
+			form:    "rank_tt0000001=1&rank_tt0000002=1",
+			wantErr: true,
+		},
+		{
+			name:    "rank out of range",
+			form:    "rank_tt0000001=4",
+			wantErr: true,
+		},
+		{
+			name:    "nothing ranked",
+			form:    "rank_tt0000004=1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/polls/token/vote", strings.NewReader(tt.form))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseRanking(r, options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRanking() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseRanking() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/poster"
+)
+
+const (
+	// posterMaxAge is how long browsers may cache a poster, a refresh may replace it
+	posterMaxAge = "public, max-age=86400"
+	// placeholderMaxAge is shorter, so posters of new releases show up soon after they are added
+	placeholderMaxAge = "public, max-age=3600"
+	// posterMissTTL is how long a failed download is remembered, as long as browsers keep the placeholder
+	posterMissTTL = time.Hour
+)
+
+// PosterHandler serves the locally stored poster of a movie in the requested size
+// posters are downloaded on first request, movies without a poster get a placeholder
+// OMDb is never asked, a movie which is neither cached nor stored waits for its poster until it is added
+func (h *Handler) PosterHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdb")
+	if !validPath.MatchString(id) {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	size, ok := poster.ParseSize(r.PathValue("size"))
+	if !ok {
+		http.Error(w, "unknown poster size", http.StatusNotFound)
+		return
+	}
+	if !h.posters.Has(id, size) {
+		if err := h.fetchPoster(r.Context(), id); err != nil {
+			if !errors.Is(err, poster.ErrNoPoster) {
+				slog.Warn("error fetching poster", "handler", "poster", "id", id, "err", err.Error())
+			}
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Header().Set("Cache-Control", placeholderMaxAge)
+			w.Write(poster.Placeholder)
+			return
+		}
+	}
+	w.Header().Set("Cache-Control", posterMaxAge)
+	http.ServeFile(w, r, h.posters.Path(id, size))
+}
+
+// fetchPoster downloads the poster of a cached or stored movie
+// a failed download is remembered for posterMissTTL, so pages listing movies without a poster
+// do not download again on every view
+func (h *Handler) fetchPoster(ctx context.Context, id string) error {
+	if _, missed := h.posterMisses.Get(id); missed {
+		return poster.ErrNoPoster
+	}
+	mov, ok := h.movCache.Get(id)
+	if !ok {
+		var err error
+		if mov, err = h.store.GetMovieByID(id); err != nil {
+			return err
+		}
+	}
+	err := h.posters.Fetch(ctx, id, mov.Poster)
+	// a request cancelled by the browser says nothing about the poster
+	if err != nil && ctx.Err() == nil {
+		h.posterMisses.Set(id, true)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/poster"
+)
+
+func TestHandler_PosterHandler(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 450))); err != nil {
+		t.Fatal(err)
+	}
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	h, s := newTestHandler(t)
+	h.posters = poster.NewStore(t.TempDir())
+	for id, url := range map[string]string{"tt0078748": srv.URL + "/alien.png", "tt0090605": srv.URL + "/missing.jpg"} {
+		if _, err := s.CreateMovie(&api.Movie{ImdbID: id, Title: id, Type: "movie", Poster: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name            string
+		imdb            string
+		wantCacheHeader string
+		wantHits        int32
+	}{
+		{name: "downloaded", imdb: "tt0078748", wantCacheHeader: posterMaxAge, wantHits: 1},
+		{name: "stored", imdb: "tt0078748", wantCacheHeader: posterMaxAge, wantHits: 1},
+		{name: "download failed", imdb: "tt0090605", wantCacheHeader: placeholderMaxAge, wantHits: 2},
+		{name: "failed download is remembered", imdb: "tt0090605", wantCacheHeader: placeholderMaxAge, wantHits: 2},
+		{name: "movie not stored", imdb: "tt0062622", wantCacheHeader: placeholderMaxAge, wantHits: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.PosterHandler, "GET", "alice", "", map[string]string{"imdb": tt.imdb, "size": "thumb"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("code = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCacheHeader {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheHeader)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("poster server hit %d times, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
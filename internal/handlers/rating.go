@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+// SetUserRatingHandler stores the star rating of the logged in user
+// a form value "score" of 0 removes the rating
+func (h *Handler) SetUserRatingHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdb")
+	if !validPath.MatchString(id) {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		slog.Error("could not match id", "id", id, "handler", "set_user_rating")
+		return
+	}
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "set_user_rating", "err", err.Error())
+		return
+	}
+	score, err := strconv.ParseFloat(r.FormValue("score"), 64)
+	if err != nil || (score != 0 && !api.ValidStarScore(score)) {
+		http.Error(w, "not a valid score", http.StatusBadRequest)
+		return
+	}
+	if score == 0 {
+		err = h.storeFor(r).DeleteUserRating(username, id)
+	} else if err = h.ensureMovieStored(r, id); err == nil {
+		err = h.storeFor(r).SetUserRating(&api.UserRating{
+			Username: username,
+			MediaID:  id,
+			Score:    score,
+			RatedAt:  time.Now(),
+		})
+	}
+	if err != nil {
+		http.Error(w, "error saving rating", http.StatusInternalServerError)
+		slog.Error("error saving rating", "handler", "set_user_rating", "err", err.Error())
+		return
+	}
+	h.publish(r, api.EventRatingChanged, id, "", map[string]any{"score": score})
+	http.Redirect(w, r, fmt.Sprintf("/films/%s", id), http.StatusSeeOther)
+}
+
+// attachRatings adds the personal rating of the logged in user and the group rating to every movie
+func (h *Handler) attachRatings(r *http.Request, movies []*api.MovieInfoData) error {
+	groupRatings, err := h.store.GetGroupRatings()
+	if err != nil {
+		return err
+	}
+	myRatings := map[string]float64{}
+	if username, ok := auth.UserFromContext(r.Context()); ok {
+		myRatings, err = h.store.GetRatingsByUser(username)
+		if err != nil {
+			return err
+		}
+	}
+	for _, m := range movies {
+		m.MyRating = myRatings[m.Movie.ImdbID]
+		m.GroupRating = groupRatings[m.Movie.ImdbID]
+	}
+	return nil
+}
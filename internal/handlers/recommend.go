@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/recommend"
+)
+
+const (
+	// maxSimilar limits the similar movies shown on the info page
+	maxSimilar = 5
+	// maxRecommendations limits the recommendations page
+	maxRecommendations = 25
+	// indexMaxAge is how long the recommendation index is kept,
+	// movies stored by other processes like cmd/import show up after it at the latest
+	indexMaxAge = time.Hour
+)
+
+type RecommendationsPage struct {
+	Recommendations []*api.Recommendation
+	Error           error
+}
+
+// RecommendationsHandler ranks the movies the logged in user has not seen by their similarity to movies the user rated highly
+func (h *Handler) RecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	page := RecommendationsPage{}
+	idx, seen, err := h.recommendIndex(username)
+	if err != nil {
+		page.Error = fmt.Errorf("error getting recommendations")
+		slog.Error("error building recommendation index", "handler", "recommendations", "err", err.Error())
+		renderTemplate(w, "recommendations", page)
+		return
+	}
+	ratings, err := h.store.GetRatingsByUser(username)
+	if err != nil {
+		page.Error = fmt.Errorf("error getting ratings")
+		slog.Error("error getting ratings", "handler", "recommendations", "err", err.Error())
+		renderTemplate(w, "recommendations", page)
+		return
+	}
+	page.Recommendations = idx.Recommend(ratings, seen, maxRecommendations)
+	renderTemplate(w, "recommendations", page)
+}
+
+// similarMovies returns the movies of the logged in user's list most similar to the movie with id
+// which the user has not seen
+func (h *Handler) similarMovies(r *http.Request, id string) ([]*api.Recommendation, error) {
+	username, _ := auth.UserFromContext(r.Context())
+	idx, seen, err := h.recommendIndex(username)
+	if err != nil {
+		return nil, err
+	}
+	listed, err := h.store.GetListedMediaIDs(username)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[string]bool, len(listed))
+	for _, listedID := range listed {
+		if !seen[listedID] && listedID != id {
+			candidates[listedID] = true
+		}
+	}
+	return idx.Similar(id, candidates, maxSimilar), nil
+}
+
+// recommendIndex returns the similarity index of all stored movies and the ids of movies seen by username
+func (h *Handler) recommendIndex(username string) (*recommend.Index, map[string]bool, error) {
+	idx, err := h.libraryIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	seenIDs, err := h.store.GetSeenMediaIDs(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := make(map[string]bool, len(seenIDs))
+	for _, id := range seenIDs {
+		seen[id] = true
+	}
+	return idx, seen, nil
+}
+
+// libraryIndex returns the cached similarity index, it is built again after InvalidateIndex or indexMaxAge
+func (h *Handler) libraryIndex() (*recommend.Index, error) {
+	h.indexMu.Lock()
+	defer h.indexMu.Unlock()
+	if h.index != nil && time.Since(h.indexBuilt) < indexMaxAge {
+		return h.index, nil
+	}
+	features, err := h.store.GetMediaFeatures()
+	if err != nil {
+		return nil, err
+	}
+	h.index, h.indexBuilt = recommend.NewIndex(features), time.Now()
+	return h.index, nil
+}
+
+// InvalidateIndex drops the cached similarity index, it must be called whenever a movie is created, updated or deleted
+func (h *Handler) InvalidateIndex() {
+	h.indexMu.Lock()
+	h.index = nil
+	h.indexMu.Unlock()
+}
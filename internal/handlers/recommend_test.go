@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// testListedMovie stores a sci-fi movie by the director with an entry of the user unless user is empty
+func testListedMovie(t *testing.T, s *store.SQLiteStorage, id, title, director, user string, watched bool) {
+	t.Helper()
+	mov := &api.Movie{ImdbID: id, Title: title, Type: "movie", Genre: "Sci-Fi", Director: director}
+	if _, err := s.CreateMovie(mov); err != nil {
+		t.Fatal(err)
+	}
+	if user == "" {
+		return
+	}
+	if _, err := s.CreateEntry(api.NewEntry(user, watched, ""), mov); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler_similarMovies(t *testing.T) {
+	h, s := newTestHandler(t)
+	testListedMovie(t, s, "tt0078748", "Alien", "Ridley Scott", "", false)
+	testListedMovie(t, s, "tt0083658", "Blade Runner", "Ridley Scott", "alice", false)
+	testListedMovie(t, s, "tt0090605", "Aliens", "James Cameron", "alice", true)
+	testListedMovie(t, s, "tt1446714", "Prometheus", "Ridley Scott", "bob", false)
+
+	similar := func() []string {
+		t.Helper()
+		r := httptest.NewRequest("GET", "/", nil)
+		r = r.WithContext(auth.WithUser(r.Context(), "alice"))
+		recommendations, err := h.similarMovies(r, "tt0078748")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, rec := range recommendations {
+			ids = append(ids, rec.MediaID)
+		}
+		return ids
+	}
+	// seen movies and movies of other lists are left out
+	if diff := cmp.Diff([]string{"tt0083658"}, similar()); diff != "" {
+		t.Errorf("similar movies mismatch (-want +got):\n%s", diff)
+	}
+
+	testListedMovie(t, s, "tt0057012", "Dr. Strangelove", "Stanley Kubrick", "alice", false)
+	if _, err := s.UpdateMovie(&api.Movie{ImdbID: "tt0057012", Title: "Dr. Strangelove", Type: "movie", Genre: "Sci-Fi", Director: "Ridley Scott"}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"tt0083658"}, similar()); diff != "" {
+		t.Errorf("similar movies before invalidating the index mismatch (-want +got):\n%s", diff)
+	}
+	h.InvalidateIndex()
+	if diff := cmp.Diff([]string{"tt0083658", "tt0057012"}, similar()); diff != "" {
+		t.Errorf("similar movies after invalidating the index mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+// CreateSavedSearchHandler saves the search query under a name for the logged in user
+// form values "name" and "query" are expected, the query must be valid for parseSearchQuery
+func (h *Handler) CreateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "create_saved_search", "err", err.Error())
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "a saved search needs a name", http.StatusBadRequest)
+		return
+	}
+	query := strings.TrimSpace(r.FormValue("query"))
+	if _, err := parseSearchQuery(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	searches, err := h.store.GetSavedSearches(username)
+	if err != nil {
+		http.Error(w, "error getting saved searches", http.StatusInternalServerError)
+		slog.Error("error getting saved searches", "handler", "create_saved_search", "err", err.Error())
+		return
+	}
+	if slices.ContainsFunc(searches, func(s *api.SavedSearch) bool { return strings.EqualFold(s.Name, name) }) {
+		http.Error(w, "a saved search with this name exists", http.StatusConflict)
+		return
+	}
+	search, err := h.storeFor(r).CreateSavedSearch(&api.SavedSearch{
+		Username:  username,
+		Name:      name,
+		Query:     query,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		http.Error(w, "error saving search", http.StatusInternalServerError)
+		slog.Error("error saving search", "handler", "create_saved_search", "err", err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/searches/%d", search.ID), http.StatusSeeOther)
+}
+
+// SavedSearchHandler shows the overview with the current results of a saved search
+// saved searches are private, other users get a not found
+func (h *Handler) SavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	username, _ := auth.UserFromContext(r.Context())
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	search, err := h.store.GetSavedSearch(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && search.Username != username) {
+		http.Error(w, "saved search not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "error getting saved search", http.StatusInternalServerError)
+		slog.Error("error getting saved search", "handler", "saved_search", "err", err.Error())
+		return
+	}
+	h.renderSearch(w, r, api.MovieOverviewData{SavedSearch: search}, search.Query)
+}
+
+func (h *Handler) DeleteSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	username, _ := auth.UserFromContext(r.Context())
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	err = h.storeFor(r).DeleteSavedSearch(id, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "saved search not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "error deleting saved search", http.StatusInternalServerError)
+		slog.Error("error deleting saved search", "handler", "delete_saved_search", "err", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// attachSavedSearches sets the saved searches of the logged in user for the navigation
+func (h *Handler) attachSavedSearches(r *http.Request, data *api.MovieOverviewData) error {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	searches, err := h.store.GetSavedSearches(username)
+	if err != nil {
+		return err
+	}
+	data.SavedSearches = searches
+	return nil
+}
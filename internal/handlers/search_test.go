@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/store"
+)
+
+// testSavedSearch stores a saved search of the user
+func testSavedSearch(t *testing.T, s *store.SQLiteStorage, username, name string) *api.SavedSearch {
+	t.Helper()
+	search, err := s.CreateSavedSearch(&api.SavedSearch{Username: username, Name: name, Query: "genre:horror", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return search
+}
+
+func TestHandler_CreateSavedSearchHandler(t *testing.T) {
+	h, s := newTestHandler(t)
+	testSavedSearch(t, s, "alice", "Halloween")
+	tests := []struct {
+		name     string
+		user     string
+		search   string
+		query    string
+		wantCode int
+	}{
+		{name: "no name", user: "alice", search: " ", query: "genre:horror", wantCode: http.StatusBadRequest},
+		{name: "invalid query", user: "alice", search: "Broken", query: "genre horror", wantCode: http.StatusBadRequest},
+		{name: "unknown search type", user: "alice", search: "Broken", query: "mood:gloomy", wantCode: http.StatusBadRequest},
+		{name: "duplicate name ignoring case", user: "alice", search: "HALLOWEEN", query: "genre:thriller", wantCode: http.StatusConflict},
+		{name: "same name of another user", user: "bob", search: "Halloween", query: "genre:thriller", wantCode: http.StatusSeeOther},
+		{name: "saved", user: "alice", search: "Short", query: "runtime:,90", wantCode: http.StatusSeeOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"name": {tt.search}, "query": {tt.query}}.Encode()
+			w := serve(h.CreateSavedSearchHandler, "POST", tt.user, form, nil)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+
+	searches, err := s.GetSavedSearches("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(searches) != 2 || searches[0].Name != "Halloween" || searches[1].Name != "Short" {
+		t.Errorf("got saved searches %v, want Halloween and Short", searches)
+	}
+}
+
+func TestHandler_SavedSearchHandler(t *testing.T) {
+	h, s := newTestHandler(t)
+	search := testSavedSearch(t, s, "bob", "Halloween")
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{name: "not a number", id: "first", wantCode: http.StatusBadRequest},
+		{name: "unknown", id: "999", wantCode: http.StatusNotFound},
+		{name: "search of another user", id: strconv.FormatInt(search.ID, 10), wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.SavedSearchHandler, "GET", "alice", "", map[string]string{"id": tt.id})
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteSavedSearchHandler(t *testing.T) {
+	h, s := newTestHandler(t)
+	search := testSavedSearch(t, s, "bob", "Halloween")
+	id := strconv.FormatInt(search.ID, 10)
+	tests := []struct {
+		name     string
+		user     string
+		wantCode int
+	}{
+		{name: "search of another user", user: "alice", wantCode: http.StatusNotFound},
+		{name: "owner", user: "bob", wantCode: http.StatusNoContent},
+		{name: "already deleted", user: "bob", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.DeleteSavedSearchHandler, "DELETE", tt.user, "", map[string]string{"id": id})
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -2,14 +2,29 @@ package handlers
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
 
 	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/chart"
+	"github.com/jhachmer/gomovie/internal/util"
+)
+
+const (
+	// maxGenreBars limits the genre chart to the most common genres
+	maxGenreBars = 10
+	// maxMonthBars limits the watch chart to the most recent months
+	maxMonthBars = 12
 )
 
 type StatsPage struct {
-	WatchStats *api.WatchStats
-	Error      error
+	WatchStats   *api.WatchStats
+	WatchedChart template.HTML
+	GenreChart   template.HTML
+	YearChart    template.HTML
+	WatchesChart template.HTML
+	Rewatches    []*api.CountStat
+	Error        error
 }
 
 func newStatsPage(h *Handler) (StatsPage, error) {
@@ -17,12 +32,49 @@ func newStatsPage(h *Handler) (StatsPage, error) {
 	if err != nil {
 		return StatsPage{}, err
 	}
+	genreCounts, err := h.store.GetGenreCounts()
+	if err != nil {
+		return StatsPage{}, err
+	}
+	years, err := h.store.GetReleaseYears()
+	if err != nil {
+		return StatsPage{}, err
+	}
+	watchesPerMonth, err := h.store.GetWatchesPerMonth()
+	if err != nil {
+		return StatsPage{}, err
+	}
+	rewatches, err := h.store.GetRewatchCounts()
+	if err != nil {
+		return StatsPage{}, err
+	}
+	if len(watchesPerMonth) > maxMonthBars {
+		watchesPerMonth = watchesPerMonth[len(watchesPerMonth)-maxMonthBars:]
+	}
+	if len(genreCounts) > maxGenreBars {
+		genreCounts = genreCounts[:maxGenreBars]
+	}
+	yearValues := util.Map(years, func(y int) float64 { return float64(y) })
 	return StatsPage{
 		WatchStats: watchStats,
-		Error:      nil,
+		WatchedChart: chart.Pie([]chart.Point{
+			{Label: "Watched", Value: float64(watchStats.NumOfWatched)},
+			{Label: "Unwatched", Value: float64(watchStats.NumOfUnwatched)},
+		}),
+		GenreChart:   chart.Bar(countPoints(genreCounts)),
+		YearChart:    chart.Histogram(yearValues, 10),
+		WatchesChart: chart.Bar(countPoints(watchesPerMonth)),
+		Rewatches:    rewatches,
+		Error:        nil,
 	}, nil
 }
 
+func countPoints(counts []*api.CountStat) []chart.Point {
+	return util.Map(counts, func(c *api.CountStat) chart.Point {
+		return chart.Point{Label: c.Name, Value: float64(c.Count)}
+	})
+}
+
 func (h *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	statsPage, err := newStatsPage(h)
 	if err != nil {
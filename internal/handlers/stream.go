@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+const (
+	// keepAliveInterval keeps idle streams open through proxies
+	keepAliveInterval = 25 * time.Second
+	// replayPageSize is the number of stored events read at once when a client catches up
+	replayPageSize = 200
+	// reconnectDelay is the time in milliseconds clients wait before reconnecting
+	reconnectDelay = 3000
+)
+
+// EventsHandler streams events as Server-Sent Events until the client goes away or the server shuts down
+// clients reconnecting with a Last-Event-ID header, or a last_event_id query value, first get the events they missed
+func (h *Handler) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		http.Error(w, "events are not available", http.StatusServiceUnavailable)
+		return
+	}
+	lastID := int64(0)
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastID, _ = strconv.ParseInt(id, 10, 64)
+	} else if id := r.URL.Query().Get("last_event_id"); id != "" {
+		lastID, _ = strconv.ParseInt(id, 10, 64)
+	}
+
+	// subscribe before catching up, so no event falls between replay and stream
+	published, cancel := h.events.Subscribe()
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+
+	stream := eventStream{w: w, lastID: lastID}
+	if lastID > 0 {
+		if err := h.replayEvents(&stream, 0); err != nil {
+			slog.Error("error replaying events", "handler", "events", "err", err.Error())
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		slog.Error("streaming not supported", "handler", "events", "err", err.Error())
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case e := <-published:
+			// events dropped for this stream while it was busy are read from the store
+			if stream.lastID > 0 && e.ID > stream.lastID+1 {
+				err = h.replayEvents(&stream, e.ID)
+			}
+			if err == nil {
+				err = stream.send(e)
+			}
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// replayEvents sends the stored events after the last sent one, up to but excluding the id before, 0 sends all
+func (h *Handler) replayEvents(stream *eventStream, before int64) error {
+	for {
+		events, err := h.store.GetEventsAfter(stream.lastID, replayPageSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if before > 0 && e.ID >= before {
+				return nil
+			}
+			if err := stream.send(e); err != nil {
+				return err
+			}
+		}
+		if len(events) < replayPageSize {
+			return nil
+		}
+	}
+}
+
+// eventStream writes events in the text/event-stream format, skipping events sent before
+type eventStream struct {
+	w      http.ResponseWriter
+	lastID int64
+}
+
+func (s *eventStream) send(e *api.Event) error {
+	if e.ID <= s.lastID {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+		return err
+	}
+	s.lastID = e.ID
+	return nil
+}
+
+// StopStreams ends all open event streams, so a graceful shutdown does not wait for them
+func (h *Handler) StopStreams() {
+	h.stopStreams.Do(func() { close(h.done) })
+}
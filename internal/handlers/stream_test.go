@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/events"
+)
+
+func Test_eventStream_send(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := eventStream{w: w, lastID: 1}
+	created := time.Date(2026, 10, 19, 20, 15, 0, 0, time.UTC)
+	for _, e := range []*api.Event{
+		{ID: 1, Type: api.EventMovieAdded, MediaID: "tt0078748", CreatedAt: created},
+		{ID: 2, Type: api.EventMovieWatched, Username: "jan", MediaID: "tt0078748", Title: "Alien", CreatedAt: created},
+		{ID: 2, Type: api.EventMovieWatched, MediaID: "tt0078748", CreatedAt: created},
+	} {
+		if err := stream.send(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "id: 2\nevent: movie.watched\n" +
+		`data: {"id":2,"type":"movie.watched","user":"jan","imdb_id":"tt0078748","title":"Alien","created_at":"2026-10-19T20:15:00Z"}` +
+		"\n\n"
+	if diff := cmp.Diff(want, w.Body.String()); diff != "" {
+		t.Errorf("stream mismatch (-want +got):\n%s", diff)
+	}
+	if stream.lastID != 2 {
+		t.Errorf("lastID = %d, want 2", stream.lastID)
+	}
+}
+
+func TestHandler_EventsHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		query  string
+	}{
+		{name: "Last-Event-ID header", header: "1"},
+		{name: "last_event_id query value", query: "?last_event_id=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, s := newTestHandler(t)
+			bus := events.NewBus(s)
+			h.events = bus
+			publish := func(e *api.Event) {
+				t.Helper()
+				if err := bus.Publish(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for range 3 {
+				publish(&api.Event{Type: api.EventMovieAdded, MediaID: "tt0078748"})
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(h.EventsHandler))
+			defer srv.Close()
+			defer h.StopStreams()
+			req, err := http.NewRequest("GET", srv.URL+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			ids := make(chan int64)
+			go func() {
+				defer close(ids)
+				scanner := bufio.NewScanner(res.Body)
+				for scanner.Scan() {
+					if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+						n, _ := strconv.ParseInt(id, 10, 64)
+						ids <- n
+					}
+				}
+			}()
+			var got []int64
+			read := func(n int) {
+				t.Helper()
+				timeout := time.After(5 * time.Second)
+				for range n {
+					select {
+					case id, ok := <-ids:
+						if !ok {
+							t.Fatalf("stream ended after ids %v", got)
+						}
+						got = append(got, id)
+					case <-timeout:
+						t.Fatalf("got ids %v, timed out waiting for more", got)
+					}
+				}
+			}
+			// the events after the last one the client saw are replayed first
+			read(2)
+
+			publish(&api.Event{Type: api.EventMovieWatched, MediaID: "tt0078748"})
+			// an event the stream did not receive is refilled from the store before the next one
+			if err := s.CreateEvent(&api.Event{Type: api.EventEntryCreated, MediaID: "tt0078748", CreatedAt: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			publish(&api.Event{Type: api.EventEntryDeleted, MediaID: "tt0078748"})
+			read(3)
+
+			if diff := cmp.Diff([]int64{2, 3, 4, 5, 6}, got); diff != "" {
+				t.Errorf("event ids mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
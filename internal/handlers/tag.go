@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+// maxTagLength matches the tags table
+const maxTagLength = 50
+
+// AddTagsHandler tags the movie with the comma separated form value "tags"
+func (h *Handler) AddTagsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdb")
+	if !validPath.MatchString(id) {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		slog.Error("could not match id", "id", id, "handler", "add_tags")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "add_tags", "err", err.Error())
+		return
+	}
+	tags, err := parseTags(r.FormValue("tags"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.ensureMovieStored(r, id); err != nil {
+		http.Error(w, fmt.Sprintf("error saving movie: %s", err.Error()), http.StatusInternalServerError)
+		slog.Error("error saving movie", "handler", "add_tags", "err", err.Error())
+		return
+	}
+	if err := h.storeFor(r).AddMediaTags(id, tags); err != nil {
+		http.Error(w, "error saving tags", http.StatusInternalServerError)
+		slog.Error("error saving tags", "handler", "add_tags", "err", err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/films/%s", id), http.StatusSeeOther)
+}
+
+// RemoveTagHandler removes a tag from the movie
+func (h *Handler) RemoveTagHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdb")
+	if !validPath.MatchString(id) {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		slog.Error("could not match id", "id", id, "handler", "remove_tag")
+		return
+	}
+	err := h.storeFor(r).RemoveMediaTag(id, r.PathValue("tag"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "movie does not have this tag", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "error removing tag", http.StatusInternalServerError)
+		slog.Error("error removing tag", "handler", "remove_tag", "err", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateCollectionHandler creates a collection from form values "name", comma separated "tags"
+// and "match_all" to require all tags instead of any
+func (h *Handler) CreateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "create_collection", "err", err.Error())
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "a collection needs a name", http.StatusBadRequest)
+		return
+	}
+	tags, err := parseTags(r.FormValue("tags"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	collections, err := h.store.GetCollections()
+	if err != nil {
+		http.Error(w, "error getting collections", http.StatusInternalServerError)
+		slog.Error("error getting collections", "handler", "create_collection", "err", err.Error())
+		return
+	}
+	if slices.ContainsFunc(collections, func(c *api.Collection) bool { return strings.EqualFold(c.Name, name) }) {
+		http.Error(w, "a collection with this name exists", http.StatusConflict)
+		return
+	}
+	c, err := h.storeFor(r).CreateCollection(&api.Collection{
+		Name:      name,
+		Tags:      tags,
+		MatchAll:  r.FormValue("match_all") != "",
+		CreatedBy: username,
+	})
+	if err != nil {
+		http.Error(w, "error saving collection", http.StatusInternalServerError)
+		slog.Error("error saving collection", "handler", "create_collection", "err", err.Error())
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/overview?collection=%d", c.ID), http.StatusSeeOther)
+}
+
+// DeleteCollectionHandler removes a collection, only its creator or an admin may delete it
+func (h *Handler) DeleteCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	username, _ := auth.UserFromContext(r.Context())
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	c, err := h.store.GetCollection(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "error getting collection", http.StatusInternalServerError)
+		slog.Error("error getting collection", "handler", "delete_collection", "err", err.Error())
+		return
+	}
+	if !h.ownerOrAdmin(c.CreatedBy, username) {
+		http.Error(w, "only the creator or an admin can delete the collection", http.StatusForbidden)
+		return
+	}
+	if err := h.storeFor(r).DeleteCollection(id); err != nil {
+		http.Error(w, "error deleting collection", http.StatusInternalServerError)
+		slog.Error("error deleting collection", "handler", "delete_collection", "err", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// attachTags adds the tags to every movie
+func (h *Handler) attachTags(movies []*api.MovieInfoData) error {
+	tags, err := h.store.GetMediaTags()
+	if err != nil {
+		return err
+	}
+	for _, m := range movies {
+		m.Tags = tags[m.Movie.ImdbID]
+	}
+	return nil
+}
+
+// attachCollections adds all collections with their number of movies and the tags in use to the overview
+func (h *Handler) attachCollections(data *api.MovieOverviewData) error {
+	collections, err := h.store.GetCollections()
+	if err != nil {
+		return err
+	}
+	mediaTags, err := h.store.GetMediaTags()
+	if err != nil {
+		return err
+	}
+	for _, c := range collections {
+		for _, tags := range mediaTags {
+			if c.Matches(tags) {
+				c.Count++
+			}
+		}
+	}
+	data.Collections = collections
+	data.AllTags, err = h.tagNames()
+	return err
+}
+
+// tagNames returns the names of all tags in use
+func (h *Handler) tagNames() ([]string, error) {
+	tags, err := h.store.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names, nil
+}
+
+// parseTags splits comma separated tags, dropping empty ones and repeats differing only in case
+// tags must not contain the separators of search queries or a slash, which the remove route could not match
+func parseTags(values string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.Split(values, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		if len(tag) > maxTagLength || strings.ContainsAny(tag, ";:/") {
+			return nil, fmt.Errorf("invalid tag: %s", tag)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	return tags, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+// TrashHandler lists the deleted movies and entries which can still be restored
+func (h *Handler) TrashHandler(w http.ResponseWriter, r *http.Request) {
+	page := api.TrashPage{RetentionDays: h.TrashDays}
+	username, _ := auth.UserFromContext(r.Context())
+	isAdmin, err := h.store.IsAdmin(username)
+	if err != nil {
+		page.Error = fmt.Errorf("error checking admin")
+		slog.Error("error checking admin", "handler", "trash", "err", err.Error())
+		renderTemplate(w, "trash", page)
+		return
+	}
+	page.IsAdmin = isAdmin
+	if page.Movies, err = h.store.GetTrashedMovies(); err != nil {
+		page.Error = fmt.Errorf("error getting trashed movies")
+		slog.Error("error getting trashed movies", "handler", "trash", "err", err.Error())
+		renderTemplate(w, "trash", page)
+		return
+	}
+	if page.Entries, err = h.store.GetTrashedEntries(); err != nil {
+		page.Error = fmt.Errorf("error getting trashed entries")
+		slog.Error("error getting trashed entries", "handler", "trash", "err", err.Error())
+	}
+	renderTemplate(w, "trash", page)
+}
+
+// RestoreMovieHandler takes a movie and the entries deleted with it out of the trash
+func (h *Handler) RestoreMovieHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdb")
+	err := h.storeFor(r).RestoreMedia(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "movie is not in the trash", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("error restoring movie", "handler", "restore_movie", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	h.InvalidateIndex()
+	h.publish(r, api.EventMovieAdded, id, "", nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurgeMovieHandler removes a trashed movie for good, admins only
+func (h *Handler) PurgeMovieHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	err := h.storeFor(r).PurgeMedia(r.PathValue("imdb"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "movie is not in the trash", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("error purging movie", "handler", "purge_movie", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreEntryHandler takes an entry out of the trash
+func (h *Handler) RestoreEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	err = h.storeFor(r).RestoreEntry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "entry is not in the trash", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("error restoring entry", "handler", "restore_entry", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurgeEntryHandler removes a trashed entry for good, admins only
+func (h *Handler) PurgeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	err = h.storeFor(r).PurgeEntry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "entry is not in the trash", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("error purging entry", "handler", "purge_entry", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/auth"
+)
+
+// CreateWatchEventHandler logs a viewing of the movie for the logged in user
+// form value "date" is expected as YYYY-MM-DD, an empty date logs an undated viewing
+func (h *Handler) CreateWatchEventHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdb")
+	if !validPath.MatchString(id) {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		slog.Error("could not match id", "id", id, "handler", "create_watch_event")
+		return
+	}
+	username, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "error parsing form", http.StatusBadRequest)
+		slog.Error("error parsing form", "handler", "create_watch_event", "err", err.Error())
+		return
+	}
+	event := &api.WatchEvent{
+		Username: username,
+		MediaID:  id,
+		Note:     r.FormValue("note"),
+	}
+	if date := r.FormValue("date"); date != "" {
+		watchedAt, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			http.Error(w, "not a valid date", http.StatusBadRequest)
+			slog.Error("error parsing date", "handler", "create_watch_event", "date", date, "err", err.Error())
+			return
+		}
+		event.WatchedAt = &watchedAt
+	}
+	if err := h.ensureMovieStored(r, id); err != nil {
+		http.Error(w, fmt.Sprintf("error saving movie: %s", err.Error()), http.StatusInternalServerError)
+		slog.Error("error saving movie", "handler", "create_watch_event", "err", err.Error())
+		return
+	}
+	if _, err := h.storeFor(r).CreateWatchEvent(event); err != nil {
+		http.Error(w, "error saving watch event", http.StatusInternalServerError)
+		slog.Error("error saving watch event", "handler", "create_watch_event", "err", err.Error())
+		return
+	}
+	data := map[string]any{"note": event.Note}
+	if event.WatchedAt != nil {
+		data["watched_at"] = event.WatchedAt.Format(time.DateOnly)
+	}
+	h.publish(r, api.EventMovieWatched, id, "", data)
+	http.Redirect(w, r, fmt.Sprintf("/films/%s", id), http.StatusSeeOther)
+}
+
+// DeleteWatchEventHandler removes a viewing of the movie, only the user who logged it or an admin may remove it
+func (h *Handler) DeleteWatchEventHandler(w http.ResponseWriter, r *http.Request) {
+	username, _ := auth.UserFromContext(r.Context())
+	eventID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	events, err := h.store.GetWatchEvents(r.PathValue("imdb"))
+	if err != nil {
+		http.Error(w, "error getting watch events", http.StatusInternalServerError)
+		slog.Error("error getting watch events", "handler", "delete_watch_event", "err", err.Error())
+		return
+	}
+	i := slices.IndexFunc(events, func(e *api.WatchEvent) bool { return e.ID == eventID })
+	if i < 0 {
+		http.Error(w, "watch event not found", http.StatusNotFound)
+		return
+	}
+	if !h.ownerOrAdmin(events[i].Username, username) {
+		http.Error(w, "only the user who logged the viewing or an admin can remove it", http.StatusForbidden)
+		return
+	}
+	if err := h.storeFor(r).DeleteWatchEvent(eventID); err != nil {
+		http.Error(w, "error deleting watch event", http.StatusInternalServerError)
+		slog.Error("error deleting watch event", "handler", "delete_watch_event", "err", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ensureMovieStored saves the movie to the database if it is not stored yet
+func (h *Handler) ensureMovieStored(r *http.Request, id string) error {
+	if _, err := h.store.GetMovieByID(id); err == nil {
+		return nil
+	}
+	mov, err := h.getMovie(id)
+	if err != nil {
+		return err
+	}
+	if _, err = h.storeFor(r).CreateMovie(mov); err != nil {
+		return err
+	}
+	h.InvalidateIndex()
+	return nil
+}
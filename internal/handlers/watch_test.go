@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func TestHandler_DeleteWatchEventHandler(t *testing.T) {
+	h, s := newTestHandler(t)
+	for _, id := range []string{"tt0078748", "tt0090605"} {
+		if _, err := s.CreateMovie(&api.Movie{ImdbID: id, Title: id, Type: "movie"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name     string
+		user     string
+		imdb     string
+		wantCode int
+	}{
+		{name: "other user", user: "bob", imdb: "tt0078748", wantCode: http.StatusForbidden},
+		{name: "other movie", user: "alice", imdb: "tt0090605", wantCode: http.StatusNotFound},
+		{name: "own viewing", user: "alice", imdb: "tt0078748", wantCode: http.StatusNoContent},
+		{name: "admin", user: "boss", imdb: "tt0078748", wantCode: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := s.CreateWatchEvent(&api.WatchEvent{Username: "alice", MediaID: "tt0078748"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := serve(h.DeleteWatchEventHandler, "DELETE", tt.user, "",
+				map[string]string{"imdb": tt.imdb, "id": strconv.FormatInt(event.ID, 10)})
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+			events, err := s.GetWatchEvents("tt0078748")
+			if err != nil {
+				t.Fatal(err)
+			}
+			deleted := len(events) == 0 || events[0].ID != event.ID
+			if deleted != (tt.wantCode == http.StatusNoContent) {
+				t.Errorf("event deleted = %v with code %d", deleted, w.Code)
+			}
+			for _, e := range events {
+				s.DeleteWatchEvent(e.ID)
+			}
+		})
+	}
+}
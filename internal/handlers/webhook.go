@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/webhook"
+)
+
+// deliveryLogSize is the number of deliveries listed per webhook
+const deliveryLogSize = 50
+
+// GetWebhooksHandler lists all webhooks without their secrets
+func (h *Handler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	hooks, err := h.store.GetWebhooks()
+	if err != nil {
+		slog.Error("error getting webhooks", "handler", "get_webhooks", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if hooks == nil {
+		hooks = []*api.Webhook{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// CreateWebhookHandler adds a webhook from a json body {"url": ..., "events": [...], "secret": ...}
+// no events subscribe to all, a missing secret is generated, the response is the only time it is returned
+func (h *Handler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	var request struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Secret string   `json:"secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if u, err := url.Parse(request.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url must be an absolute http or https url", http.StatusBadRequest)
+		return
+	}
+	for _, event := range request.Events {
+		if !slices.Contains(api.EventTypes, event) {
+			http.Error(w, "unknown event type "+event, http.StatusBadRequest)
+			return
+		}
+	}
+	hook := &api.Webhook{
+		URL:       request.URL,
+		Secret:    request.Secret,
+		Events:    request.Events,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+	if hook.Secret == "" {
+		hook.Secret = webhook.NewSecret()
+	}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	if err := h.storeFor(r).CreateWebhook(hook); err != nil {
+		slog.Error("error creating webhook", "handler", "create_webhook", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+// DeleteWebhookHandler removes a webhook with its queued deliveries
+func (h *Handler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	err = h.storeFor(r).DeleteWebhook(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "unknown webhook", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("error deleting webhook", "handler", "delete_webhook", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveriesHandler returns the delivery log of a webhook, newest first
+func (h *Handler) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "not a valid id", http.StatusBadRequest)
+		return
+	}
+	deliveries, err := h.store.GetDeliveries(id, deliveryLogSize)
+	if err != nil {
+		slog.Error("error getting deliveries", "handler", "get_webhook_deliveries", "err", err.Error())
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*api.WebhookDelivery{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Checkpoint records imported rows in a file, so an interrupted import resumes after the last stored row
+// a nil Checkpoint records nothing
+type Checkpoint struct {
+	f    *os.File
+	done map[string]bool
+}
+
+// OpenCheckpoint reads the rows recorded in path and appends new ones to it, the file is created if missing
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	done := make(map[string]bool)
+	f, err := os.Open(path)
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			done[scanner.Text()] = true
+		}
+		err = errors.Join(scanner.Err(), f.Close())
+		if err != nil {
+			return nil, fmt.Errorf("could not read checkpoint %s: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	f, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{f: f, done: done}, nil
+}
+
+// Done reports if the item was imported by an earlier run
+func (c *Checkpoint) Done(item Item) bool {
+	if c == nil {
+		return false
+	}
+	return c.done[checkpointKey(item)]
+}
+
+// Mark records the item as imported
+func (c *Checkpoint) Mark(item Item) error {
+	if c == nil {
+		return nil
+	}
+	key := checkpointKey(item)
+	c.done[key] = true
+	_, err := fmt.Fprintln(c.f, key)
+	return err
+}
+
+// Close closes the checkpoint file
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.f.Close()
+}
+
+func checkpointKey(item Item) string {
+	return fmt.Sprintf("%s:%d:%s:%s", item.File, item.Line, item.Kind, item.Query)
+}
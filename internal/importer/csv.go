@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// record is a csv row with its fields keyed by column header
+type record struct {
+	line   int
+	fields map[string]string
+}
+
+// get returns the trimmed value of a column, an unset column is always empty
+func (r record) get(column string) string {
+	if column == "" {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[column])
+}
+
+// readRecords reads a csv file with a header row, every required column must be present
+func readRecords(r io.Reader, required ...string) ([]record, error) {
+	return readTable(r, true, required...)
+}
+
+// readTable reads a csv file, fields are keyed by their header if the file has one
+// and always by their 1-based position written as "#1"
+func readTable(r io.Reader, hasHeader bool, required ...string) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	var header []string
+	if hasHeader {
+		var err error
+		header, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		}
+	}
+	for _, column := range required {
+		if _, ok := position(column); !ok && !slices.Contains(header, column) {
+			return nil, fmt.Errorf("missing column %q", column)
+		}
+	}
+
+	var records []record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rec := record{line: line, fields: make(map[string]string, 2*len(row))}
+		for i, value := range row {
+			rec.fields["#"+strconv.Itoa(i+1)] = value
+			// a blank header cell names no column
+			if i < len(header) && header[i] != "" {
+				rec.fields[header[i]] = value
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+// position parses a column given by position like "#3"
+func position(column string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(column, "#"))
+	if !strings.HasPrefix(column, "#") || err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// dryRunStore reads from the wrapped store but keeps all writes in memory
+// later rows of a dry run see the writes of earlier rows, so the report matches a real import
+type dryRunStore struct {
+	Store
+	movies  map[string]*api.Movie
+	entries map[string][]*api.Entry
+	events  map[string][]*api.WatchEvent
+}
+
+// DryRun returns a Store which leaves store unchanged
+func DryRun(store Store) Store {
+	return &dryRunStore{
+		Store:   store,
+		movies:  make(map[string]*api.Movie),
+		entries: make(map[string][]*api.Entry),
+		events:  make(map[string][]*api.WatchEvent),
+	}
+}
+
+func (d *dryRunStore) GetMovieByID(id string) (*api.Movie, error) {
+	if mov, ok := d.movies[id]; ok {
+		return mov, nil
+	}
+	return d.Store.GetMovieByID(id)
+}
+
+func (d *dryRunStore) CreateMovie(mov *api.Movie) (*api.Movie, error) {
+	d.movies[mov.ImdbID] = mov
+	return mov, nil
+}
+
+func (d *dryRunStore) GetEntries(mediaID string) ([]*api.Entry, error) {
+	if _, ok := d.movies[mediaID]; ok {
+		return d.entries[mediaID], nil
+	}
+	entries, err := d.Store.GetEntries(mediaID)
+	return append(entries, d.entries[mediaID]...), err
+}
+
+func (d *dryRunStore) CreateEntry(entry *api.Entry, mov *api.Movie) (*api.Entry, error) {
+	d.entries[mov.ImdbID] = append(d.entries[mov.ImdbID], entry)
+	return entry, nil
+}
+
+// MarkEntryWatched changes only entries added by the dry run, stored ones are left as they are
+func (d *dryRunStore) MarkEntryWatched(mediaID, name string) error {
+	markWatched(d.entries[mediaID], name)
+	return nil
+}
+
+func (d *dryRunStore) GetWatchEvents(mediaID string) ([]*api.WatchEvent, error) {
+	if _, ok := d.movies[mediaID]; ok {
+		return d.events[mediaID], nil
+	}
+	events, err := d.Store.GetWatchEvents(mediaID)
+	return append(events, d.events[mediaID]...), err
+}
+
+// CreateWatchEvent marks the entries of the user watched like the store does
+func (d *dryRunStore) CreateWatchEvent(event *api.WatchEvent) (*api.WatchEvent, error) {
+	d.events[event.MediaID] = append(d.events[event.MediaID], event)
+	markWatched(d.entries[event.MediaID], event.Username)
+	return event, nil
+}
+
+// markWatched marks the entries of name watched, names are compared case insensitive
+func markWatched(entries []*api.Entry, name string) {
+	for _, e := range entries {
+		if strings.EqualFold(e.Name, name) {
+			e.Watched = true
+		}
+	}
+}
+
+func (d *dryRunStore) SetUserRating(*api.UserRating) error {
+	return nil
+}
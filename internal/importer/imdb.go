@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// IMDb is the Origin of rows read from an IMDb export
+const IMDb = "IMDb"
+
+var validIMDbID = regexp.MustCompile(api.IMDbIDPattern)
+
+// ReadIMDb reads a ratings or watchlist csv exported from IMDb
+// watchlists are told apart by their Position column, rows of series and episodes are returned as unmatched
+func ReadIMDb(r io.Reader, file string) ([]Item, []Unmatched, error) {
+	records, err := readRecords(r, "Const", "Title", "Year")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+	var items []Item
+	var unmatched []Unmatched
+	for _, rec := range records {
+		item, err := imdbItem(file, rec)
+		if err != nil {
+			unmatched = append(unmatched, Unmatched{Item: item, Reason: err.Error()})
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, unmatched, nil
+}
+
+func imdbItem(file string, rec record) (Item, error) {
+	item := Item{
+		Origin: IMDb,
+		File:   file,
+		Line:   rec.line,
+		Kind:   Rating,
+		Query:  Query{IMDbID: rec.get("Const"), Title: rec.get("Title"), Year: rec.get("Year")},
+	}
+	if _, ok := rec.fields["Position"]; ok {
+		item.Kind = Watchlist
+	}
+	if !validIMDbID.MatchString(item.IMDbID) {
+		return item, fmt.Errorf("invalid IMDb id %q", item.IMDbID)
+	}
+	if titleType := rec.get("Title Type"); !isMovieType(titleType) {
+		return item, fmt.Errorf("unsupported title type %q", titleType)
+	}
+	if item.Kind == Watchlist {
+		return item, nil
+	}
+
+	rating, err := strconv.Atoi(rec.get("Your Rating"))
+	if err != nil || rating < 1 || rating > 10 {
+		return item, fmt.Errorf("invalid rating %q", rec.get("Your Rating"))
+	}
+	// IMDb rates from 1 to 10, every point is half a star
+	item.Score = float64(rating) / 2
+	item.RatedAt, err = time.Parse(time.DateOnly, rec.get("Date Rated"))
+	if err != nil {
+		return item, fmt.Errorf("invalid date rated %q", rec.get("Date Rated"))
+	}
+	return item, nil
+}
+
+// isMovieType reports if an IMDb title type can be stored as movie
+// an empty type is accepted, since older exports have no Title Type column
+func isMovieType(titleType string) bool {
+	for _, unsupported := range []string{"Series", "Episode", "Game", "Podcast"} {
+		if strings.Contains(titleType, unsupported) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// countingResolver resolves every IMDb id and counts lookups
+type countingResolver struct {
+	calls int
+}
+
+func (c *countingResolver) Resolve(q Query) (*api.Movie, error) {
+	c.calls++
+	if q.IMDbID == "" {
+		return nil, errors.New("no id")
+	}
+	return &api.Movie{ImdbID: q.IMDbID, Title: q.Title, Year: q.Year}, nil
+}
+
+func readIMDbFiles(t *testing.T) ([]Item, []Unmatched) {
+	t.Helper()
+	var items []Item
+	var unmatched []Unmatched
+	for _, name := range []string{"watchlist.csv", "ratings.csv"} {
+		f, err := os.Open(filepath.Join("testdata", "imdb", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		i, u, err := ReadIMDb(f, name)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, i...)
+		unmatched = append(unmatched, u...)
+	}
+	return items, unmatched
+}
+
+func TestReadIMDb(t *testing.T) {
+	items, unmatched := readIMDbFiles(t)
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind.String()+" "+item.Query.String())
+	}
+	want := []string{
+		"watchlist tt31193180",
+		"watchlist tt0078748",
+		"rating tt0078748",
+		"rating tt0062622",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("items mismatch (-want +got):\n%s", diff)
+	}
+	if items[2].Score != 4.5 || items[3].Score != 3.5 {
+		t.Errorf("scores = %v, %v, want 4.5, 3.5", items[2].Score, items[3].Score)
+	}
+	if got := items[2].RatedAt.Format("2006-01-02"); got != "2023-11-02" {
+		t.Errorf("RatedAt = %s, want 2023-11-02", got)
+	}
+	var reasons []string
+	for _, u := range unmatched {
+		reasons = append(reasons, u.Item.Title+": "+u.Reason)
+	}
+	wantReasons := []string{
+		`Breaking Bad: unsupported title type "TV Series"`,
+		`Broken Id: invalid IMDb id "tt123"`,
+	}
+	if diff := cmp.Diff(wantReasons, reasons); diff != "" {
+		t.Errorf("unmatched mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestImporter_ImportIMDb(t *testing.T) {
+	items, _ := readIMDbFiles(t)
+	store := newMemStore()
+	resolver := &countingResolver{}
+
+	dry := NewImporter(DryRun(store), resolver, "bob").Import(items)
+	want := Report{Rows: 4, Movies: 3, Entries: 3, WatchEvents: 2, Ratings: 2}
+	if diff := cmp.Diff(want, *dry, cmpReport); diff != "" {
+		t.Errorf("dry run report mismatch (-want +got):\n%s", diff)
+	}
+	if len(store.movies) != 0 || len(store.entries) != 0 || len(store.events) != 0 || len(store.ratings) != 0 {
+		t.Fatalf("dry run changed the store: %+v", store)
+	}
+
+	report := NewImporter(store, resolver, "bob").Import(items)
+	if diff := cmp.Diff(want, *report, cmpReport); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+	if e := store.entries["tt0078748"]; len(e) != 1 || !e[0].Watched {
+		t.Errorf("entries of Alien = %+v, want the watchlist entry marked watched by the rating", e)
+	}
+	if e := store.events["tt0078748"]; len(e) != 1 || e[0].WatchedAt != nil {
+		t.Errorf("watch events of Alien = %+v, want one undated viewing", e)
+	}
+	if got := store.ratings["bob/tt0062622"]; got != 3.5 {
+		t.Errorf("rating of 2001 = %v, want 3.5", got)
+	}
+
+	resolver.calls = 0
+	again := NewImporter(store, resolver, "bob").Import(items)
+	if diff := cmp.Diff(Report{Rows: 4, Ratings: 2}, *again, cmpReport); diff != "" {
+		t.Errorf("repeated import report mismatch (-want +got):\n%s", diff)
+	}
+	if resolver.calls != 0 {
+		t.Errorf("repeated import resolved %d stored movies", resolver.calls)
+	}
+}
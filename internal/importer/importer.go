@@ -0,0 +1,420 @@
+// Package importer imports watchlists, viewings and ratings exported by other services
+// rows are resolved to OMDb movies and stored as entries, watch events and personal ratings
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// Kind is what a row of an export records
+type Kind int
+
+const (
+	// Watchlist rows add an unwatched entry
+	Watchlist Kind = iota
+	// Watch rows log a viewing, WatchedAt may be nil
+	Watch
+	// Rating rows set the personal star rating
+	Rating
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Watchlist:
+		return "watchlist"
+	case Watch:
+		return "watch"
+	case Rating:
+		return "rating"
+	}
+	return fmt.Sprintf("Kind(%d)", k)
+}
+
+// Query identifies the movie of a row, by IMDb id if known and by title and year otherwise
+type Query struct {
+	IMDbID string
+	Title  string
+	Year   string
+}
+
+func (q Query) String() string {
+	if q.IMDbID != "" {
+		return q.IMDbID
+	}
+	return fmt.Sprintf("%s (%s)", q.Title, q.Year)
+}
+
+// Item is a single row of an export
+type Item struct {
+	// Origin names the exporting service, e.g. Letterboxd
+	Origin string
+	// File and Line locate the row for the report
+	File string
+	Line int
+	Kind Kind
+	Query
+	// Username overrides the importing user for rows of shared lists
+	Username  string
+	WatchedAt *time.Time
+	Score     float64
+	RatedAt   time.Time
+	// Note is the note of a viewing or the comment of a restored entry
+	Note string
+	// Movie is used instead of a lookup if the export contains the movie
+	Movie *api.Movie
+	// Restore is set for rows of a gomovie export, which are stored exactly as exported
+	// instead of adding the entries and viewings implied by ratings and viewings of other services
+	Restore bool
+}
+
+// Resolver looks up the movie an imported row refers to
+type Resolver interface {
+	Resolve(q Query) (*api.Movie, error)
+}
+
+// ResolverFunc adapts a function to a Resolver
+type ResolverFunc func(q Query) (*api.Movie, error)
+
+func (f ResolverFunc) Resolve(q Query) (*api.Movie, error) {
+	return f(q)
+}
+
+// OMDbResolver resolves movies via OMDb, remembering results so every movie is requested once per import
+func OMDbResolver() Resolver {
+	type result struct {
+		mov *api.Movie
+		err error
+	}
+	var mu sync.Mutex
+	seen := make(map[Query]result)
+	return ResolverFunc(func(q Query) (*api.Movie, error) {
+		mu.Lock()
+		r, ok := seen[q]
+		mu.Unlock()
+		if ok {
+			return r.mov, r.err
+		}
+		if q.IMDbID != "" {
+			r.mov, r.err = api.MovieFromID(q.IMDbID)
+		} else {
+			r.mov, r.err = api.MovieFromTitleAndYear(q.Title, q.Year)
+		}
+		mu.Lock()
+		seen[q] = r
+		mu.Unlock()
+		return r.mov, r.err
+	})
+}
+
+// Store is the part of the store used by the Importer
+type Store interface {
+	GetMovieByID(string) (*api.Movie, error)
+	CreateMovie(*api.Movie) (*api.Movie, error)
+	GetEntries(mediaID string) ([]*api.Entry, error)
+	CreateEntry(entry *api.Entry, movie *api.Movie) (*api.Entry, error)
+	MarkEntryWatched(mediaID, name string) error
+	GetWatchEvents(mediaID string) ([]*api.WatchEvent, error)
+	CreateWatchEvent(event *api.WatchEvent) (*api.WatchEvent, error)
+	SetUserRating(rating *api.UserRating) error
+}
+
+// Importer stores imported rows for a single user
+// rows already present in the store are skipped, so an import can be repeated safely
+type Importer struct {
+	store    Store
+	resolver Resolver
+	username string
+	// Checkpoint skips rows imported by an earlier, interrupted run if set
+	Checkpoint *Checkpoint
+}
+
+// NewImporter returns an Importer storing rows as the given user
+func NewImporter(store Store, resolver Resolver, username string) *Importer {
+	return &Importer{
+		store:    store,
+		resolver: resolver,
+		username: username,
+	}
+}
+
+// Import stores all items and reports what was created and which rows could not be imported
+func (im *Importer) Import(items []Item) *Report {
+	report := &Report{}
+	for _, item := range items {
+		report.Rows++
+		if im.Checkpoint.Done(item) {
+			report.Resumed++
+			continue
+		}
+		mov, err := im.movie(item, report)
+		if err != nil {
+			report.unmatched(item, err)
+			continue
+		}
+		if err := im.apply(item, mov, report); err != nil {
+			report.unmatched(item, err)
+			continue
+		}
+		if err := im.Checkpoint.Mark(item); err != nil {
+			report.unmatched(item, fmt.Errorf("could not write checkpoint: %w", err))
+		}
+	}
+	return report
+}
+
+// Prefetch resolves the movies of all items with up to workers concurrent lookups
+// Import then only waits for the store, rows of stored movies and finished rows are not looked up
+func (im *Importer) Prefetch(items []Item, workers int) {
+	type result struct {
+		mov *api.Movie
+		err error
+	}
+	var queries []Query
+	seen := make(map[Query]bool)
+	for _, item := range items {
+		if seen[item.Query] || item.Movie != nil || im.Checkpoint.Done(item) {
+			continue
+		}
+		seen[item.Query] = true
+		if item.IMDbID != "" {
+			if _, err := im.store.GetMovieByID(item.IMDbID); err == nil {
+				continue
+			}
+		}
+		queries = append(queries, item.Query)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	resolved := make(map[Query]result, len(queries))
+	sem := make(chan struct{}, max(workers, 1))
+	for _, q := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			mov, err := im.resolver.Resolve(q)
+			mu.Lock()
+			resolved[q] = result{mov, err}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	next := im.resolver
+	im.resolver = ResolverFunc(func(q Query) (*api.Movie, error) {
+		if r, ok := resolved[q]; ok {
+			return r.mov, r.err
+		}
+		return next.Resolve(q)
+	})
+}
+
+// user returns the user an item is stored for
+func (im *Importer) user(item Item) string {
+	if item.Username != "" {
+		return item.Username
+	}
+	return im.username
+}
+
+// movie resolves the movie of an item and stores it if it is new
+// items with an IMDb id of a stored movie need no lookup
+func (im *Importer) movie(item Item, report *Report) (*api.Movie, error) {
+	if item.IMDbID != "" {
+		if stored, err := im.store.GetMovieByID(item.IMDbID); err == nil {
+			return stored, nil
+		}
+	}
+	mov := item.Movie
+	if mov == nil {
+		var err error
+		mov, err = im.resolver.Resolve(item.Query)
+		if err != nil {
+			return nil, fmt.Errorf("no match for %s: %w", item.Query, err)
+		}
+	}
+	if stored, err := im.store.GetMovieByID(mov.ImdbID); err == nil {
+		return stored, nil
+	}
+	if _, err := im.store.CreateMovie(mov); err != nil {
+		return nil, fmt.Errorf("could not store %s: %w", mov.ImdbID, err)
+	}
+	report.Movies++
+	return mov, nil
+}
+
+func (im *Importer) apply(item Item, mov *api.Movie, report *Report) error {
+	// restored viewings and ratings do not add entries, the export lists entries on their own
+	// and restored movies nobody added have no user
+	if !item.Restore || item.Kind == Watchlist && item.Username != "" {
+		if err := im.ensureEntry(item, mov, report); err != nil {
+			return err
+		}
+	}
+	switch item.Kind {
+	case Watch:
+		return im.watch(item, mov, report)
+	case Rating:
+		return im.rate(item, mov, report)
+	}
+	return nil
+}
+
+// ensureEntry adds an entry for the user unless the user already has one for the movie,
+// an unwatched entry is marked watched by viewings and ratings
+// user names are compared case insensitive like the store does
+func (im *Importer) ensureEntry(item Item, mov *api.Movie, report *Report) error {
+	entries, err := im.store.GetEntries(mov.ImdbID)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !strings.EqualFold(e.Name, im.user(item)) {
+			continue
+		}
+		if item.Kind == Watchlist || e.Watched {
+			return nil
+		}
+		return im.store.MarkEntryWatched(mov.ImdbID, e.Name)
+	}
+	comment := "Imported from " + item.Origin
+	if item.Restore {
+		comment = item.Note
+	}
+	entry := api.NewEntry(im.user(item), item.Kind != Watchlist, comment)
+	if _, err := im.store.CreateEntry(entry, mov); err != nil {
+		return err
+	}
+	report.Entries++
+	return nil
+}
+
+// watch logs a viewing unless the user already logged one on the same date
+func (im *Importer) watch(item Item, mov *api.Movie, report *Report) error {
+	events, err := im.store.GetWatchEvents(mov.ImdbID)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if strings.EqualFold(e.Username, im.user(item)) && sameDate(e.WatchedAt, item.WatchedAt) {
+			report.Skipped++
+			return nil
+		}
+	}
+	_, err = im.store.CreateWatchEvent(&api.WatchEvent{
+		Username:  im.user(item),
+		MediaID:   mov.ImdbID,
+		WatchedAt: item.WatchedAt,
+		Note:      item.Note,
+	})
+	if err != nil {
+		return err
+	}
+	report.WatchEvents++
+	return nil
+}
+
+func (im *Importer) rate(item Item, mov *api.Movie, report *Report) error {
+	if !api.ValidStarScore(item.Score) {
+		return fmt.Errorf("invalid rating %v", item.Score)
+	}
+	err := im.store.SetUserRating(&api.UserRating{
+		Username: im.user(item),
+		MediaID:  mov.ImdbID,
+		Score:    item.Score,
+		RatedAt:  item.RatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	report.Ratings++
+	if item.Restore {
+		return nil
+	}
+	return im.ensureWatched(im.user(item), mov, report)
+}
+
+// ensureWatched logs an undated viewing for rated movies the user has not logged a viewing of
+func (im *Importer) ensureWatched(username string, mov *api.Movie, report *Report) error {
+	events, err := im.store.GetWatchEvents(mov.ImdbID)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if strings.EqualFold(e.Username, username) {
+			return nil
+		}
+	}
+	_, err = im.store.CreateWatchEvent(&api.WatchEvent{Username: username, MediaID: mov.ImdbID})
+	if err != nil {
+		return err
+	}
+	report.WatchEvents++
+	return nil
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
+
+// Unmatched is a row which could not be imported
+type Unmatched struct {
+	Item   Item
+	Reason string
+}
+
+// Report summarises an import
+type Report struct {
+	Rows        int
+	Movies      int
+	Entries     int
+	WatchEvents int
+	Ratings     int
+	// Skipped counts viewings which were already stored
+	Skipped int
+	// Resumed counts rows finished by an earlier run according to the checkpoint
+	Resumed   int
+	Unmatched []Unmatched
+}
+
+// AddUnmatched adds rows which were rejected before the import, e.g. because of invalid values
+func (r *Report) AddUnmatched(rows []Unmatched) {
+	r.Rows += len(rows)
+	r.Unmatched = append(r.Unmatched, rows...)
+}
+
+func (r *Report) unmatched(item Item, err error) {
+	r.Unmatched = append(r.Unmatched, Unmatched{Item: item, Reason: err.Error()})
+}
+
+// Write prints the summary followed by every unmatched row
+func (r *Report) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%d rows: %d movies, %d entries, %d watch events and %d ratings added, %d already present, %d unmatched\n",
+		r.Rows, r.Movies, r.Entries, r.WatchEvents, r.Ratings, r.Skipped, len(r.Unmatched))
+	if err != nil {
+		return err
+	}
+	if r.Resumed > 0 {
+		if _, err := fmt.Fprintf(w, "%d rows imported by an earlier run\n", r.Resumed); err != nil {
+			return err
+		}
+	}
+	for _, u := range r.Unmatched {
+		_, err := fmt.Fprintf(w, "%s:%d %s %s: %s\n", u.Item.File, u.Item.Line, u.Item.Kind, u.Item.Query, u.Reason)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
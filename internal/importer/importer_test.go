@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// memStore keeps everything the Importer stores in memory
+type memStore struct {
+	movies  map[string]*api.Movie
+	entries map[string][]*api.Entry
+	events  map[string][]*api.WatchEvent
+	ratings map[string]float64
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		movies:  map[string]*api.Movie{},
+		entries: map[string][]*api.Entry{},
+		events:  map[string][]*api.WatchEvent{},
+		ratings: map[string]float64{},
+	}
+}
+
+func (m *memStore) GetMovieByID(id string) (*api.Movie, error) {
+	if mov, ok := m.movies[id]; ok {
+		return mov, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memStore) CreateMovie(mov *api.Movie) (*api.Movie, error) {
+	m.movies[mov.ImdbID] = mov
+	return mov, nil
+}
+
+func (m *memStore) GetEntries(id string) ([]*api.Entry, error) {
+	return m.entries[id], nil
+}
+
+func (m *memStore) CreateEntry(e *api.Entry, mov *api.Movie) (*api.Entry, error) {
+	m.entries[mov.ImdbID] = append(m.entries[mov.ImdbID], e)
+	return e, nil
+}
+
+func (m *memStore) MarkEntryWatched(id, name string) error {
+	markWatched(m.entries[id], name)
+	return nil
+}
+
+func (m *memStore) GetWatchEvents(id string) ([]*api.WatchEvent, error) {
+	return m.events[id], nil
+}
+
+func (m *memStore) CreateWatchEvent(e *api.WatchEvent) (*api.WatchEvent, error) {
+	m.events[e.MediaID] = append(m.events[e.MediaID], e)
+	markWatched(m.entries[e.MediaID], e.Username)
+	return e, nil
+}
+
+func (m *memStore) SetUserRating(r *api.UserRating) error {
+	m.ratings[r.Username+"/"+r.MediaID] = r.Score
+	return nil
+}
+
+// titleResolver knows a fixed set of movies by title
+var titleResolver = ResolverFunc(func(q Query) (*api.Movie, error) {
+	ids := map[string]string{
+		"Sinners":               "tt31193180",
+		"Alien":                 "tt0078748",
+		"2001: A Space Odyssey": "tt0062622",
+		"Shawshank Redemption":  "tt0111161",
+	}
+	id, ok := ids[q.Title]
+	if q.IMDbID != "" {
+		id, ok = ids[q.IMDbID]
+	}
+	if !ok {
+		return nil, errors.New("Movie not found!")
+	}
+	return &api.Movie{ImdbID: id, Title: q.Title, Year: q.Year}, nil
+})
+
+func letterboxdZip(t *testing.T) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files, err := filepath.Glob(filepath.Join("testdata", "letterboxd", "*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create(filepath.Base(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestReadLetterboxd(t *testing.T) {
+	items, unmatched, err := ReadLetterboxd(letterboxdZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind.String()+" "+item.Query.String())
+	}
+	want := []string{
+		"watchlist Sinners (2025)",
+		"watchlist Alien (1979)",
+		"watch Alien (1979)",
+		"watch Alien (1979)",
+		"watch 2001: A Space Odyssey (1968)",
+		"watch Unknown Student Film (2020)",
+		"rating Alien (1979)",
+		"rating 2001: A Space Odyssey (1968)",
+		"rating Shawshank Redemption (1994)",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("items mismatch (-want +got):\n%s", diff)
+	}
+	if len(unmatched) != 1 || unmatched[0].Item.File != "diary.csv" || unmatched[0].Item.Line != 6 {
+		t.Errorf("unmatched = %+v, want diary.csv line 6", unmatched)
+	}
+	if got := items[2].WatchedAt.Format("2006-01-02"); got != "2024-05-09" {
+		t.Errorf("watched date = %s, want the Watched Date column", got)
+	}
+	if got := items[4].WatchedAt.Format("2006-01-02"); got != "2024-06-02" {
+		t.Errorf("watched date = %s, want the logged date when Watched Date is empty", got)
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	items, unmatched, err := ReadLetterboxd(letterboxdZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMemStore()
+	report := NewImporter(store, titleResolver, "alice").Import(items)
+	report.AddUnmatched(unmatched)
+
+	want := Report{Rows: 10, Movies: 4, Entries: 4, WatchEvents: 3, Ratings: 2}
+	if diff := cmp.Diff(want, *report, cmpReport); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+	var reasons []string
+	for _, u := range report.Unmatched {
+		reasons = append(reasons, u.Item.Query.String()+": "+u.Reason)
+	}
+	wantReasons := []string{
+		"Unknown Student Film (2020): no match for Unknown Student Film (2020): Movie not found!",
+		"Shawshank Redemption (1994): invalid rating 7",
+		"Broken Row (1999): invalid watched date \"06/04/2024\"",
+	}
+	if diff := cmp.Diff(wantReasons, reasons); diff != "" {
+		t.Errorf("unmatched mismatch (-want +got):\n%s", diff)
+	}
+	if e := store.entries["tt31193180"]; len(e) != 1 || e[0].Watched {
+		t.Errorf("watchlist entry = %+v, want one unwatched entry", e)
+	}
+	if got := store.ratings["alice/tt0078748"]; got != 5 {
+		t.Errorf("rating of Alien = %v, want 5", got)
+	}
+
+	for _, user := range []string{"alice", "Alice"} {
+		t.Run("repeated import as "+user+" only rewrites ratings", func(t *testing.T) {
+			again := NewImporter(store, titleResolver, user).Import(items)
+			want := Report{Rows: 9, Skipped: 3, Ratings: 2}
+			if diff := cmp.Diff(want, *again, cmpReport); diff != "" {
+				t.Errorf("report mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImporter_ratingMarksEntryWatched(t *testing.T) {
+	store := newMemStore()
+	alien := &api.Movie{ImdbID: "tt0078748", Title: "Alien"}
+	store.CreateMovie(alien)
+	// the viewing was logged before the entry was added, so the entry is unwatched
+	store.CreateWatchEvent(&api.WatchEvent{Username: "alice", MediaID: alien.ImdbID})
+	store.CreateEntry(api.NewEntry("Alice", false, ""), alien)
+
+	items := []Item{{Kind: Rating, Query: Query{IMDbID: alien.ImdbID}, Score: 4}}
+	report := NewImporter(store, titleResolver, "alice").Import(items)
+	if diff := cmp.Diff(Report{Rows: 1, Ratings: 1}, *report, cmpReport); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+	if e := store.entries[alien.ImdbID]; len(e) != 1 || !e[0].Watched {
+		t.Errorf("entries of Alien = %+v, want the entry marked watched", e)
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	report := Report{Rows: 2, Movies: 1, Entries: 1}
+	report.unmatched(Item{File: "diary.csv", Line: 3, Kind: Watch, Query: Query{Title: "Alien", Year: "1979"}}, errors.New("no match"))
+	var buf strings.Builder
+	if err := report.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "2 rows: 1 movies, 1 entries, 0 watch events and 0 ratings added, 0 already present, 1 unmatched\n" +
+		"diary.csv:3 watch Alien (1979): no match\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Write() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// cmpReport compares report counters only
+var cmpReport = cmp.FilterPath(func(p cmp.Path) bool {
+	return p.Last().String() == ".Unmatched"
+}, cmp.Ignore())
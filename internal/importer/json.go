@@ -0,0 +1,60 @@
+package importer
+
+import (
+	"io"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/export"
+)
+
+// Gomovie is the Origin of rows read from a JSON export of gomovie
+const Gomovie = "gomovie"
+
+// ReadJSON reads a JSON export written by the export package
+// movies are taken from the export, so nothing is looked up, and entries keep their comments
+// entries are restored unwatched and marked as watched by their viewings, like entries created in the app
+func ReadJSON(r io.Reader, file string) ([]Item, error) {
+	var items []Item
+	// the export has one movie per line after the first
+	line := 1
+	err := export.Read(r, func(record *api.ExportRecord) error {
+		line++
+		item := Item{
+			Origin:  Gomovie,
+			File:    file,
+			Line:    line,
+			Query:   Query{IMDbID: record.Movie.ImdbID, Title: record.Movie.Title, Year: record.Movie.Year},
+			Movie:   record.Movie,
+			Restore: true,
+		}
+		// a movie nobody added is stored on its own
+		if len(record.Entries) == 0 && len(record.WatchEvents) == 0 && len(record.Ratings) == 0 {
+			items = append(items, item)
+		}
+		for _, e := range record.Entries {
+			entry := item
+			entry.Kind = Watchlist
+			entry.Username = e.Name
+			entry.Note = string(e.Comment)
+			items = append(items, entry)
+		}
+		for _, e := range record.WatchEvents {
+			watch := item
+			watch.Kind = Watch
+			watch.Username = e.Username
+			watch.WatchedAt = e.WatchedAt
+			watch.Note = e.Note
+			items = append(items, watch)
+		}
+		for _, r := range record.Ratings {
+			rating := item
+			rating.Kind = Rating
+			rating.Username = r.Username
+			rating.Score = r.Score
+			rating.RatedAt = r.RatedAt
+			items = append(items, rating)
+		}
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/export"
+)
+
+// exported is an export Source of fixed records
+type exported []*api.ExportRecord
+
+func (e exported) ExportMovies(fn func(*api.ExportRecord) error) error {
+	for _, record := range e {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReadJSON_RoundTrip(t *testing.T) {
+	watchedAt := time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC)
+	ratedAt := time.Date(2024, 5, 10, 18, 30, 0, 0, time.UTC)
+	source := exported{
+		{
+			Movie:   &api.Movie{ImdbID: "tt0078748", Title: "Alien", Year: "1979"},
+			Entries: []*api.Entry{api.NewEntry("bob", true, "bob's pick")},
+			WatchEvents: []*api.WatchEvent{
+				{Username: "bob", MediaID: "tt0078748", WatchedAt: &watchedAt, Note: "cinema"},
+				{Username: "alice", MediaID: "tt0078748"},
+			},
+			Ratings: []*api.UserRating{{Username: "carol", MediaID: "tt0078748", Score: 3.5, RatedAt: ratedAt}},
+		},
+		{Movie: &api.Movie{ImdbID: "tt0062622", Title: "2001: A Space Odyssey", Year: "1968"}},
+	}
+	var buf strings.Builder
+	if err := export.Write(&buf, source, export.JSON, ""); err != nil {
+		t.Fatal(err)
+	}
+	items, err := ReadJSON(strings.NewReader(buf.String()), "gomovie.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMemStore()
+	report := NewImporter(store, &countingResolver{}, "importer").Import(items)
+	want := Report{Rows: 5, Movies: 2, Entries: 1, WatchEvents: 2, Ratings: 1}
+	if diff := cmp.Diff(want, *report, cmpReport); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+	if len(report.Unmatched) != 0 {
+		t.Errorf("unmatched = %+v, want none", report.Unmatched)
+	}
+
+	// the entry is restored unwatched and marked watched by bob's viewing
+	if diff := cmp.Diff(source[0].Entries, store.entries["tt0078748"]); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(source[0].WatchEvents, store.events["tt0078748"]); diff != "" {
+		t.Errorf("watch events mismatch (-want +got):\n%s", diff)
+	}
+	if got := store.ratings["carol/tt0078748"]; got != 3.5 {
+		t.Errorf("rating of carol = %v, want 3.5", got)
+	}
+	if _, ok := store.movies["tt0062622"]; !ok || len(store.entries["tt0062622"]) != 0 {
+		t.Errorf("movie nobody added = %v, entries %v, want stored without entry", ok, store.entries["tt0062622"])
+	}
+
+	t.Run("repeated restore changes nothing", func(t *testing.T) {
+		again := NewImporter(store, &countingResolver{}, "importer").Import(items)
+		want := Report{Rows: 5, Skipped: 2, Ratings: 1}
+		if diff := cmp.Diff(want, *again, cmpReport); diff != "" {
+			t.Errorf("report mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"time"
+)
+
+// Letterboxd is the Origin of rows read from a Letterboxd export
+const Letterboxd = "Letterboxd"
+
+// letterboxd export files in import order, so watchlist entries exist before viewings mark them watched
+var letterboxdFiles = []struct {
+	name string
+	kind Kind
+}{
+	{"watchlist.csv", Watchlist},
+	{"diary.csv", Watch},
+	{"ratings.csv", Rating},
+}
+
+// ReadLetterboxd reads the watchlist, diary and ratings of a Letterboxd export zip
+// missing files are skipped, rows with invalid values are returned as unmatched
+func ReadLetterboxd(zr *zip.Reader) ([]Item, []Unmatched, error) {
+	var items []Item
+	var unmatched []Unmatched
+	for _, file := range letterboxdFiles {
+		f, err := zr.Open(file.name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		records, err := readRecords(f, "Date", "Name", "Year")
+		f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", file.name, err)
+		}
+		for _, rec := range records {
+			item, err := letterboxdItem(file.name, file.kind, rec)
+			if err != nil {
+				unmatched = append(unmatched, Unmatched{Item: item, Reason: err.Error()})
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	return items, unmatched, nil
+}
+
+func letterboxdItem(file string, kind Kind, rec record) (Item, error) {
+	item := Item{
+		Origin: Letterboxd,
+		File:   file,
+		Line:   rec.line,
+		Kind:   kind,
+		Query:  Query{Title: rec.get("Name"), Year: rec.get("Year")},
+	}
+	logged, err := time.Parse(time.DateOnly, rec.get("Date"))
+	if err != nil {
+		return item, fmt.Errorf("invalid date %q", rec.get("Date"))
+	}
+	switch kind {
+	case Watch:
+		watched := logged
+		if date := rec.get("Watched Date"); date != "" {
+			watched, err = time.Parse(time.DateOnly, date)
+			if err != nil {
+				return item, fmt.Errorf("invalid watched date %q", date)
+			}
+		}
+		item.WatchedAt = &watched
+	case Rating:
+		item.Score, err = strconv.ParseFloat(rec.get("Rating"), 64)
+		if err != nil {
+			return item, fmt.Errorf("invalid rating %q", rec.get("Rating"))
+		}
+		item.RatedAt = logged
+	}
+	return item, nil
+}
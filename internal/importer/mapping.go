@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// CSV is the default Origin of rows read with a Mapping
+const CSV = "CSV"
+
+// DefaultTruthy are the watched values accepted if a Mapping defines none
+var DefaultTruthy = []string{"true", "yes", "y", "1", "x", "watched"}
+
+var validYear = regexp.MustCompile(`^\d{4}$`)
+
+// Mapping describes the columns of a csv file from any source
+// columns are selected by header name or by 1-based position written as "#3",
+// unset columns are not imported
+type Mapping struct {
+	// Origin names the source in entry comments, defaults to CSV
+	Origin    string `json:"origin"`
+	IMDbID    string `json:"imdb_id"`
+	Title     string `json:"title"`
+	Year      string `json:"year"`
+	Watched   string `json:"watched"`
+	WatchedAt string `json:"watched_at"`
+	Rating    string `json:"rating"`
+	// User is the column of the user a row belongs to, rows without one belong to the importing user
+	User string `json:"user"`
+	// Truthy lists the values of the watched column meaning watched, compared case-insensitively
+	Truthy []string `json:"truthy"`
+	// DateLayout is the Go time layout of the watched_at column, defaults to 2006-01-02
+	DateLayout string `json:"date_layout"`
+	// RatingScale is the best possible rating, ratings are converted to half stars out of 5
+	RatingScale float64 `json:"rating_scale"`
+	// NoHeader is set for files without a header row, all columns must then be given by position
+	NoHeader bool `json:"no_header"`
+}
+
+// LoadMapping reads a Mapping from a json file
+func LoadMapping(path string) (Mapping, error) {
+	var m Mapping
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid mapping %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Validate checks that rows can be resolved and positions are used for files without header
+func (m Mapping) Validate() error {
+	if m.IMDbID == "" && m.Title == "" {
+		return errors.New("mapping needs an imdb_id or title column")
+	}
+	if m.RatingScale < 0 {
+		return fmt.Errorf("invalid rating scale %v", m.RatingScale)
+	}
+	if m.NoHeader {
+		for _, column := range m.columns() {
+			if _, ok := position(column); !ok {
+				return fmt.Errorf("column %q must be given by position like #1, the file has no header", column)
+			}
+		}
+	}
+	return nil
+}
+
+func (m Mapping) columns() []string {
+	var columns []string
+	for _, c := range []string{m.IMDbID, m.Title, m.Year, m.Watched, m.WatchedAt, m.Rating, m.User} {
+		if c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+func (m Mapping) truthy(value string) bool {
+	truthy := m.Truthy
+	if len(truthy) == 0 {
+		truthy = DefaultTruthy
+	}
+	for _, t := range truthy {
+		if strings.EqualFold(strings.TrimSpace(t), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadCSV reads a csv file using the column mapping
+// a watched row becomes a viewing and an unwatched row a watchlist entry, a rating adds a rating item
+func ReadCSV(r io.Reader, file string, m Mapping) ([]Item, []Unmatched, error) {
+	if err := m.Validate(); err != nil {
+		return nil, nil, err
+	}
+	records, err := readTable(r, !m.NoHeader, m.columns()...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+	var items []Item
+	var unmatched []Unmatched
+	for _, rec := range records {
+		rowItems, err := m.items(file, rec)
+		if err != nil {
+			unmatched = append(unmatched, Unmatched{Item: rowItems[0], Reason: err.Error()})
+			continue
+		}
+		items = append(items, rowItems...)
+	}
+	return items, unmatched, nil
+}
+
+// items converts a row, on error the first item still describes the row for the report
+func (m Mapping) items(file string, rec record) ([]Item, error) {
+	origin := m.Origin
+	if origin == "" {
+		origin = CSV
+	}
+	item := Item{
+		Origin:   origin,
+		File:     file,
+		Line:     rec.line,
+		Kind:     Watchlist,
+		Username: rec.get(m.User),
+		Query:    Query{IMDbID: rec.get(m.IMDbID), Title: rec.get(m.Title), Year: rec.get(m.Year)},
+	}
+	if item.IMDbID != "" && !validIMDbID.MatchString(item.IMDbID) {
+		return []Item{item}, fmt.Errorf("invalid IMDb id %q", item.IMDbID)
+	}
+	if item.IMDbID == "" && item.Title == "" {
+		return []Item{item}, errors.New("no IMDb id or title")
+	}
+	// a year which is no year does not reject the row, the title is looked up on its own
+	// unless the file has no header, where such a row is most likely a header line after all
+	if !validYear.MatchString(item.Year) {
+		if m.NoHeader && item.Year != "" {
+			return []Item{item}, fmt.Errorf("invalid year %q", item.Year)
+		}
+		item.Year = ""
+	}
+
+	if date := rec.get(m.WatchedAt); date != "" {
+		layout := m.DateLayout
+		if layout == "" {
+			layout = time.DateOnly
+		}
+		watchedAt, err := time.Parse(layout, date)
+		if err != nil {
+			return []Item{item}, fmt.Errorf("invalid watched date %q", date)
+		}
+		item.Kind = Watch
+		item.WatchedAt = &watchedAt
+	} else if m.truthy(rec.get(m.Watched)) {
+		item.Kind = Watch
+	}
+	items := []Item{item}
+
+	if value := rec.get(m.Rating); value != "" {
+		score, err := m.score(value)
+		if err != nil {
+			return items, err
+		}
+		rating := item
+		rating.Kind = Rating
+		rating.Score = score
+		rating.RatedAt = time.Now()
+		if item.WatchedAt != nil {
+			rating.RatedAt = *item.WatchedAt
+		}
+		items = append(items, rating)
+	}
+	return items, nil
+}
+
+// score converts a rating to half stars, rounding to the nearest half star
+func (m Mapping) score(value string) (float64, error) {
+	scale := m.RatingScale
+	if scale == 0 {
+		scale = api.MaxStarScore
+	}
+	rating, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || rating < 0 || rating > scale {
+		return 0, fmt.Errorf("invalid rating %q", value)
+	}
+	score := math.Round(rating/scale*api.MaxStarScore/api.StarScoreStep) * api.StarScoreStep
+	return max(score, api.MinStarScore), nil
+}
@@ -0,0 +1,250 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+var sharedMapping = Mapping{
+	Title:       "Film",
+	Year:        "Released",
+	Watched:     "Seen",
+	WatchedAt:   "Seen On",
+	Rating:      "Score (of 10)",
+	User:        "Added By",
+	RatingScale: 10,
+}
+
+func readCSVFile(t *testing.T, name string, m Mapping) ([]Item, []Unmatched) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "csv", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	items, unmatched, err := ReadCSV(f, name, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items, unmatched
+}
+
+func TestReadCSV(t *testing.T) {
+	items, unmatched := readCSVFile(t, "shared.csv", sharedMapping)
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind.String()+" "+item.Query.String()+" "+item.Username)
+	}
+	want := []string{
+		"watch Alien (1979) bob",
+		"rating Alien (1979) bob",
+		"watchlist Sinners (2025) ",
+		"watch 2001: A Space Odyssey () alice",
+		"rating 2001: A Space Odyssey () alice",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("items mismatch (-want +got):\n%s", diff)
+	}
+	if got := items[0].WatchedAt.Format(time.DateOnly); got != "2024-05-09" {
+		t.Errorf("watched date = %s, want 2024-05-09", got)
+	}
+	if items[1].Score != 4 || items[4].Score != 3.5 {
+		t.Errorf("scores = %v, %v, want 4 and 3.5", items[1].Score, items[4].Score)
+	}
+
+	var reasons []string
+	for _, u := range unmatched {
+		reasons = append(reasons, u.Item.Query.String()+": "+u.Reason)
+	}
+	wantReasons := []string{
+		"Shawshank Redemption (1994): invalid rating \"11\"",
+		" (1999): no IMDb id or title",
+		"Alien (1979): invalid watched date \"09/05/2024\"",
+	}
+	if diff := cmp.Diff(wantReasons, reasons); diff != "" {
+		t.Errorf("unmatched mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadCSV_NoHeader(t *testing.T) {
+	spreadsheet := Mapping{Watched: "#1", Title: "#2", Year: "#3", User: "#5", Truthy: []string{"TRUE"}, NoHeader: true}
+	items, unmatched := readCSVFile(t, "spreadsheet.csv", spreadsheet)
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind.String()+" "+item.Query.String()+" "+item.Username)
+	}
+	want := []string{
+		"watch Alien (1979) bob",
+		"watchlist Sinners (2025) alice",
+		"watch 2001: A Space Odyssey (1968) ",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("items mismatch (-want +got):\n%s", diff)
+	}
+	if len(unmatched) != 0 {
+		t.Errorf("unmatched = %+v, want none", unmatched)
+	}
+}
+
+func TestReadCSV_NoHeaderStartingWithHeader(t *testing.T) {
+	spreadsheet := Mapping{Watched: "#1", Title: "#2", Year: "#3", User: "#5", Truthy: []string{"TRUE"}, NoHeader: true}
+	sheet := "Watched,Title,Year,Notes,User\nTRUE,Alien,1979,,bob\nFALSE,Sinners,,,alice\n"
+	items, unmatched, err := ReadCSV(strings.NewReader(sheet), "spreadsheet.csv", spreadsheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind.String()+" "+item.Query.String()+" "+item.Username)
+	}
+	want := []string{"watch Alien (1979) bob", "watchlist Sinners () alice"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("items mismatch (-want +got):\n%s", diff)
+	}
+	if len(unmatched) != 1 || unmatched[0].Item.Line != 1 || unmatched[0].Reason != `invalid year "Year"` {
+		t.Errorf("unmatched = %+v, want the header line", unmatched)
+	}
+}
+
+func TestReadCSV_BlankHeader(t *testing.T) {
+	// IMDbID is unset and must not read the unnamed last column
+	m := Mapping{Title: "Title", Year: "Year"}
+	items, unmatched, err := ReadCSV(strings.NewReader("Title,Year,\nAlien,1979,notes here\n"), "blank.csv", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmatched) != 0 {
+		t.Fatalf("unmatched = %+v, want none", unmatched)
+	}
+	if len(items) != 1 || items[0].IMDbID != "" || items[0].Query.String() != "Alien (1979)" {
+		t.Errorf("items = %+v, want the watchlist entry Alien (1979)", items)
+	}
+}
+
+func TestMapping_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       Mapping
+		wantErr bool
+	}{
+		{"title", Mapping{Title: "Title"}, false},
+		{"imdb id", Mapping{IMDbID: "Const"}, false},
+		{"no movie column", Mapping{Year: "Year", Watched: "Watched"}, true},
+		{"negative scale", Mapping{Title: "Title", RatingScale: -1}, true},
+		{"positions without header", Mapping{Title: "#2", Year: "#3", NoHeader: true}, false},
+		{"names without header", Mapping{Title: "Title", NoHeader: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImporter_UserColumn(t *testing.T) {
+	items, _ := readCSVFile(t, "shared.csv", sharedMapping)
+	store := newMemStore()
+	NewImporter(store, titleResolver, "carol").Import(items)
+
+	if e := store.entries["tt0078748"]; len(e) != 1 || e[0].Name != "bob" {
+		t.Errorf("entries of Alien = %+v, want one of bob", e)
+	}
+	if e := store.entries["tt31193180"]; len(e) != 1 || e[0].Name != "carol" {
+		t.Errorf("entries of Sinners = %+v, want one of the importing user", e)
+	}
+	if got := store.ratings["alice/tt0062622"]; got != 3.5 {
+		t.Errorf("rating of alice = %v, want 3.5", got)
+	}
+}
+
+// slowResolver counts concurrent lookups
+type slowResolver struct {
+	mu      sync.Mutex
+	calls   int
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *slowResolver) Resolve(q Query) (*api.Movie, error) {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	return titleResolver.Resolve(q)
+}
+
+func TestImporter_Prefetch(t *testing.T) {
+	items, _, err := ReadLetterboxd(letterboxdZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := &slowResolver{}
+	im := NewImporter(newMemStore(), resolver, "alice")
+	im.Prefetch(items, 2)
+
+	if got := resolver.peak.Load(); got > 2 {
+		t.Errorf("peak concurrent lookups = %d, want at most 2", got)
+	}
+	prefetched := resolver.calls
+	report := im.Import(items)
+	if resolver.calls != prefetched {
+		t.Errorf("lookups during import = %d, want all prefetched", resolver.calls-prefetched)
+	}
+	want := Report{Rows: 9, Movies: 4, Entries: 4, WatchEvents: 3, Ratings: 2}
+	if diff := cmp.Diff(want, *report, cmpReport); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestImporter_Checkpoint(t *testing.T) {
+	items, _, err := ReadLetterboxd(letterboxdZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "import.checkpoint")
+	cp, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := NewImporter(newMemStore(), titleResolver, "alice")
+	im.Checkpoint = cp
+	// the first run is interrupted after three rows
+	im.Import(items[:3])
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+	resolver := &slowResolver{}
+	im = NewImporter(newMemStore(), resolver, "alice")
+	im.Checkpoint = cp
+	report := im.Import(items)
+	if report.Resumed != 3 {
+		t.Errorf("resumed rows = %d, want 3", report.Resumed)
+	}
+	if resolver.calls != len(items)-3 {
+		t.Errorf("lookups = %d, want %d", resolver.calls, len(items)-3)
+	}
+}
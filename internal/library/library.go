@@ -0,0 +1,399 @@
+// Package library scans a local media folder, matches the video files to movies on OMDb
+// and keeps the matches on the library list
+// the IMDb id, title and year of Kodi and Jellyfin NFO files are preferred over file names
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/util"
+)
+
+// JobName identifies library scans in the job history
+const JobName = "library"
+
+// maxLookupRequests is the most OMDb requests a single lookup sends
+const maxLookupRequests = 2
+
+// Extensions are the video file extensions picked up by a scan
+var Extensions = []string{".mkv", ".mp4", ".avi", ".m4v", ".mov", ".wmv", ".mpg", ".mpeg", ".ts", ".webm"}
+
+// Store is the part of the store used by the Scanner
+type Store interface {
+	GetLibraryItems() ([]*api.LibraryItem, error)
+	SaveLibraryItem(item *api.LibraryItem, movie *api.Movie) error
+	DeleteLibraryItem(path string) error
+	GetMovieByID(string) (*api.Movie, error)
+	CreateJobRun(run *api.JobRun) error
+	GetJobRequests(since time.Time) (int, error)
+}
+
+// Lookup resolves a title and an optional year to a movie and returns the number of OMDb requests it sent
+// without a movie, candidates describe the movies the title could refer to
+type Lookup func(title string, year int) (movie *api.Movie, candidates []string, requests int, err error)
+
+// OMDbLookup resolves titles with a year by MovieFromTitleAndYear
+// titles without a year are searched first, several movies with exactly that title make it ambiguous
+func OMDbLookup(title string, year int) (*api.Movie, []string, int, error) {
+	if year != 0 {
+		movie, err := api.MovieFromTitleAndYear(title, strconv.Itoa(year))
+		return movie, nil, 1, err
+	}
+	results, err := api.QueryOMDb(api.SearchQueryRequest{Title: title, Type: "movie"})
+	if err == nil && results.Response == "True" {
+		var exact []api.SearchResultMedia
+		for _, r := range results.Search {
+			if strings.EqualFold(r.Title, title) {
+				exact = append(exact, r)
+			}
+		}
+		switch len(exact) {
+		case 0:
+		case 1:
+			movie, err := api.MovieFromID(exact[0].ImdbID)
+			return movie, nil, 2, err
+		default:
+			candidates := make([]string, len(exact))
+			for i, r := range exact {
+				candidates[i] = fmt.Sprintf("%s (%s) %s", r.Title, r.Year, r.ImdbID)
+			}
+			return nil, candidates, 1, nil
+		}
+	}
+	movie, err := api.MovieFromTitleAndYear(title, "")
+	return movie, nil, 2, err
+}
+
+// Report summarises a scan
+type Report struct {
+	Scanned   int
+	Matched   int
+	Unchanged int
+	Removed   int
+	// Lookups counts the files resolved on OMDb
+	Lookups int
+	// Requests counts the OMDb requests sent for the lookups
+	Requests int
+	// Skipped counts new and changed files left for a later scan as the daily OMDb quota was used up
+	Skipped int
+	// NFOWritten counts NFO files written for matched files without one
+	NFOWritten int
+	Ambiguous  []*api.LibraryItem
+	Unmatched  []*api.LibraryItem
+}
+
+// Write prints the summary and every ambiguous or unmatched file
+func (r *Report) Write(w io.Writer) {
+	fmt.Fprintf(w, "scanned %d files: %d matched, %d unchanged, %d ambiguous, %d unmatched, %d removed\n",
+		r.Scanned, r.Matched, r.Unchanged, len(r.Ambiguous), len(r.Unmatched), r.Removed)
+	if r.NFOWritten > 0 {
+		fmt.Fprintf(w, "wrote %d NFO files\n", r.NFOWritten)
+	}
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, "skipped %d files, the daily OMDb quota is used up\n", r.Skipped)
+	}
+	if len(r.Ambiguous) > 0 {
+		fmt.Fprintln(w, "ambiguous:")
+		for _, item := range r.Ambiguous {
+			fmt.Fprintf(w, "  %s: %s\n", item.Path, item.Reason)
+		}
+	}
+	if len(r.Unmatched) > 0 {
+		fmt.Fprintln(w, "unmatched:")
+		for _, item := range r.Unmatched {
+			fmt.Fprintf(w, "  %s: %s\n", item.Path, item.Reason)
+		}
+	}
+}
+
+// Scanner matches the files of a media folder to movies every interval
+type Scanner struct {
+	store    Store
+	root     string
+	lookup   Lookup
+	fetch    func(imdbID string) (*api.Movie, error)
+	interval time.Duration
+	quota    int
+	// Retry looks up unchanged files again which were ambiguous or unmatched before
+	Retry bool
+	// WriteNFO writes an NFO next to every matched file which has none
+	WriteNFO bool
+	// OnMatch is called with the movie of every newly matched file once it is stored, e.g. to invalidate caches
+	OnMatch func(*api.Movie)
+	now     func() time.Time
+}
+
+// NewScanner returns a Scanner for the media folder root, resolving files with lookup
+// lookups stop for the day once all jobs together sent dailyQuota OMDb requests
+func NewScanner(store Store, root string, lookup Lookup, interval time.Duration, dailyQuota int) *Scanner {
+	return &Scanner{
+		store:    store,
+		root:     root,
+		lookup:   lookup,
+		fetch:    api.MovieFromID,
+		interval: interval,
+		quota:    dailyQuota,
+		now:      time.Now,
+	}
+}
+
+// Name implements the server Job interface
+func (s *Scanner) Name() string {
+	return JobName
+}
+
+// Run scans the library right away and then once every interval until ctx is cancelled
+func (s *Scanner) Run(ctx context.Context) {
+	if s.root == "" || s.interval <= 0 {
+		slog.Info("library scans disabled", "job", JobName)
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		run := s.RunOnce(ctx)
+		slog.Info("library scan finished", "job", JobName, "processed", run.Processed,
+			"failed", run.Failed, "requests", run.Requests, "err", run.Error)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce scans the library and records the run
+// Processed counts matched files and Failed counts ambiguous and unmatched ones
+func (s *Scanner) RunOnce(ctx context.Context) *api.JobRun {
+	run, _ := s.ScanAndRecord(ctx)
+	return run
+}
+
+// ScanAndRecord scans the library like RunOnce and returns the report of the scan as well
+// the run is recorded, so its OMDb requests count against the daily quota of all jobs
+func (s *Scanner) ScanAndRecord(ctx context.Context) (*api.JobRun, *Report) {
+	run := &api.JobRun{Job: JobName, StartedAt: s.now()}
+	report, err := s.Scan(ctx)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.Processed = report.Matched
+	run.Failed = len(report.Ambiguous) + len(report.Unmatched)
+	run.Requests = report.Requests
+	run.FinishedAt = s.now()
+	if err := s.store.CreateJobRun(run); err != nil {
+		slog.Error("could not record job run", "job", JobName, "err", err.Error())
+	}
+	return run, report
+}
+
+// Scan matches new and changed files, removes files which are gone and reports the result
+// files are only looked up while today's OMDb quota lasts, the others are skipped until a later scan
+// the report is never nil, it covers the files scanned before an error
+func (s *Scanner) Scan(ctx context.Context) (*Report, error) {
+	report := &Report{}
+	used, err := s.store.GetJobRequests(s.now().UTC().Truncate(24 * time.Hour))
+	if err != nil {
+		return report, err
+	}
+	budget := s.quota - used
+	files, err := util.FindValidFiles(s.root, Extensions...)
+	if err != nil {
+		return report, fmt.Errorf("could not read library %s: %w", s.root, err)
+	}
+	items, err := s.store.GetLibraryItems()
+	if err != nil {
+		return report, err
+	}
+	known := make(map[string]*api.LibraryItem, len(items))
+	for _, item := range items {
+		known[item.Path] = item
+	}
+
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		rel, err := filepath.Rel(s.root, f.Path)
+		if err != nil {
+			return report, err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			slog.Warn("could not read file", "job", JobName, "path", f.Path, "err", err.Error())
+			continue
+		}
+		report.Scanned++
+
+		// an added or edited NFO counts as change of the file
+		modTime := info.ModTime()
+		nfoPath, hasNFO := FindNFO(f.Path)
+		if hasNFO {
+			if nfoInfo, err := os.Stat(nfoPath); err == nil && nfoInfo.ModTime().After(modTime) {
+				modTime = nfoInfo.ModTime()
+			}
+		}
+		modTime = modTime.UTC().Truncate(time.Second)
+		if item, ok := known[rel]; ok && item.Size == info.Size() && item.ModTime.Equal(modTime) &&
+			(item.Status == api.LibraryMatched || !s.Retry) {
+			report.Unchanged++
+			report.add(item)
+			if s.WriteNFO && !hasNFO && item.Status == api.LibraryMatched {
+				movie, err := s.store.GetMovieByID(item.MediaID)
+				if err == nil && s.writeNFO(f.Path, movie, item, report) {
+					if err := s.store.SaveLibraryItem(item, movie); err != nil {
+						return report, err
+					}
+				}
+			}
+			continue
+		}
+
+		if budget-report.Requests < maxLookupRequests {
+			report.Skipped++
+			continue
+		}
+		var nfo *NFO
+		if hasNFO {
+			if nfo, err = ReadNFO(nfoPath); err != nil {
+				slog.Warn("could not read nfo", "job", JobName, "path", nfoPath, "err", err.Error())
+			}
+		}
+		item := &api.LibraryItem{Path: rel, Size: info.Size(), ModTime: modTime, ScannedAt: s.now()}
+		movie := s.match(item, nfo, report)
+		if movie != nil {
+			report.Matched++
+			if s.WriteNFO && !hasNFO {
+				s.writeNFO(f.Path, movie, item, report)
+			}
+		}
+		if err := s.store.SaveLibraryItem(item, movie); err != nil {
+			return report, err
+		}
+		if movie != nil && s.OnMatch != nil {
+			s.OnMatch(movie)
+		}
+		report.add(item)
+	}
+
+	for path := range known {
+		if seen[path] {
+			continue
+		}
+		if err := s.store.DeleteLibraryItem(path); err != nil {
+			return report, err
+		}
+		report.Removed++
+	}
+	if report.Skipped > 0 {
+		return report, errors.New("daily OMDb quota used up")
+	}
+	return report, nil
+}
+
+// match resolves the item by the IMDb id of its NFO or else by title and year, the item status is set accordingly
+// title and year come from the NFO if it has them and from the file name otherwise
+func (s *Scanner) match(item *api.LibraryItem, nfo *NFO, report *Report) *api.Movie {
+	item.Title, item.Year = parseName(item.Path)
+	if nfo != nil {
+		if title := strings.TrimSpace(nfo.Title); title != "" {
+			item.Title = title
+		}
+		if year := nfo.ReleaseYear(); year != 0 {
+			item.Year = year
+		}
+		if id := nfo.IMDb(); id != "" {
+			report.Lookups++
+			report.Requests++
+			movie, err := s.fetch(id)
+			if err != nil {
+				item.Status = api.LibraryUnmatched
+				item.Reason = fmt.Sprintf("nfo id %s: %s", id, err.Error())
+				return nil
+			}
+			item.Title = movie.Title
+			item.Status = api.LibraryMatched
+			item.Reason = ""
+			return movie
+		}
+	}
+	report.Lookups++
+	movie, candidates, requests, err := s.lookup(item.Title, item.Year)
+	report.Requests += requests
+	switch {
+	case err != nil:
+		item.Status = api.LibraryUnmatched
+		item.Reason = err.Error()
+	case movie == nil && len(candidates) > 0:
+		item.Status = api.LibraryAmbiguous
+		item.Reason = "could be " + strings.Join(candidates, ", ")
+	case movie == nil:
+		item.Status = api.LibraryUnmatched
+		item.Reason = "no movie found"
+	default:
+		item.Status = api.LibraryMatched
+		item.Reason = ""
+	}
+	if movie != nil && item.Year != 0 && movie.Year != strconv.Itoa(item.Year) {
+		// OMDb returned a different movie than the file name names
+		item.Status = api.LibraryAmbiguous
+		item.Reason = fmt.Sprintf("found %s (%s) %s", movie.Title, movie.Year, movie.ImdbID)
+		return nil
+	}
+	return movie
+}
+
+// writeNFO writes the NFO of a matched file and reports if it was written
+// the modification time of the item is updated, so the new NFO does not count as change on the next scan
+// folders which cannot be written to only log a warning
+func (s *Scanner) writeNFO(video string, movie *api.Movie, item *api.LibraryItem, report *Report) bool {
+	path := NFOPaths(video)[0]
+	if err := WriteNFO(path, movie); err != nil {
+		slog.Warn("could not write nfo", "job", JobName, "path", path, "err", err.Error())
+		return false
+	}
+	report.NFOWritten++
+	if info, err := os.Stat(path); err == nil {
+		if modTime := info.ModTime().UTC().Truncate(time.Second); modTime.After(item.ModTime) {
+			item.ModTime = modTime
+		}
+	}
+	return true
+}
+
+// parseName returns title and year of a library path
+// files like "Alien (1979)/movie.mkv" without year in the file name use the name of their directory
+func parseName(path string) (string, int) {
+	if title, year, err := util.ExtractTitleAndYearFromPath(path); err == nil {
+		return title, year
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if title, year, err := util.ExtractTitleAndYearFromPath(dir); err == nil {
+			return title, year
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.TrimSpace(strings.NewReplacer(".", " ", "_", " ").Replace(name)), 0
+}
+
+func (r *Report) add(item *api.LibraryItem) {
+	switch item.Status {
+	case api.LibraryAmbiguous:
+		r.Ambiguous = append(r.Ambiguous, item)
+	case api.LibraryUnmatched:
+		r.Unmatched = append(r.Unmatched, item)
+	}
+}
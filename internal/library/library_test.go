@@ -0,0 +1,317 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+type fakeStore struct {
+	items    map[string]*api.LibraryItem
+	movies   map[string]*api.Movie
+	runs     []*api.JobRun
+	requests int
+}
+
+func (f *fakeStore) GetLibraryItems() ([]*api.LibraryItem, error) {
+	var items []*api.LibraryItem
+	for _, item := range f.items {
+		copied := *item
+		items = append(items, &copied)
+	}
+	return items, nil
+}
+
+func (f *fakeStore) SaveLibraryItem(item *api.LibraryItem, movie *api.Movie) error {
+	if movie != nil {
+		item.MediaID = movie.ImdbID
+		f.movies[movie.ImdbID] = movie
+	}
+	f.items[item.Path] = item
+	return nil
+}
+
+func (f *fakeStore) GetMovieByID(id string) (*api.Movie, error) {
+	if movie, ok := f.movies[id]; ok {
+		return movie, nil
+	}
+	return nil, errors.New("movie not found")
+}
+
+func newStore() *fakeStore {
+	return &fakeStore{items: map[string]*api.LibraryItem{}, movies: map[string]*api.Movie{}}
+}
+
+func (f *fakeStore) DeleteLibraryItem(path string) error {
+	delete(f.items, path)
+	return nil
+}
+
+func (f *fakeStore) CreateJobRun(run *api.JobRun) error {
+	f.runs = append(f.runs, run)
+	f.requests += run.Requests
+	return nil
+}
+
+func (f *fakeStore) GetJobRequests(time.Time) (int, error) {
+	return f.requests, nil
+}
+
+// fakeLookup knows a few movies, "Dune" without a year is ambiguous
+func fakeLookup(calls *[]string) Lookup {
+	movies := map[string]*api.Movie{
+		"Alien 1979":                {ImdbID: "tt0078748", Title: "Alien", Year: "1979"},
+		"2001 A Space Odyssey 1968": {ImdbID: "tt0062622", Title: "2001: A Space Odyssey", Year: "1968"},
+		"Heat 1995":                 {ImdbID: "tt0113277", Title: "Heat", Year: "1995"},
+		"Heat 1986":                 {ImdbID: "tt0113277", Title: "Heat", Year: "1995"},
+	}
+	return func(title string, year int) (*api.Movie, []string, int, error) {
+		key := title
+		if year != 0 {
+			key += " " + strconv.Itoa(year)
+		}
+		*calls = append(*calls, key)
+		if title == "Dune" && year == 0 {
+			return nil, []string{"Dune (1984) tt0087182", "Dune (2021) tt1160419"}, 1, nil
+		}
+		return movies[key], nil, 1, nil
+	}
+}
+
+func writeFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func paths(items []*api.LibraryItem) []string {
+	var paths []string
+	for _, item := range items {
+		paths = append(paths, item.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestScanner_Scan(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"Alien (1979)/movie.MKV",
+		"2001.A.Space.Odyssey.1968.1080p.mkv",
+		"Dune.mp4",
+		"Home Video.avi",
+		"Heat (1986).mp4",
+		"notes.txt",
+	)
+	store := newStore()
+	var calls []string
+	scanner := NewScanner(store, root, fakeLookup(&calls), time.Hour, 100)
+
+	report, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Scanned != 5 || report.Matched != 2 || report.Lookups != 5 {
+		t.Errorf("report = %+v, want 5 scanned, 2 matched and 5 lookups", report)
+	}
+	if diff := cmp.Diff([]string{"Dune.mp4", "Heat (1986).mp4"}, paths(report.Ambiguous)); diff != "" {
+		t.Errorf("ambiguous mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"Home Video.avi"}, paths(report.Unmatched)); diff != "" {
+		t.Errorf("unmatched mismatch (-want +got):\n%s", diff)
+	}
+	if got := store.items["Alien (1979)/movie.MKV"]; got.MediaID != "tt0078748" || got.Status != api.LibraryMatched {
+		t.Errorf("Alien item = %+v, want matched to tt0078748", got)
+	}
+
+	t.Run("unchanged files are not looked up again", func(t *testing.T) {
+		calls = nil
+		report, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 0 || report.Unchanged != 5 {
+			t.Errorf("lookups = %v, unchanged = %d, want no lookups and 5 unchanged", calls, report.Unchanged)
+		}
+		if len(report.Ambiguous) != 2 || len(report.Unmatched) != 1 {
+			t.Errorf("report = %+v, want earlier ambiguous and unmatched files", report)
+		}
+	})
+
+	t.Run("retry looks up files without match", func(t *testing.T) {
+		calls = nil
+		scanner.Retry = true
+		defer func() { scanner.Retry = false }()
+		if _, err := scanner.Scan(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(calls)
+		if diff := cmp.Diff([]string{"Dune", "Heat 1986", "Home Video"}, calls); diff != "" {
+			t.Errorf("lookups mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("removed files are dropped", func(t *testing.T) {
+		if err := os.RemoveAll(filepath.Join(root, "Alien (1979)")); err != nil {
+			t.Fatal(err)
+		}
+		report, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Removed != 1 {
+			t.Errorf("removed = %d, want 1", report.Removed)
+		}
+		if _, ok := store.items["Alien (1979)/movie.MKV"]; ok {
+			t.Error("removed file is still in the library")
+		}
+	})
+}
+
+func TestScanner_RunOnce(t *testing.T) {
+	store := newStore()
+	var calls []string
+	scanner := NewScanner(store, filepath.Join(t.TempDir(), "missing"), fakeLookup(&calls), time.Hour, 100)
+	run := scanner.RunOnce(context.Background())
+	if run.Error == "" {
+		t.Error("run of a missing library has no error")
+	}
+	if len(store.runs) != 1 {
+		t.Errorf("recorded runs = %d, want 1", len(store.runs))
+	}
+}
+
+func TestScanner_NFO(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "Alien (1979)/Alien (1979).mkv", "Heat (1986).mp4", "Dune.mp4", "Wrong ID.mkv", "2001.A.Space.Odyssey.1968.mkv")
+	for nfo, path := range map[string]string{
+		"kodi.nfo":     "Alien (1979)/movie.nfo",
+		"jellyfin.nfo": "Heat (1986).nfo",
+		"title.nfo":    "Dune.nfo",
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata", "nfo", nfo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, root, path)
+		if err := os.WriteFile(filepath.Join(root, path), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "Wrong ID.nfo"), []byte("<movie><imdbid>tt9999999</imdbid></movie>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newStore()
+	var calls []string
+	scanner := NewScanner(store, root, fakeLookup(&calls), time.Hour, 100)
+	var fetched []string
+	scanner.fetch = func(id string) (*api.Movie, error) {
+		fetched = append(fetched, id)
+		switch id {
+		case "tt0078748":
+			return &api.Movie{ImdbID: id, Title: "Alien", Year: "1979"}, nil
+		case "tt0113277":
+			return &api.Movie{ImdbID: id, Title: "Heat", Year: "1995"}, nil
+		}
+		return nil, errors.New("Incorrect IMDb ID.")
+	}
+	scanner.WriteNFO = true
+
+	report, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(fetched)
+	if diff := cmp.Diff([]string{"tt0078748", "tt0113277", "tt9999999"}, fetched); diff != "" {
+		t.Errorf("fetched ids mismatch (-want +got):\n%s", diff)
+	}
+	// the year of the NFO wins over the wrong year in the file name, Dune is looked up with the year of its NFO
+	slices.Sort(calls)
+	if diff := cmp.Diff([]string{"2001 A Space Odyssey 1968", "Dune 2021"}, calls); diff != "" {
+		t.Errorf("lookups mismatch (-want +got):\n%s", diff)
+	}
+	if got := store.items["Heat (1986).mp4"]; got.MediaID != "tt0113277" || got.Year != 1995 {
+		t.Errorf("Heat item = %+v, want matched by its NFO", got)
+	}
+	if diff := cmp.Diff([]string{"Dune.mp4", "Wrong ID.mkv"}, paths(report.Unmatched)); diff != "" {
+		t.Errorf("unmatched mismatch (-want +got):\n%s", diff)
+	}
+
+	// only the matched file without NFO gets one
+	if report.NFOWritten != 1 {
+		t.Errorf("written NFOs = %d, want 1", report.NFOWritten)
+	}
+	nfo, err := ReadNFO(filepath.Join(root, "2001.A.Space.Odyssey.1968.nfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nfo.IMDb() != "tt0062622" {
+		t.Errorf("written NFO id = %s, want tt0062622", nfo.IMDb())
+	}
+
+	t.Run("written NFOs are no change", func(t *testing.T) {
+		calls, fetched = nil, nil
+		report, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(calls)+len(fetched) != 0 || report.Unchanged != 5 || report.NFOWritten != 0 {
+			t.Errorf("report = %+v, lookups %v %v, want all files unchanged", report, calls, fetched)
+		}
+	})
+}
+
+func TestScanner_quota(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "Alien (1979).mkv", "Heat (1995).mp4", "2001.A.Space.Odyssey.1968.mkv")
+	tests := []struct {
+		name        string
+		quota       int
+		used        int
+		wantLookups int
+		wantSkipped int
+	}{
+		{name: "quota left", quota: 100, used: 90, wantLookups: 3},
+		{name: "quota shared with other jobs", quota: 100, used: 97, wantLookups: 2, wantSkipped: 1},
+		{name: "quota used up", quota: 100, used: 100, wantSkipped: 3},
+		{name: "scans disabled", quota: 0, wantSkipped: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore()
+			store.requests = tt.used
+			var calls []string
+			scanner := NewScanner(store, root, fakeLookup(&calls), time.Hour, tt.quota)
+
+			run, report := scanner.ScanAndRecord(context.Background())
+			if len(calls) != tt.wantLookups || report.Skipped != tt.wantSkipped {
+				t.Errorf("lookups = %d, skipped = %d, want %d and %d", len(calls), report.Skipped, tt.wantLookups, tt.wantSkipped)
+			}
+			if run.Requests != tt.wantLookups {
+				t.Errorf("recorded requests = %d, want %d", run.Requests, tt.wantLookups)
+			}
+			if (run.Error != "") != (tt.wantSkipped > 0) {
+				t.Errorf("Error = %q with %d skipped files", run.Error, tt.wantSkipped)
+			}
+			if len(store.items) != tt.wantLookups {
+				t.Errorf("stored %d files, want only the %d looked up", len(store.items), tt.wantLookups)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package library
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/util"
+)
+
+// imdbIDPattern finds IMDb ids in NFO files which only contain a link instead of XML
+var imdbIDPattern = regexp.MustCompile(`tt\d{7,8}`)
+
+// NFO is the movie metadata Kodi and Jellyfin store in .nfo files next to the videos
+type NFO struct {
+	XMLName       xml.Name    `xml:"movie"`
+	Title         string      `xml:"title"`
+	OriginalTitle string      `xml:"originaltitle,omitempty"`
+	Year          string      `xml:"year,omitempty"`
+	Plot          string      `xml:"plot,omitempty"`
+	Runtime       string      `xml:"runtime,omitempty"`
+	MPAA          string      `xml:"mpaa,omitempty"`
+	Premiered     string      `xml:"premiered,omitempty"`
+	Genres        []string    `xml:"genre"`
+	Countries     []string    `xml:"country"`
+	Directors     []string    `xml:"director"`
+	Credits       []string    `xml:"credits"`
+	Actors        []NFOActor  `xml:"actor"`
+	UniqueIDs     []UniqueID  `xml:"uniqueid"`
+	IMDbID        string      `xml:"imdbid,omitempty"`
+	ID            string      `xml:"id,omitempty"`
+	Thumbs        []NFOThumb  `xml:"thumb"`
+	Ratings       *NFORatings `xml:"ratings,omitempty"`
+}
+
+type NFOActor struct {
+	Name string `xml:"name"`
+}
+
+// UniqueID is an id of the movie on a site like imdb or tmdb
+type UniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+type NFOThumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	URL    string `xml:",chardata"`
+}
+
+type NFORatings struct {
+	Ratings []NFORating `xml:"rating"`
+}
+
+type NFORating struct {
+	Name    string `xml:"name,attr"`
+	Max     int    `xml:"max,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:"value"`
+	Votes   string `xml:"votes,omitempty"`
+}
+
+// IMDb returns the IMDb id of the movie, or an empty string if the NFO has none
+// uniqueid elements are preferred over the imdbid and id elements of older files
+func (n *NFO) IMDb() string {
+	for _, id := range n.UniqueIDs {
+		if match := imdbIDPattern.FindString(id.Value); strings.EqualFold(id.Type, "imdb") && match != "" {
+			return match
+		}
+	}
+	for _, id := range []string{n.IMDbID, n.ID} {
+		if match := imdbIDPattern.FindString(id); match != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+// ReleaseYear returns the year of the movie, taken from the premiere date if the year is missing
+func (n *NFO) ReleaseYear() int {
+	if year, err := strconv.Atoi(strings.TrimSpace(n.Year)); err == nil {
+		return year
+	}
+	if len(n.Premiered) >= 4 {
+		if year, err := strconv.Atoi(n.Premiered[:4]); err == nil {
+			return year
+		}
+	}
+	return 0
+}
+
+// ReadNFO parses the NFO file at path
+// files which are no XML but contain an IMDb link, as Kodi allows, return an NFO with only the id
+func ReadNFO(path string) (*NFO, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var nfo NFO
+	if err := xml.Unmarshal(data, &nfo); err != nil {
+		id := imdbIDPattern.Find(data)
+		if id == nil {
+			return nil, err
+		}
+		return &NFO{IMDbID: string(id)}, nil
+	}
+	return &nfo, nil
+}
+
+// NFOPaths returns where the NFO of a video file is looked for, in order
+// "<video name>.nfo" next to the video, then movie.nfo in its folder
+func NFOPaths(video string) []string {
+	return []string{
+		strings.TrimSuffix(video, filepath.Ext(video)) + ".nfo",
+		filepath.Join(filepath.Dir(video), "movie.nfo"),
+	}
+}
+
+// FindNFO returns the path of the NFO of a video file and whether there is one
+func FindNFO(video string) (string, bool) {
+	for _, path := range NFOPaths(video) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// NFOFromMovie converts movie data from OMDb to an NFO
+func NFOFromMovie(m *api.Movie) *NFO {
+	nfo := &NFO{
+		Title:     m.Title,
+		Year:      m.Year,
+		Plot:      notAvailable(m.Plot),
+		MPAA:      notAvailable(m.Rated),
+		Genres:    splitList(m.Genre),
+		Countries: splitList(m.Country),
+		Directors: splitList(m.Director),
+		Credits:   splitList(m.Writer),
+		UniqueIDs: []UniqueID{{Type: "imdb", Default: true, Value: m.ImdbID}},
+		IMDbID:    m.ImdbID,
+	}
+	if m.RuntimeMinutes > 0 {
+		nfo.Runtime = strconv.Itoa(m.RuntimeMinutes)
+	}
+	if !m.ReleasedAt.IsZero() {
+		nfo.Premiered = m.ReleasedAt.Format("2006-01-02")
+	}
+	for _, name := range splitList(m.Actors) {
+		nfo.Actors = append(nfo.Actors, NFOActor{Name: name})
+	}
+	if poster := notAvailable(m.Poster); poster != "" {
+		nfo.Thumbs = []NFOThumb{{Aspect: "poster", URL: poster}}
+	}
+	if rating := notAvailable(m.ImdbRating); rating != "" {
+		nfo.Ratings = &NFORatings{Ratings: []NFORating{{
+			Name:    "imdb",
+			Max:     10,
+			Default: true,
+			Value:   rating,
+			Votes:   strings.ReplaceAll(notAvailable(m.ImdbVotes), ",", ""),
+		}}}
+	}
+	return nfo
+}
+
+// WriteNFO writes the NFO of a movie to path, an existing file is never replaced
+func WriteNFO(path string, m *api.Movie) error {
+	data, err := xml.MarshalIndent(NFOFromMovie(m), "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(xml.Header + string(data) + "\n")
+	return errors.Join(err, f.Close())
+}
+
+func splitList(s string) []string {
+	if notAvailable(s) == "" {
+		return nil
+	}
+	return util.SplitIMDBString(s)
+}
+
+// notAvailable returns s without the "N/A" OMDb uses for missing values
+func notAvailable(s string) string {
+	if s == "N/A" {
+		return ""
+	}
+	return s
+}
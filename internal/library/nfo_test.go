@@ -0,0 +1,106 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func TestReadNFO(t *testing.T) {
+	type want struct {
+		IMDb  string
+		Title string
+		Year  int
+	}
+	tests := []struct {
+		name    string
+		file    string
+		want    want
+		wantErr bool
+	}{
+		{name: "kodi uniqueid", file: "kodi.nfo", want: want{IMDb: "tt0078748", Title: "Alien", Year: 1979}},
+		{name: "kodi uniqueid with imdb link", file: "kodi-url.nfo", want: want{IMDb: "tt0083658", Title: "Blade Runner", Year: 1982}},
+		{name: "jellyfin imdbid and premiere date", file: "jellyfin.nfo", want: want{IMDb: "tt0113277", Title: "Heat", Year: 1995}},
+		{name: "imdb link", file: "link.nfo", want: want{IMDb: "tt0062622"}},
+		{name: "title and year only", file: "title.nfo", want: want{Title: "Dune", Year: 2021}},
+		{name: "no xml and no link", file: "broken.nfo", wantErr: true},
+		{name: "missing", file: "missing.nfo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nfo, err := ReadNFO(filepath.Join("testdata", "nfo", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadNFO() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := want{IMDb: nfo.IMDb(), Title: nfo.Title, Year: nfo.ReleaseYear()}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ReadNFO() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteNFO(t *testing.T) {
+	movie := &api.Movie{
+		ImdbID:     "tt0078748",
+		Title:      "Alien",
+		Year:       "1979",
+		Genre:      "Horror, Sci-Fi",
+		Actors:     "Sigourney Weaver, Tom Skerritt",
+		Plot:       "N/A",
+		Poster:     "https://example.com/alien.jpg",
+		ImdbRating: "8.5",
+		ImdbVotes:  "1,000,000",
+
+		RuntimeMinutes: 117,
+		ReleasedAt:     time.Date(1979, 6, 22, 0, 0, 0, 0, time.UTC),
+	}
+	path := filepath.Join(t.TempDir(), "Alien (1979).nfo")
+	if err := WriteNFO(path, movie); err != nil {
+		t.Fatal(err)
+	}
+	nfo, err := ReadNFO(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(NFOFromMovie(movie), nfo, cmp.FilterPath(func(p cmp.Path) bool {
+		return p.String() == "XMLName"
+	}, cmp.Ignore())); diff != "" {
+		t.Errorf("written NFO mismatch (-want +got):\n%s", diff)
+	}
+	if nfo.Plot != "" || nfo.Ratings.Ratings[0].Votes != "1000000" {
+		t.Errorf("nfo = %+v, want no plot and plain votes", nfo)
+	}
+
+	t.Run("existing files are kept", func(t *testing.T) {
+		if err := WriteNFO(path, &api.Movie{ImdbID: "tt0000001"}); !os.IsExist(err) {
+			t.Errorf("WriteNFO() error = %v, want file exists", err)
+		}
+	})
+}
+
+func TestFindNFO(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "Alien (1979)/Alien (1979).mkv", "Alien (1979)/movie.nfo", "Heat.mkv", "Heat.nfo", "Dune.mkv")
+	tests := []struct {
+		video string
+		want  string
+	}{
+		{video: "Alien (1979)/Alien (1979).mkv", want: "Alien (1979)/movie.nfo"},
+		{video: "Heat.mkv", want: "Heat.nfo"},
+		{video: "Dune.mkv", want: ""},
+	}
+	for _, tt := range tests {
+		got, ok := FindNFO(filepath.Join(root, tt.video))
+		if ok != (tt.want != "") || (ok && got != filepath.Join(root, tt.want)) {
+			t.Errorf("FindNFO(%q) = %q, %v, want %q", tt.video, got, ok, tt.want)
+		}
+	}
+}
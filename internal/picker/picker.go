@@ -0,0 +1,70 @@
+// Package picker randomly chooses an unwatched movie for tonight
+package picker
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+const (
+	// Cooldown is how long a suggested movie is avoided by weighted picks
+	Cooldown = 14 * 24 * time.Hour
+	// minRecentWeight keeps a just suggested movie pickable, if only rarely
+	minRecentWeight = 0.05
+)
+
+// Picker chooses among candidates, either uniformly or weighted
+type Picker struct {
+	random func() float64
+	now    func() time.Time
+}
+
+// New returns a Picker using the default random source and clock
+func New() *Picker {
+	return &Picker{
+		random: rand.Float64,
+		now:    time.Now,
+	}
+}
+
+// Pick returns a random candidate, nil if there are none
+// weighted picks favour movies on the lists of several members and avoid movies suggested within the Cooldown
+func (p *Picker) Pick(candidates []*api.PickCandidate, weighted bool) *api.PickCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+	if !weighted {
+		return candidates[int(p.random()*float64(len(candidates)))]
+	}
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, c := range candidates {
+		weights[i] = p.Weight(c)
+		total += weights[i]
+	}
+	target := p.random() * total
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return candidates[i]
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// Weight is the relative chance of a candidate in weighted picks
+// every member with the movie on their list counts once, a recent suggestion lowers the weight
+// until it recovers linearly at the end of the Cooldown
+func (p *Picker) Weight(c *api.PickCandidate) float64 {
+	weight := float64(max(c.Members, 1))
+	if c.LastPickedAt.IsZero() {
+		return weight
+	}
+	elapsed := p.now().Sub(c.LastPickedAt)
+	if elapsed >= Cooldown {
+		return weight
+	}
+	return weight * max(minRecentWeight, float64(elapsed)/float64(Cooldown))
+}
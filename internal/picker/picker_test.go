@@ -0,0 +1,71 @@
+package picker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+var now = time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+
+func fixed(value float64) func() float64 {
+	return func() float64 { return value }
+}
+
+func TestPicker_Weight(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate *api.PickCandidate
+		want      float64
+	}{
+		{"never picked", &api.PickCandidate{Members: 3}, 3},
+		{"no members", &api.PickCandidate{}, 1},
+		{"picked long ago", &api.PickCandidate{Members: 2, LastPickedAt: now.Add(-Cooldown)}, 2},
+		{"picked halfway", &api.PickCandidate{Members: 2, LastPickedAt: now.Add(-Cooldown / 2)}, 1},
+		{"picked just now", &api.PickCandidate{Members: 2, LastPickedAt: now}, 2 * minRecentWeight},
+	}
+	p := &Picker{now: func() time.Time { return now }}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Weight(tt.candidate); got != tt.want {
+				t.Errorf("Weight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPicker_Pick(t *testing.T) {
+	candidates := []*api.PickCandidate{
+		{MediaID: "tt1", Members: 1},
+		{MediaID: "tt2", Members: 3},
+		{MediaID: "tt3", Members: 4, LastPickedAt: now},
+	}
+	tests := []struct {
+		name     string
+		random   float64
+		weighted bool
+		want     string
+	}{
+		{"uniform first", 0, false, "tt1"},
+		{"uniform last", 0.99, false, "tt3"},
+		// weights are 1, 3 and 0.2
+		{"weighted first", 0.2, true, "tt1"},
+		{"weighted favours members", 0.5, true, "tt2"},
+		{"weighted avoids recent", 0.93, true, "tt2"},
+		{"weighted recent", 0.99, true, "tt3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Picker{random: fixed(tt.random), now: func() time.Time { return now }}
+			got := p.Pick(candidates, tt.weighted)
+			if diff := cmp.Diff(tt.want, got.MediaID); diff != "" {
+				t.Errorf("Pick() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if got := New().Pick(nil, true); got != nil {
+		t.Errorf("Pick() of no candidates = %v, want nil", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package poll tallies ranked ballots of a watch party poll with instant-runoff voting
+package poll
+
+import (
+	"slices"
+)
+
+// Round is one count of an instant-runoff tally
+type Round struct {
+	// Counts holds the votes of every option still running
+	Counts map[string]int
+	// Exhausted is the number of ballots without any running option left
+	Exhausted int
+	// Eliminated is the option dropped after the round, empty in the final round
+	Eliminated string
+}
+
+// Result is the winner of a poll and the rounds leading to it
+type Result struct {
+	Winner string
+	Rounds []Round
+}
+
+// Tally counts every ballot for its highest ranked running option, until an option has a majority
+// of the ballots still counting or only one is left. Otherwise the option with the fewest votes is eliminated,
+// ties are broken by the fewest votes in the earliest round they differ in, then by the later position in options.
+// Options which appear on no ballot still run, ballot entries which are not options are ignored.
+func Tally(options []string, ballots [][]string) *Result {
+	result := &Result{}
+	if len(options) == 0 {
+		return result
+	}
+	running := slices.Clone(options)
+	for {
+		round := Round{Counts: make(map[string]int, len(running))}
+		for _, option := range running {
+			round.Counts[option] = 0
+		}
+		for _, ballot := range ballots {
+			if choice, ok := firstRunning(ballot, round.Counts); ok {
+				round.Counts[choice]++
+			} else {
+				round.Exhausted++
+			}
+		}
+		counting := len(ballots) - round.Exhausted
+		for _, option := range running {
+			if len(running) == 1 || round.Counts[option]*2 > counting {
+				result.Rounds = append(result.Rounds, round)
+				result.Winner = option
+				return result
+			}
+		}
+		round.Eliminated = weakest(running, append(result.Rounds, round))
+		result.Rounds = append(result.Rounds, round)
+		running = slices.DeleteFunc(running, func(option string) bool { return option == round.Eliminated })
+	}
+}
+
+// firstRunning returns the highest ranked option of the ballot which is still running
+func firstRunning(ballot []string, counts map[string]int) (string, bool) {
+	for _, choice := range ballot {
+		if _, ok := counts[choice]; ok {
+			return choice, true
+		}
+	}
+	return "", false
+}
+
+// weakest returns the running option to eliminate after the last of rounds
+func weakest(running []string, rounds []Round) string {
+	last := rounds[len(rounds)-1]
+	candidates := []string{}
+	fewest := -1
+	for _, option := range running {
+		switch count := last.Counts[option]; {
+		case fewest < 0 || count < fewest:
+			fewest, candidates = count, []string{option}
+		case count == fewest:
+			candidates = append(candidates, option)
+		}
+	}
+	for _, round := range rounds {
+		if len(candidates) == 1 {
+			break
+		}
+		fewest := slices.MinFunc(candidates, func(a, b string) int { return round.Counts[a] - round.Counts[b] })
+		candidates = slices.DeleteFunc(candidates, func(option string) bool {
+			return round.Counts[option] > round.Counts[fewest]
+		})
+	}
+	return candidates[len(candidates)-1]
+}
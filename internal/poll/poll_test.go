@@ -0,0 +1,91 @@
+package poll
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTally(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		ballots [][]string
+		want    *Result
+	}{
+		{
+			name:    "majority in first round",
+			options: []string{"alien", "heat", "up"},
+			ballots: [][]string{{"alien"}, {"alien", "up"}, {"heat"}},
+			want: &Result{Winner: "alien", Rounds: []Round{
+				{Counts: map[string]int{"alien": 2, "heat": 1, "up": 0}},
+			}},
+		},
+		{
+			name:    "transfers decide",
+			options: []string{"alien", "heat", "up"},
+			ballots: [][]string{
+				{"alien", "heat"}, {"alien", "heat"}, {"heat", "alien"},
+				{"heat", "up"}, {"up", "heat"},
+			},
+			want: &Result{Winner: "heat", Rounds: []Round{
+				{Counts: map[string]int{"alien": 2, "heat": 2, "up": 1}, Eliminated: "up"},
+				{Counts: map[string]int{"alien": 2, "heat": 3}},
+			}},
+		},
+		{
+			name:    "exhausted ballots do not count for the majority",
+			options: []string{"alien", "heat", "up"},
+			ballots: [][]string{{"alien"}, {"alien"}, {"heat"}, {"heat"}, {"up"}},
+			want: &Result{Winner: "alien", Rounds: []Round{
+				{Counts: map[string]int{"alien": 2, "heat": 2, "up": 1}, Eliminated: "up"},
+				{Counts: map[string]int{"alien": 2, "heat": 2}, Exhausted: 1, Eliminated: "heat"},
+				{Counts: map[string]int{"alien": 2}, Exhausted: 3},
+			}},
+		},
+		{
+			name:    "tie broken by earlier round",
+			options: []string{"heat", "alien", "up"},
+			ballots: [][]string{
+				{"alien"}, {"alien"}, {"alien"},
+				{"heat"}, {"heat"}, {"up", "heat"},
+			},
+			want: &Result{Winner: "alien", Rounds: []Round{
+				{Counts: map[string]int{"alien": 3, "heat": 2, "up": 1}, Eliminated: "up"},
+				{Counts: map[string]int{"alien": 3, "heat": 3}, Eliminated: "heat"},
+				{Counts: map[string]int{"alien": 3}, Exhausted: 3},
+			}},
+		},
+		{
+			name:    "full tie eliminates the later option",
+			options: []string{"alien", "heat"},
+			ballots: [][]string{{"alien"}, {"heat"}},
+			want: &Result{Winner: "alien", Rounds: []Round{
+				{Counts: map[string]int{"alien": 1, "heat": 1}, Eliminated: "heat"},
+				{Counts: map[string]int{"alien": 1}, Exhausted: 1},
+			}},
+		},
+		{
+			name:    "unknown choices are ignored",
+			options: []string{"alien", "heat"},
+			ballots: [][]string{{"jaws", "heat"}, {"heat"}, {"alien"}},
+			want: &Result{Winner: "heat", Rounds: []Round{
+				{Counts: map[string]int{"alien": 1, "heat": 2}},
+			}},
+		},
+		{
+			name:    "no options",
+			options: nil,
+			ballots: [][]string{{"alien"}},
+			want:    &Result{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Tally(tt.options, tt.ballots)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Tally() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
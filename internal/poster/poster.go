@@ -0,0 +1,206 @@
+// Package poster mirrors movie posters on disk, so pages do not hot-link the OMDb image CDN
+// every poster is downloaded once and stored as JPEG in all sizes
+package poster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// Size names a stored variant of a poster
+type Size string
+
+const (
+	Thumb Size = "thumb"
+	Full  Size = "full"
+)
+
+// widths holds the maximum width of every size, smaller posters are never scaled up
+var widths = map[Size]int{
+	Thumb: 100,
+	Full:  600,
+}
+
+// sourceFile stores the url a poster was downloaded from
+const sourceFile = "source"
+
+// maxPosterBytes limits downloads, OMDb posters are usually well below 100 KB
+const maxPosterBytes = 10 << 20
+
+// ErrNoPoster is returned for movies without a poster url
+var ErrNoPoster = errors.New("no poster available")
+
+// Placeholder is served for movies without a poster
+var Placeholder = []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="300" height="450" viewBox="0 0 300 450">` +
+	`<rect width="300" height="450" fill="#34495e"/>` +
+	`<text x="150" y="225" fill="#ecf0f1" font-family="sans-serif" font-size="24" text-anchor="middle">No Poster</text></svg>`)
+
+// ParseSize returns the Size with the given name
+func ParseSize(name string) (Size, bool) {
+	size := Size(name)
+	_, ok := widths[size]
+	return size, ok
+}
+
+// Store keeps posters in one directory per movie below dir
+type Store struct {
+	dir    string
+	client *http.Client
+	locks  sync.Map
+}
+
+// NewStore returns a Store saving posters below dir
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:    dir,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Path returns the file of a poster size, the file may not exist yet
+func (s *Store) Path(imdbID string, size Size) string {
+	return filepath.Join(s.dir, imdbID, string(size)+".jpg")
+}
+
+// Has reports if the poster size of a movie is stored
+func (s *Store) Has(imdbID string, size Size) bool {
+	_, err := os.Stat(s.Path(imdbID, size))
+	return err == nil
+}
+
+// Fetch downloads the poster at url unless it was already downloaded from the same url
+// stored posters are removed when the movie has no poster anymore
+func (s *Store) Fetch(ctx context.Context, imdbID, url string) error {
+	unlock := s.lock(imdbID)
+	defer unlock()
+
+	url = strings.TrimSpace(url)
+	if url == "" || url == api.NotAvailable {
+		if err := os.RemoveAll(filepath.Join(s.dir, imdbID)); err != nil {
+			return err
+		}
+		return ErrNoPoster
+	}
+	if source, err := os.ReadFile(filepath.Join(s.dir, imdbID, sourceFile)); err == nil && string(source) == url {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading poster of %s: %s", imdbID, res.Status)
+	}
+	return s.save(imdbID, url, io.LimitReader(res.Body, maxPosterBytes))
+}
+
+// Remove deletes all stored sizes of a poster
+func (s *Store) Remove(imdbID string) error {
+	unlock := s.lock(imdbID)
+	defer unlock()
+	return os.RemoveAll(filepath.Join(s.dir, imdbID))
+}
+
+// save decodes a poster and writes all sizes, the source url is written last
+// so an interrupted save is retried by the next Fetch
+func (s *Store) save(imdbID, source string, r io.Reader) error {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("decoding poster of %s: %w", imdbID, err)
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, imdbID), 0o755); err != nil {
+		return err
+	}
+	for size, width := range widths {
+		err := writeFile(s.Path(imdbID, size), func(w io.Writer) error {
+			return jpeg.Encode(w, Resize(img, width), &jpeg.Options{Quality: 85})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return writeFile(filepath.Join(s.dir, imdbID, sourceFile), func(w io.Writer) error {
+		_, err := io.WriteString(w, source)
+		return err
+	})
+}
+
+func (s *Store) lock(imdbID string) (unlock func()) {
+	mu, _ := s.locks.LoadOrStore(imdbID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// writeFile writes to a temporary file first, so readers never see a partial poster
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Resize scales img down to the given width keeping the aspect ratio
+// every target pixel is the average of the source pixels it covers
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0, y1 := y*bounds.Dy()/height, max((y+1)*bounds.Dy()/height, y*bounds.Dy()/height+1)
+		for x := range width {
+			x0, x1 := x*bounds.Dx()/width, max((x+1)*bounds.Dx()/width, x*bounds.Dx()/width+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
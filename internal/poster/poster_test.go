@@ -0,0 +1,138 @@
+package poster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        image.Image
+		width      int
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "poster to thumb", src: testImage(300, 450), width: 100, wantWidth: 100, wantHeight: 150},
+		{name: "uneven scale", src: testImage(301, 445), width: 100, wantWidth: 100, wantHeight: 147},
+		{name: "never scaled up", src: testImage(300, 450), width: 600, wantWidth: 300, wantHeight: 450},
+		{name: "offset bounds", src: testImage(300, 450).(*image.RGBA).SubImage(image.Rect(100, 0, 300, 300)), width: 50, wantWidth: 50, wantHeight: 75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resize(tt.src, tt.width).Bounds()
+			if got.Dx() != tt.wantWidth || got.Dy() != tt.wantHeight {
+				t.Errorf("Resize() = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestResize_Averages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.RGBA{R: 200, A: 255})
+	src.Set(1, 0, color.RGBA{R: 100, A: 255})
+	src.Set(0, 1, color.RGBA{R: 0, A: 255})
+	src.Set(1, 1, color.RGBA{R: 100, A: 255})
+	got := Resize(src, 1).At(0, 0).(color.RGBA)
+	if want := (color.RGBA{R: 100, A: 255}); got != want {
+		t.Errorf("Resize() pixel = %v, want %v", got, want)
+	}
+}
+
+func TestStore_Fetch(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(300, 450)); err != nil {
+		t.Fatal(err)
+	}
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	store := NewStore(t.TempDir())
+	ctx := context.Background()
+	if err := store.Fetch(ctx, "tt0078748", srv.URL+"/alien.png"); err != nil {
+		t.Fatalf("Fetch() error: %v", err)
+	}
+	for size, wantWidth := range map[Size]int{Thumb: 100, Full: 300} {
+		f, err := os.Open(store.Path("tt0078748", size))
+		if err != nil {
+			t.Fatalf("poster size %s missing: %v", size, err)
+		}
+		cfg, err := jpeg.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("poster size %s is not a jpeg: %v", size, err)
+		}
+		if cfg.Width != wantWidth {
+			t.Errorf("poster size %s width = %d, want %d", size, cfg.Width, wantWidth)
+		}
+	}
+
+	if err := store.Fetch(ctx, "tt0078748", srv.URL+"/alien.png"); err != nil {
+		t.Fatalf("second Fetch() error: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("poster downloaded %d times, want 1", got)
+	}
+
+	if err := store.Fetch(ctx, "tt0062622", srv.URL+"/missing.jpg"); err == nil {
+		t.Error("Fetch() of missing poster returned no error")
+	}
+	if store.Has("tt0062622", Thumb) {
+		t.Error("failed Fetch() left a poster behind")
+	}
+
+	if err := store.Fetch(ctx, "tt0078748", "N/A"); !errors.Is(err, ErrNoPoster) {
+		t.Errorf("Fetch() of N/A poster error = %v, want %v", err, ErrNoPoster)
+	}
+	if store.Has("tt0078748", Thumb) {
+		t.Error("poster still stored after it became N/A")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Size
+		wantOK bool
+	}{
+		{name: "thumb", want: Thumb, wantOK: true},
+		{name: "full", want: Full, wantOK: true},
+		{name: "huge", want: "huge", wantOK: false},
+		{name: "", want: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSize(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseSize(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package recommend ranks movies by the genres, actors and directors they share, using only the stored library
+package recommend
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// LikedScore is the star rating from which a rated movie counts as liked
+const LikedScore = 3.5
+
+// kind weights, a shared director says more about a movie than a shared genre
+const (
+	genreWeight    = 1.0
+	actorWeight    = 1.5
+	directorWeight = 2.0
+)
+
+type kind int
+
+const (
+	director kind = iota
+	actor
+	genre
+)
+
+var kindWeights = map[kind]float64{
+	director: directorWeight,
+	actor:    actorWeight,
+	genre:    genreWeight,
+}
+
+// feature is a genre, actor or director, compared case insensitive
+type feature struct {
+	kind kind
+	name string
+}
+
+type movie struct {
+	id       string
+	title    string
+	features map[feature]string
+}
+
+// Index holds the features of all movies and how rare each feature is in the library
+type Index struct {
+	movies  map[string]*movie
+	ids     []string
+	weights map[feature]float64
+}
+
+// NewIndex builds an Index, every feature is weighted by its kind and its inverse document frequency,
+// so an actor in few movies counts more than one in many
+func NewIndex(features []*api.MediaFeatures) *Index {
+	idx := &Index{
+		movies:  make(map[string]*movie, len(features)),
+		weights: make(map[feature]float64),
+	}
+	frequency := make(map[feature]int)
+	for _, f := range features {
+		m := &movie{id: f.MediaID, title: f.Title, features: make(map[feature]string)}
+		add := func(k kind, names []string) {
+			for _, name := range names {
+				m.features[feature{kind: k, name: strings.ToLower(name)}] = name
+			}
+		}
+		add(director, f.Directors)
+		add(actor, f.Actors)
+		add(genre, f.Genres)
+		for key := range m.features {
+			frequency[key]++
+		}
+		idx.movies[f.MediaID] = m
+		idx.ids = append(idx.ids, f.MediaID)
+	}
+	n := float64(len(features))
+	for key, count := range frequency {
+		idx.weights[key] = kindWeights[key.kind] * math.Log(1+n/float64(count))
+	}
+	return idx
+}
+
+// Similarity is the weighted share of features two movies have in common, between 0 and 1
+func (idx *Index) Similarity(a, b string) float64 {
+	ma, mb := idx.movies[a], idx.movies[b]
+	if ma == nil || mb == nil || a == b {
+		return 0
+	}
+	shared, union := 0.0, 0.0
+	for key := range ma.features {
+		union += idx.weights[key]
+		if _, ok := mb.features[key]; ok {
+			shared += idx.weights[key]
+		}
+	}
+	for key := range mb.features {
+		if _, ok := ma.features[key]; !ok {
+			union += idx.weights[key]
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return shared / union
+}
+
+// Similar returns up to limit movies among candidates most similar to the movie with id
+func (idx *Index) Similar(id string, candidates map[string]bool, limit int) []*api.Recommendation {
+	var recommendations []*api.Recommendation
+	for _, other := range idx.ids {
+		if !candidates[other] {
+			continue
+		}
+		if score := idx.Similarity(id, other); score > 0 {
+			recommendations = append(recommendations, &api.Recommendation{
+				MediaID: other,
+				Title:   idx.movies[other].title,
+				Score:   score,
+				Shared:  idx.shared(id, other),
+			})
+		}
+	}
+	return top(recommendations, limit)
+}
+
+// Recommend ranks the movies not in seen by their similarity to the liked movies among ratings,
+// each liked movie adds its similarity weighted by how much it was liked
+func (idx *Index) Recommend(ratings map[string]float64, seen map[string]bool, limit int) []*api.Recommendation {
+	var recommendations []*api.Recommendation
+	for _, id := range idx.ids {
+		if seen[id] {
+			continue
+		}
+		if _, rated := ratings[id]; rated {
+			continue
+		}
+		rec := &api.Recommendation{MediaID: id, Title: idx.movies[id].title}
+		best, because := 0.0, ""
+		for liked, score := range ratings {
+			if score < LikedScore {
+				continue
+			}
+			similarity := idx.Similarity(id, liked) * score / 5
+			rec.Score += similarity
+			if similarity > best || (similarity == best && liked < because) {
+				best, because = similarity, liked
+			}
+		}
+		if rec.Score == 0 {
+			continue
+		}
+		rec.Because = idx.movies[because].title
+		rec.Shared = idx.shared(id, because)
+		recommendations = append(recommendations, rec)
+	}
+	return top(recommendations, limit)
+}
+
+// shared returns the display names of the features a has in common with b, directors first
+func (idx *Index) shared(a, b string) []string {
+	ma, mb := idx.movies[a], idx.movies[b]
+	keys := make([]feature, 0)
+	for key := range ma.features {
+		if _, ok := mb.features[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(x, y feature) int {
+		return cmp.Or(cmp.Compare(x.kind, y.kind), cmp.Compare(x.name, y.name))
+	})
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = ma.features[key]
+	}
+	return names
+}
+
+// top sorts recommendations by score and title and returns the first limit, all if limit is not positive
+func top(recommendations []*api.Recommendation, limit int) []*api.Recommendation {
+	slices.SortFunc(recommendations, func(a, b *api.Recommendation) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Title, b.Title))
+	})
+	if limit > 0 && len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations
+}
@@ -0,0 +1,115 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+var library = []*api.MediaFeatures{
+	{MediaID: "tt1", Title: "Alien", Genres: []string{"Horror", "Sci-Fi"}, Actors: []string{"Sigourney Weaver", "Tom Skerritt"}, Directors: []string{"Ridley Scott"}},
+	{MediaID: "tt2", Title: "Aliens", Genres: []string{"Action", "Sci-Fi"}, Actors: []string{"Sigourney Weaver", "Michael Biehn"}, Directors: []string{"James Cameron"}},
+	{MediaID: "tt3", Title: "Blade Runner", Genres: []string{"Drama", "Sci-Fi"}, Actors: []string{"Harrison Ford"}, Directors: []string{"Ridley Scott"}},
+	{MediaID: "tt4", Title: "The Terminator", Genres: []string{"Action", "Sci-Fi"}, Actors: []string{"Michael Biehn"}, Directors: []string{"James Cameron"}},
+	{MediaID: "tt5", Title: "Notting Hill", Genres: []string{"Comedy", "Romance"}, Actors: []string{"Hugh Grant"}, Directors: []string{"Roger Michell"}},
+}
+
+func TestIndex_Similarity(t *testing.T) {
+	idx := NewIndex(library)
+	if got := idx.Similarity("tt1", "tt1"); got != 0 {
+		t.Errorf("Similarity() of a movie to itself = %v, want 0", got)
+	}
+	if got := idx.Similarity("tt1", "tt5"); got != 0 {
+		t.Errorf("Similarity() without shared features = %v, want 0", got)
+	}
+	if a, b := idx.Similarity("tt2", "tt4"), idx.Similarity("tt4", "tt2"); a != b {
+		t.Errorf("Similarity() not symmetric: %v != %v", a, b)
+	}
+	// a shared director and actor count more than shared genres
+	if a, b := idx.Similarity("tt2", "tt4"), idx.Similarity("tt1", "tt2"); a <= b {
+		t.Errorf("Similarity(Aliens, Terminator) = %v, want more than Similarity(Alien, Aliens) = %v", a, b)
+	}
+}
+
+func TestIndex_Similar(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates map[string]bool
+		want       []*api.Recommendation
+	}{
+		{
+			name:       "most similar first",
+			candidates: map[string]bool{"tt3": true, "tt4": true, "tt5": true},
+			want: []*api.Recommendation{
+				{MediaID: "tt3", Title: "Blade Runner", Shared: []string{"Ridley Scott", "Sci-Fi"}},
+				{MediaID: "tt4", Title: "The Terminator", Shared: []string{"Sci-Fi"}},
+			},
+		},
+		{
+			name:       "only candidates",
+			candidates: map[string]bool{"tt4": true},
+			want: []*api.Recommendation{
+				{MediaID: "tt4", Title: "The Terminator", Shared: []string{"Sci-Fi"}},
+			},
+		},
+	}
+	idx := NewIndex(library)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.Similar("tt1", tt.candidates, 2)
+			if diff := cmp.Diff(tt.want, got, cmp.FilterPath(func(p cmp.Path) bool {
+				return p.Last().String() == ".Score"
+			}, cmp.Ignore())); diff != "" {
+				t.Errorf("Similar() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIndex_Recommend(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratings map[string]float64
+		seen    map[string]bool
+		want    []string
+		because string
+	}{
+		{
+			name:    "liked movie",
+			ratings: map[string]float64{"tt2": 5},
+			seen:    map[string]bool{},
+			want:    []string{"tt4", "tt1", "tt3"},
+			because: "Aliens",
+		},
+		{
+			name:    "seen and rated movies are left out",
+			ratings: map[string]float64{"tt2": 5, "tt3": 1},
+			seen:    map[string]bool{"tt4": true},
+			want:    []string{"tt1"},
+			because: "Aliens",
+		},
+		{
+			name:    "low ratings are not liked",
+			ratings: map[string]float64{"tt2": 3},
+			seen:    map[string]bool{},
+			want:    nil,
+		},
+	}
+	idx := NewIndex(library)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommendations := idx.Recommend(tt.ratings, tt.seen, 0)
+			var got []string
+			for _, rec := range recommendations {
+				got = append(got, rec.MediaID)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Recommend() mismatch (-want +got):\n%s", diff)
+			}
+			if len(recommendations) > 0 && recommendations[0].Because != tt.because {
+				t.Errorf("Recommend() because = %q, want %q", recommendations[0].Because, tt.because)
+			}
+		})
+	}
+}
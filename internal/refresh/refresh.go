@@ -0,0 +1,143 @@
+// Package refresh periodically updates the metadata of stale movies from OMDb
+package refresh
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// JobName identifies refresh runs in the job history
+const JobName = "refresh"
+
+const (
+	// staleAfter is how long a complete movie keeps its metadata
+	staleAfter = 30 * 24 * time.Hour
+	// recentStaleAfter applies to recent releases and movies without poster or ratings, which change more often
+	recentStaleAfter = 24 * time.Hour
+	// recentRelease is how long after its release a movie counts as recent
+	recentRelease = 180 * 24 * time.Hour
+)
+
+// Store is the part of the store used by the Refresher
+type Store interface {
+	GetStaleMovies(params api.StaleParams) ([]string, error)
+	UpdateMovie(*api.Movie) (*api.Movie, error)
+	CreateJobRun(run *api.JobRun) error
+	GetJobRequests(since time.Time) (int, error)
+}
+
+// Refresher updates stale movies in batches without exceeding a daily OMDb quota
+type Refresher struct {
+	store    Store
+	fetch    func(imdbID string) (*api.Movie, error)
+	interval time.Duration
+	quota    int
+	// OnRefresh is called with every successfully updated movie, e.g. to invalidate caches
+	OnRefresh func(*api.Movie)
+	now       func() time.Time
+}
+
+// NewRefresher returns a Refresher running every interval, fetching movies with fetch
+// the daily quota is spread evenly over all runs of a day
+func NewRefresher(store Store, fetch func(string) (*api.Movie, error), interval time.Duration, dailyQuota int) *Refresher {
+	return &Refresher{
+		store:    store,
+		fetch:    fetch,
+		interval: interval,
+		quota:    dailyQuota,
+		now:      time.Now,
+	}
+}
+
+// Name implements the server Job interface
+func (r *Refresher) Name() string {
+	return JobName
+}
+
+// Run refreshes a batch of movies right away and then once every interval until ctx is cancelled
+func (r *Refresher) Run(ctx context.Context) {
+	if r.quota <= 0 {
+		slog.Info("background refresh disabled", "job", JobName)
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		run := r.RunOnce(ctx)
+		slog.Info("background refresh finished", "job", JobName, "processed", run.Processed,
+			"failed", run.Failed, "requests", run.Requests, "err", run.Error)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce refreshes a single batch of stale movies and records the run
+func (r *Refresher) RunOnce(ctx context.Context) *api.JobRun {
+	run := &api.JobRun{Job: JobName, StartedAt: r.now()}
+	if err := r.refresh(ctx, run); err != nil {
+		run.Error = err.Error()
+	}
+	run.FinishedAt = r.now()
+	if err := r.store.CreateJobRun(run); err != nil {
+		slog.Error("could not record job run", "job", JobName, "err", err.Error())
+	}
+	return run
+}
+
+func (r *Refresher) refresh(ctx context.Context, run *api.JobRun) error {
+	limit, err := r.batchSize(run.StartedAt)
+	if err != nil {
+		return err
+	}
+	if limit == 0 {
+		return errors.New("daily OMDb quota used up")
+	}
+	ids, err := r.store.GetStaleMovies(api.StaleParams{
+		RefreshedBefore:       run.StartedAt.Add(-staleAfter),
+		RecentRefreshedBefore: run.StartedAt.Add(-recentStaleAfter),
+		ReleasedAfter:         run.StartedAt.Add(-recentRelease),
+		Limit:                 limit,
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		run.Requests++
+		mov, err := r.fetch(id)
+		if err == nil {
+			_, err = r.store.UpdateMovie(mov)
+		}
+		if err != nil {
+			run.Failed++
+			slog.Warn("could not refresh movie", "job", JobName, "id", id, "err", err.Error())
+			continue
+		}
+		run.Processed++
+		if r.OnRefresh != nil {
+			r.OnRefresh(mov)
+		}
+	}
+	return nil
+}
+
+// batchSize returns how many movies a run may refresh
+// each run gets its share of the daily quota, capped by what all jobs left of today's quota
+func (r *Refresher) batchSize(now time.Time) (int, error) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	used, err := r.store.GetJobRequests(day)
+	if err != nil {
+		return 0, err
+	}
+	perRun := max(1, int(int64(r.quota)*int64(r.interval)/int64(24*time.Hour)))
+	return max(0, min(perRun, r.quota-used)), nil
+}
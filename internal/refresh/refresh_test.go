@@ -0,0 +1,156 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+type fakeStore struct {
+	stale    []string
+	updated  []string
+	runs     []*api.JobRun
+	requests int
+}
+
+func (f *fakeStore) GetStaleMovies(params api.StaleParams) ([]string, error) {
+	return f.stale[:min(params.Limit, len(f.stale))], nil
+}
+
+func (f *fakeStore) UpdateMovie(m *api.Movie) (*api.Movie, error) {
+	f.updated = append(f.updated, m.ImdbID)
+	return m, nil
+}
+
+func (f *fakeStore) CreateJobRun(run *api.JobRun) error {
+	f.runs = append(f.runs, run)
+	f.requests += run.Requests
+	return nil
+}
+
+func (f *fakeStore) GetJobRequests(time.Time) (int, error) {
+	return f.requests, nil
+}
+
+func fetchMovie(id string) (*api.Movie, error) {
+	if id == "tt0000000" {
+		return nil, errors.New("movie not found")
+	}
+	return &api.Movie{ImdbID: id}, nil
+}
+
+func TestRefresher_RunOnce(t *testing.T) {
+	tests := []struct {
+		name        string
+		stale       []string
+		interval    time.Duration
+		quota       int
+		used        int
+		wantUpdated []string
+		wantFailed  int
+		wantErr     bool
+	}{
+		{
+			name:        "refreshes all stale movies within batch",
+			stale:       []string{"tt0078748", "tt0062622"},
+			interval:    time.Hour,
+			quota:       240,
+			wantUpdated: []string{"tt0078748", "tt0062622"},
+		},
+		{
+			name:        "batch is share of daily quota",
+			stale:       []string{"tt0078748", "tt0062622", "tt0111161"},
+			interval:    6 * time.Hour,
+			quota:       8,
+			wantUpdated: []string{"tt0078748", "tt0062622"},
+		},
+		{
+			name:        "batch is capped by remaining quota",
+			stale:       []string{"tt0078748", "tt0062622"},
+			interval:    24 * time.Hour,
+			quota:       10,
+			used:        9,
+			wantUpdated: []string{"tt0078748"},
+		},
+		{
+			name:     "quota used up",
+			stale:    []string{"tt0078748"},
+			interval: time.Hour,
+			quota:    10,
+			used:     10,
+			wantErr:  true,
+		},
+		{
+			name:        "failed fetch counts as request",
+			stale:       []string{"tt0000000", "tt0078748"},
+			interval:    time.Hour,
+			quota:       240,
+			wantUpdated: []string{"tt0078748"},
+			wantFailed:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{stale: tt.stale, requests: tt.used}
+			r := NewRefresher(store, fetchMovie, tt.interval, tt.quota)
+			var refreshed []string
+			r.OnRefresh = func(m *api.Movie) { refreshed = append(refreshed, m.ImdbID) }
+
+			run := r.RunOnce(context.Background())
+			if diff := cmp.Diff(tt.wantUpdated, store.updated); diff != "" {
+				t.Errorf("updated movies mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantUpdated, refreshed); diff != "" {
+				t.Errorf("OnRefresh mismatch (-want +got):\n%s", diff)
+			}
+			if run.Failed != tt.wantFailed {
+				t.Errorf("Failed = %d, want %d", run.Failed, tt.wantFailed)
+			}
+			if run.Requests != len(tt.wantUpdated)+tt.wantFailed {
+				t.Errorf("Requests = %d, want %d", run.Requests, len(tt.wantUpdated)+tt.wantFailed)
+			}
+			if (run.Error != "") != tt.wantErr {
+				t.Errorf("Error = %q, wantErr %v", run.Error, tt.wantErr)
+			}
+			if len(store.runs) != 1 {
+				t.Errorf("recorded %d runs, want 1", len(store.runs))
+			}
+		})
+	}
+}
+
+func TestRefresher_RunOnceCancelled(t *testing.T) {
+	store := &fakeStore{stale: []string{"tt0078748"}}
+	r := NewRefresher(store, fetchMovie, time.Hour, 240)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	run := r.RunOnce(ctx)
+	if len(store.updated) != 0 {
+		t.Errorf("updated %v after cancel", store.updated)
+	}
+	if run.Error != context.Canceled.Error() {
+		t.Errorf("Error = %q, want %q", run.Error, context.Canceled.Error())
+	}
+}
+
+func TestRefresher_RunStopsOnCancel(t *testing.T) {
+	store := &fakeStore{}
+	r := NewRefresher(store, fetchMovie, time.Hour, 240)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
@@ -59,13 +59,19 @@ func Authenticate() Middleware {
 				http.Redirect(w, r, "/login", http.StatusUnauthorized)
 				return
 			}
-			_, err = auth.VerifyToken(cookie.Value)
+			token, err := auth.VerifyToken(cookie.Value)
 			if err != nil {
 				slog.Warn("jwt not verified", "err", err.Error())
 				http.Redirect(w, r, "/login", http.StatusUnauthorized)
 				return
 			}
-			handlerFunc(w, r)
+			username, err := token.Claims.GetSubject()
+			if err != nil {
+				slog.Warn("jwt without subject", "err", err.Error())
+				http.Redirect(w, r, "/login", http.StatusUnauthorized)
+				return
+			}
+			handlerFunc(w, r.WithContext(auth.WithUser(r.Context(), username)))
 		}
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/jhachmer/gomovie/internal/handlers"
@@ -16,10 +17,18 @@ type Server struct {
 	Handler     *handlers.Handler
 	Mux         *http.ServeMux
 	RateLimiter *rate.RateLimiter
+	Jobs        []Job
+}
+
+// Job is a background task running alongside the server until the server context is cancelled
+type Job interface {
+	Name() string
+	Run(ctx context.Context)
 }
 
 // NewServer returns a new Server instance with given Address and Logger and Handler values
-func NewServer(addr string, handler *handlers.Handler) *Server {
+// jobs are started when the server starts serving
+func NewServer(addr string, handler *handlers.Handler, jobs ...Job) *Server {
 	mux := http.NewServeMux()
 
 	rateLimiter := rate.NewRateLimiter(100, time.Minute)
@@ -28,6 +37,7 @@ func NewServer(addr string, handler *handlers.Handler) *Server {
 		Handler:     handler,
 		Mux:         mux,
 		RateLimiter: rateLimiter,
+		Jobs:        jobs,
 	}
 	return svr
 }
@@ -51,15 +61,55 @@ func (svr *Server) setupRoutes() {
 	svr.Mux.HandleFunc("POST /films/{imdb}/entry", Chain(svr.Handler.CreateEntryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
 	svr.Mux.HandleFunc("PUT /films/{imdb}/entry", Chain(svr.Handler.UpdateEntryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
 	svr.Mux.HandleFunc("DELETE /films/{imdb}/entry", Chain(svr.Handler.DeleteEntryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /films/{imdb}/watch", Chain(svr.Handler.CreateWatchEventHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("DELETE /films/{imdb}/watch/{id}", Chain(svr.Handler.DeleteWatchEventHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /films/{imdb}/rating", Chain(svr.Handler.SetUserRatingHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /posters/{imdb}/{size}", Chain(svr.Handler.PosterHandler, Authenticate(), Logging()))
 	svr.Mux.HandleFunc("GET /overview", Chain(svr.Handler.HomeHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
 	svr.Mux.HandleFunc("GET /search", Chain(svr.Handler.SearchHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
 	svr.Mux.HandleFunc("GET /stats", Chain(svr.Handler.StatsHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /pick", Chain(svr.Handler.PickPageHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /pick", Chain(svr.Handler.PickHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /recommendations", Chain(svr.Handler.RecommendationsHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /polls", Chain(svr.Handler.PollsHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /polls", Chain(svr.Handler.CreatePollHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /polls/{token}", Chain(svr.Handler.PollHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /polls/{token}/vote", Chain(svr.Handler.VotePollHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /polls/{token}/close", Chain(svr.Handler.ClosePollHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /films/{imdb}/tags", Chain(svr.Handler.AddTagsHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("DELETE /films/{imdb}/tags/{tag}", Chain(svr.Handler.RemoveTagHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /collections", Chain(svr.Handler.CreateCollectionHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("DELETE /collections/{id}", Chain(svr.Handler.DeleteCollectionHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /searches", Chain(svr.Handler.CreateSavedSearchHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /searches/{id}", Chain(svr.Handler.SavedSearchHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("DELETE /searches/{id}", Chain(svr.Handler.DeleteSavedSearchHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /people/{id}", Chain(svr.Handler.PersonHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /countries/{id}", Chain(svr.Handler.CountryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /trash", Chain(svr.Handler.TrashHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /trash/films/{imdb}/restore", Chain(svr.Handler.RestoreMovieHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("DELETE /trash/films/{imdb}", Chain(svr.Handler.PurgeMovieHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("POST /trash/entries/{id}/restore", Chain(svr.Handler.RestoreEntryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("DELETE /trash/entries/{id}", Chain(svr.Handler.PurgeEntryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /export", Chain(svr.Handler.ExportHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
+	svr.Mux.HandleFunc("GET /events", Chain(svr.Handler.EventsHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /library", Chain(svr.Handler.GetLibraryHandler, Authenticate(), RateLimit(svr.RateLimiter), Logging()))
 	svr.Mux.HandleFunc("GET /check/{imdb}", Chain(svr.Handler.ContainsMovieHandler, RateLimit(svr.RateLimiter), Authenticate(), Logging()))
 
 	svr.Mux.HandleFunc("GET /admin", Chain(svr.Handler.AdminHandler, Logging()))
 	svr.Mux.HandleFunc("POST /admin_login", Chain(svr.Handler.AdminLoginHandler, Logging()))
 	svr.Mux.HandleFunc("GET /get_users", Chain(svr.Handler.GetUsersHandler, Logging()))
-	svr.Mux.HandleFunc("PUT /toggle_active", Chain(svr.Handler.ToggleActiveHandler, Logging()))
+	svr.Mux.HandleFunc("PUT /toggle_active", Chain(svr.Handler.ToggleActiveHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /job_runs", Chain(svr.Handler.GetJobRunsHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /backups", Chain(svr.Handler.GetBackupsHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("POST /backups", Chain(svr.Handler.CreateBackupHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("POST /backups/restore", Chain(svr.Handler.RestoreBackupHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /webhooks", Chain(svr.Handler.GetWebhooksHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("POST /webhooks", Chain(svr.Handler.CreateWebhookHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("DELETE /webhooks/{id}", Chain(svr.Handler.DeleteWebhookHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /webhooks/{id}/deliveries", Chain(svr.Handler.GetWebhookDeliveriesHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /people/aliases", Chain(svr.Handler.GetPersonAliasesHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("POST /people/merge", Chain(svr.Handler.MergePeopleHandler, Authenticate(), Logging()))
+	svr.Mux.HandleFunc("GET /audit", Chain(svr.Handler.GetAuditLogHandler, Authenticate(), Logging()))
 }
 
 // Serve calls setup functions and spins up the Server
@@ -72,6 +122,20 @@ func (svr *Server) Serve(ctx context.Context) error {
 		Addr:    svr.Addr,
 		Handler: copHandler,
 	}
+	server.RegisterOnShutdown(svr.Handler.StopStreams)
+
+	// jobs are stopped and awaited before Serve returns, also when the server fails
+	jobCtx, stopJobs := context.WithCancel(ctx)
+	var jobs sync.WaitGroup
+	defer jobs.Wait()
+	defer stopJobs()
+	for _, job := range svr.Jobs {
+		jobs.Go(func() {
+			slog.Info("job started", "job", job.Name())
+			job.Run(jobCtx)
+			slog.Info("job stopped", "job", job.Name())
+		})
+	}
 
 	errCh := make(chan error, 1)
 	defer close(errCh)
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// audit appends an entry for a change made by the actor of the store
+// it runs on the transaction of the change, so the entry is only kept if the change is
+func (s *SQLiteStorage) audit(db execer, action, target, mediaID string, before, after any) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec( /*sql*/ `
+		INSERT INTO audit_log (actor, action, target, media_id, before, after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?);
+		`, s.actor, action, target, mediaID, nullJSON(beforeJSON), nullJSON(afterJSON), timestamp(time.Now()))
+	return err
+}
+
+// GetAuditEntries returns the audit entries matching the filter, newest first
+func (s *SQLiteStorage) GetAuditEntries(filter api.AuditFilter) ([]*api.AuditEntry, error) {
+	filters := []string{}
+	args := []any{}
+	if filter.Actor != "" {
+		filters = append(filters, "lower(actor) = lower(?)")
+		args = append(args, filter.Actor)
+	}
+	if filter.MediaID != "" {
+		filters = append(filters, "media_id = ?")
+		args = append(args, filter.MediaID)
+	}
+	if !filter.From.IsZero() {
+		filters = append(filters, "created_at >= ?")
+		args = append(args, timestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		filters = append(filters, "created_at < ?")
+		args = append(args, timestamp(filter.To))
+	}
+
+	query := /*sql*/ `
+		SELECT id, actor, action, target, media_id, before, after, created_at
+		FROM audit_log
+		`
+	if len(filters) > 0 {
+		query += "WHERE " + strings.Join(filters, " AND ") + "\n"
+	}
+	query += "ORDER BY id DESC LIMIT ?;"
+	args = append(args, filter.Limit)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*api.AuditEntry{}
+	for rows.Next() {
+		var e api.AuditEntry
+		var before, after sql.NullString
+		err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Target, &e.MediaID, &before, &after, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if before.Valid {
+			e.Before = []byte(before.String)
+		}
+		if after.Valid {
+			e.After = []byte(after.String)
+		}
+		entries = append(entries, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// nullJSON stores missing and null values as NULL
+func nullJSON(data []byte) sql.NullString {
+	if len(data) == 0 || string(data) == "null" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// auditEntries returns entries as they are shown in the audit log, with the comment as text
+func auditEntries(entries []*api.Entry) []map[string]any {
+	if len(entries) == 0 {
+		return nil
+	}
+	states := make([]map[string]any, len(entries))
+	for i, e := range entries {
+		states[i] = map[string]any{"id": e.ID, "name": e.Name, "watched": e.Watched, "comment": string(e.Comment)}
+	}
+	return states
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// lastAudit returns the newest audit entry
+func lastAudit(t *testing.T, s *SQLiteStorage) *api.AuditEntry {
+	t.Helper()
+	entries, err := s.GetAuditEntries(api.AuditFilter{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("audit log is empty")
+	}
+	return entries[0]
+}
+
+func TestSQLiteStorage_audit(t *testing.T) {
+	s := newTestStore(t)
+	mov := testMovie(t, s, "tt0078748", "Alien")
+	alice := s.As("alice")
+	refresher := s.As(api.SystemActor("refresh"))
+
+	tests := []struct {
+		name       string
+		mutate     func() error
+		wantActor  string
+		wantAction string
+		wantTarget string
+		wantBefore bool
+	}{
+		{
+			name: "movie created",
+			mutate: func() error {
+				_, err := alice.CreateMovie(&api.Movie{ImdbID: "tt0090605", Title: "Aliens"})
+				return err
+			},
+			wantActor:  "alice",
+			wantAction: api.AuditMovieCreated,
+			wantTarget: "tt0090605",
+		},
+		{
+			name: "movie refreshed by job",
+			mutate: func() error {
+				_, err := refresher.UpdateMovie(&api.Movie{ImdbID: mov.ImdbID, Title: "Alien (1979)"})
+				return err
+			},
+			wantActor:  "system:refresh",
+			wantAction: api.AuditMovieUpdated,
+			wantTarget: mov.ImdbID,
+			wantBefore: true,
+		},
+		{
+			name: "rating set",
+			mutate: func() error {
+				return alice.SetUserRating(&api.UserRating{Username: "alice", MediaID: mov.ImdbID, Score: 4, RatedAt: time.Now()})
+			},
+			wantActor:  "alice",
+			wantAction: api.AuditRatingSet,
+			wantTarget: "alice",
+		},
+		{
+			name:       "rating deleted",
+			mutate:     func() error { return alice.DeleteUserRating("alice", mov.ImdbID) },
+			wantActor:  "alice",
+			wantAction: api.AuditRatingDeleted,
+			wantTarget: "alice",
+			wantBefore: true,
+		},
+		{
+			name:       "movie trashed",
+			mutate:     func() error { return alice.DeleteMedia(mov.ImdbID) },
+			wantActor:  "alice",
+			wantAction: api.AuditMovieTrashed,
+			wantTarget: mov.ImdbID,
+			wantBefore: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mutate(); err != nil {
+				t.Fatal(err)
+			}
+			got := lastAudit(t, s)
+			if got.Actor != tt.wantActor || got.Action != tt.wantAction || got.Target != tt.wantTarget {
+				t.Errorf("got %s %s %s, want %s %s %s", got.Actor, got.Action, got.Target, tt.wantActor, tt.wantAction, tt.wantTarget)
+			}
+			if (got.Before != nil) != tt.wantBefore {
+				t.Errorf("before = %s, want present %v", got.Before, tt.wantBefore)
+			}
+		})
+	}
+}
+
+func TestSQLiteStorage_auditFailedMutation(t *testing.T) {
+	s := newTestStore(t)
+	alice := s.As("alice")
+	if err := alice.CreateUser("bob", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	logged := countRows(t, s, "audit_log", "1")
+
+	if err := alice.CreateUser("bob", "secret"); err == nil {
+		t.Error("creating a user twice succeeded")
+	}
+	if err := alice.DeleteWatchEvent(42); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting an unknown viewing returned %v, want sql.ErrNoRows", err)
+	}
+	if n := countRows(t, s, "audit_log", "1"); n != logged {
+		t.Errorf("failed changes wrote %d audit entries", n-logged)
+	}
+}
+
+func TestSQLiteStorage_auditPurgeTrash(t *testing.T) {
+	s := newTestStore(t)
+	alien := testMovie(t, s, "tt0078748", "Alien")
+	aliens := testMovie(t, s, "tt0090605", "Aliens")
+	testEntry(t, s, alien, "alice")
+	testEntry(t, s, aliens, "alice")
+	if err := s.DeleteMedia(alien.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteEntry(aliens.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+
+	purger := s.As(api.SystemActor("trash"))
+	if _, err := purger.PurgeTrash(time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	where := "actor = 'system:trash' AND action = ? AND media_id = ?"
+	if n := countRows(t, s, "audit_log", where, api.AuditMoviePurged, alien.ImdbID); n != 1 {
+		t.Errorf("got %d audit entries for the purged movie, want 1", n)
+	}
+	if n := countRows(t, s, "audit_log", where, api.AuditEntryPurged, aliens.ImdbID); n != 1 {
+		t.Errorf("got %d audit entries for the purged entry, want 1", n)
+	}
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/ncruces/go-sqlite3/driver"
+)
+
+// ErrSchemaVersion is returned for snapshots written by a different version of gomovie
+var ErrSchemaVersion = errors.New("snapshot schema version does not match")
+
+// snapshotTables must exist in every snapshot
+var snapshotTables = []string{"useraccounts", "media", "entries", "watch_events", "user_ratings"}
+
+// Backup writes a consistent copy of the database to path while it stays usable
+// path must not exist yet
+func (s *SQLiteStorage) Backup(path string) error {
+	_, err := s.DB.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+// Restore replaces the content of the database with the snapshot at path after validating it
+// open connections see the restored content with their next query
+// tables added without a migration since the snapshot was written are created again afterwards
+// the restore is audited in the restored database, it cannot share a transaction with the restore itself
+func (s *SQLiteStorage) Restore(path string) error {
+	if err := ValidateSnapshot(path); err != nil {
+		return err
+	}
+	if err := s.restore(path); err != nil {
+		return err
+	}
+	if err := s.InitDatabaseTables(); err != nil {
+		return err
+	}
+	return s.audit(s.DB, api.AuditBackupRestored, filepath.Base(path), "", nil, nil)
+}
+
+func (s *SQLiteStorage) restore(path string) error {
+	conn, err := s.DB.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("restore is not supported by %T", driverConn)
+		}
+		return c.Raw().Restore("main", snapshotURI(path))
+	})
+}
+
+// ValidateSnapshot checks that the file at path is an intact gomovie database
+// with the schema version of this build, so it can be restored without migrations
+func ValidateSnapshot(path string) error {
+	db, err := sql.Open("sqlite3", snapshotURI(path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("could not read snapshot %s: %w", path, err)
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("%w: snapshot has version %d, database needs %d", ErrSchemaVersion, version, SchemaVersion)
+	}
+	var integrity string
+	if err := db.QueryRow("PRAGMA integrity_check(1)").Scan(&integrity); err != nil {
+		return fmt.Errorf("could not check snapshot %s: %w", path, err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("snapshot %s is damaged: %s", path, integrity)
+	}
+	for _, table := range snapshotTables {
+		var exists bool
+		err := db.QueryRow( /*sql*/ `
+			SELECT EXISTS(SELECT 1 FROM sqlite_schema WHERE type = 'table' AND name = ?);
+			`, table).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("snapshot %s has no table %s", path, table)
+		}
+	}
+	return nil
+}
+
+// snapshotURI opens path read-only, a missing file is an error instead of an empty database
+func snapshotURI(path string) string {
+	return (&url.URL{Scheme: "file", OmitHost: true, Path: path, RawQuery: "mode=ro"}).String()
+}
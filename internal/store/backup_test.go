@@ -0,0 +1,36 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStorage_Restore(t *testing.T) {
+	old := newTestStore(t)
+	testMovie(t, old, "tt0078748", "Alien")
+	path := filepath.Join(t.TempDir(), "snapshot.sqlite")
+	if err := old.Backup(path); err != nil {
+		t.Fatal(err)
+	}
+	// a snapshot of an older build, written before saved searches were added
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("DROP TABLE saved_searches"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s := newTestStore(t)
+	if err := s.Restore(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetMovieByID("tt0078748"); err != nil {
+		t.Errorf("restored movie: %v", err)
+	}
+	if _, err := s.GetSavedSearches("alice"); err != nil {
+		t.Errorf("saved searches after restore: %v", err)
+	}
+}
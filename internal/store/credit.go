@@ -0,0 +1,255 @@
+package store
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/util"
+)
+
+// nameList is a list of names of media, stored as a table of unique names and a join table
+type nameList struct {
+	table     string
+	joinTable string
+	column    string
+}
+
+var (
+	directorList = nameList{table: "people", joinTable: "media_directors", column: "person_id"}
+	writerList   = nameList{table: "people", joinTable: "media_writers", column: "person_id"}
+	languageList = nameList{table: "languages", joinTable: "media_languages", column: "language_id"}
+	countryList  = nameList{table: "countries", joinTable: "media_countries", column: "country_id"}
+)
+
+// creditNote matches notes OMDb appends to writers like "(screenplay)" or "(based on the novel by)"
+var creditNote = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// splitCredits splits a list given by OMDb into its names
+// notes and the "N/A" OMDb returns for missing values are dropped, duplicates are kept once
+func splitCredits(s string) []string {
+	var names []string
+	for _, name := range util.SplitIMDBString(creditNote.ReplaceAllString(s, "")) {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "N/A" || slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// storeCredits replaces the directors, writers, languages and countries of the movie
+func storeCredits(db execer, m *api.Movie) error {
+	for _, credit := range []struct {
+		list  nameList
+		value string
+	}{
+		{directorList, m.Director},
+		{writerList, m.Writer},
+		{languageList, m.Language},
+		{countryList, m.Country},
+	} {
+		if err := setMediaNames(db, credit.list, m.ImdbID, splitCredits(credit.value)); err != nil {
+			return err
+		}
+	}
+	// actors are people as well, so they can have a person page
+	_, err := db.Exec( /*sql*/ `
+		INSERT OR IGNORE INTO people (name)
+		SELECT a.name FROM actors a JOIN media_actors ma ON ma.actor_id = a.id
+		WHERE ma.media_id = ?;
+		`, m.ImdbID)
+	return err
+}
+
+// setMediaNames replaces the names of the media in the list, keeping the order of names
+func setMediaNames(db execer, l nameList, mediaID string, names []string) error {
+	if _, err := db.Exec(`DELETE FROM `+l.joinTable+` WHERE media_id = ?`, mediaID); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO `+l.table+` (name) VALUES (?)`, name); err != nil {
+			return err
+		}
+		_, err := db.Exec(`INSERT OR IGNORE INTO `+l.joinTable+` (media_id, `+l.column+`)
+			SELECT ?, id FROM `+l.table+` WHERE name = ?`, mediaID, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getMediaNames returns the ids and names of the media in the list in the order they were stored
+func (s *SQLiteStorage) getMediaNames(l nameList, mediaID string) ([]int64, []string, error) {
+	rows, err := s.DB.Query(`
+		SELECT n.id, n.name
+		FROM `+l.joinTable+` j
+		JOIN `+l.table+` n ON n.id = j.`+l.column+`
+		WHERE j.media_id = ?
+		ORDER BY j.rowid;
+		`, mediaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var names []string
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		names = append(names, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return ids, names, nil
+}
+
+// fillCredits sets director, writer, language and country of the movie from their tables
+// the director column is kept for media stored before directors had their own table
+func (s *SQLiteStorage) fillCredits(m *api.Movie) error {
+	for _, credit := range []struct {
+		list  nameList
+		value *string
+	}{
+		{directorList, &m.Director},
+		{writerList, &m.Writer},
+		{languageList, &m.Language},
+		{countryList, &m.Country},
+	} {
+		_, names, err := s.getMediaNames(credit.list, m.ImdbID)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			*credit.value = util.JoinIMDBStrings(names)
+		}
+	}
+	return nil
+}
+
+// GetMediaCredits returns the actors, directors, writers and countries of the media with their ids
+func (s *SQLiteStorage) GetMediaCredits(mediaID string) (*api.MediaCredits, error) {
+	var credits api.MediaCredits
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT p.id, p.name
+		FROM media_actors ma
+		JOIN actors a ON a.id = ma.actor_id
+		JOIN people p ON p.name = a.name
+		WHERE ma.media_id = ?
+		ORDER BY ma.rowid;
+		`, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p api.Person
+		if err := rows.Scan(&p.ID, &p.Name); err != nil {
+			return nil, err
+		}
+		credits.Actors = append(credits.Actors, &p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, people := range []struct {
+		list   nameList
+		people *[]*api.Person
+	}{
+		{directorList, &credits.Directors},
+		{writerList, &credits.Writers},
+	} {
+		ids, names, err := s.getMediaNames(people.list, mediaID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ids {
+			*people.people = append(*people.people, &api.Person{ID: ids[i], Name: names[i]})
+		}
+	}
+	ids, names, err := s.getMediaNames(countryList, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ids {
+		credits.Countries = append(credits.Countries, &api.Country{ID: ids[i], Name: names[i]})
+	}
+	return &credits, nil
+}
+
+// GetCountry returns the production country with id
+func (s *SQLiteStorage) GetCountry(id int64) (*api.Country, error) {
+	var c api.Country
+	err := s.DB.QueryRow(`SELECT id, name FROM countries WHERE id = ?`, id).Scan(&c.ID, &c.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCountryMovies returns the stored movies produced in the country
+func (s *SQLiteStorage) GetCountryMovies(countryID int64) ([]*api.MovieInfoData, error) {
+	return s.getListedMovies(countryList, countryID)
+}
+
+// getListedMovies returns the movies with the name of id in the list, newest first
+func (s *SQLiteStorage) getListedMovies(l nameList, id int64) ([]*api.MovieInfoData, error) {
+	rows, err := s.DB.Query(`
+		SELECT m.id
+		FROM media m
+		JOIN `+l.joinTable+` j ON j.media_id = m.id
+		WHERE j.`+l.column+` = ? AND m.media_type = 'movie' AND m.deleted_at IS NULL
+		ORDER BY m.year DESC, m.title;
+		`, id)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	movies := []*api.MovieInfoData{}
+	for _, id := range ids {
+		mov, err := s.GetMovieByID(id)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := s.GetEntries(id)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, &api.MovieInfoData{Movie: mov, Entry: entries})
+	}
+	return movies, nil
+}
+
+// likeAny returns a filter for media with any name in the list like one of names
+func likeAny(l nameList, names []string, args *[]any) string {
+	placeholders := make([]string, len(names))
+	for i, name := range names {
+		placeholders[i] = "n.name LIKE ?"
+		*args = append(*args, "%"+name+"%")
+	}
+	return `m.id IN (
+		SELECT j.media_id FROM ` + l.joinTable + ` j JOIN ` + l.table + ` n ON n.id = j.` + l.column + `
+		WHERE ` + strings.Join(placeholders, " OR ") + `)`
+}
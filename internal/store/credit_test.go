@@ -0,0 +1,199 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func Test_splitCredits(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{name: "names", s: "Lana Wachowski, Lilly Wachowski", want: []string{"Lana Wachowski", "Lilly Wachowski"}},
+		{name: "notes", s: "Dan O'Bannon (screenplay by), Ronald Shusett (story by)", want: []string{"Dan O'Bannon", "Ronald Shusett"}},
+		{name: "duplicates after notes", s: "Stephen King (novel), Stephen King (screenplay)", want: []string{"Stephen King"}},
+		{name: "not available", s: "N/A", want: nil},
+		{name: "not available among names", s: "Ridley Scott, N/A", want: []string{"Ridley Scott"}},
+		{name: "empty", s: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, splitCredits(tt.s)); diff != "" {
+				t.Errorf("splitCredits() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// creditNames returns the names of people and countries of the credits
+func creditNames(credits *api.MediaCredits) map[string][]string {
+	names := make(map[string][]string)
+	for kind, people := range map[string][]*api.Person{"actors": credits.Actors, "directors": credits.Directors, "writers": credits.Writers} {
+		for _, p := range people {
+			names[kind] = append(names[kind], p.Name)
+		}
+	}
+	for _, c := range credits.Countries {
+		names["countries"] = append(names["countries"], c.Name)
+	}
+	return names
+}
+
+func TestSQLiteStorage_GetMediaCredits(t *testing.T) {
+	s := newTestStore(t)
+	mov := &api.Movie{
+		ImdbID: "tt0133093", Title: "The Matrix", Year: "1999", Type: "movie",
+		Actors:   "Keanu Reeves, Laurence Fishburne, Carrie-Anne Moss",
+		Director: "Lana Wachowski, Lilly Wachowski",
+		Writer:   "Lilly Wachowski, Lana Wachowski",
+		Country:  "United States, Australia",
+		Language: "English",
+	}
+	if _, err := s.CreateMovie(mov); err != nil {
+		t.Fatal(err)
+	}
+	credits, err := s.GetMediaCredits(mov.ImdbID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// names keep the order OMDb lists them in
+	want := map[string][]string{
+		"actors":    {"Keanu Reeves", "Laurence Fishburne", "Carrie-Anne Moss"},
+		"directors": {"Lana Wachowski", "Lilly Wachowski"},
+		"writers":   {"Lilly Wachowski", "Lana Wachowski"},
+		"countries": {"United States", "Australia"},
+	}
+	if diff := cmp.Diff(want, creditNames(credits)); diff != "" {
+		t.Errorf("credits mismatch (-want +got):\n%s", diff)
+	}
+
+	mov.Writer = "N/A"
+	mov.Country = "Australia, United States"
+	if _, err := s.UpdateMovie(mov); err != nil {
+		t.Fatal(err)
+	}
+	if credits, err = s.GetMediaCredits(mov.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	got := creditNames(credits)
+	if len(got["writers"]) != 0 {
+		t.Errorf("writers after update = %v, want none", got["writers"])
+	}
+	if diff := cmp.Diff([]string{"Australia", "United States"}, got["countries"]); diff != "" {
+		t.Errorf("countries after update mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSQLiteStorage_SearchMovieCredits(t *testing.T) {
+	s := newTestStore(t)
+	for _, mov := range []*api.Movie{
+		{ImdbID: "tt0133093", Title: "The Matrix", Year: "1999", Type: "movie", Writer: "Lilly Wachowski, Lana Wachowski", Country: "United States, Australia"},
+		{ImdbID: "tt0078748", Title: "Alien", Year: "1979", Type: "movie", Writer: "Dan O'Bannon (screenplay by), Ronald Shusett (story by)", Country: "United Kingdom, United States"},
+		{ImdbID: "tt0120737", Title: "The Fellowship of the Ring", Year: "2001", Type: "movie", Writer: "J.R.R. Tolkien (novel)", Country: "New Zealand, United States"},
+	} {
+		if _, err := s.CreateMovie(mov); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name   string
+		params api.SearchParams
+		want   []string
+	}{
+		{name: "country", params: api.SearchParams{Countries: []string{"Australia"}}, want: []string{"tt0133093"}},
+		{name: "any of the countries", params: api.SearchParams{Countries: []string{"Kingdom", "Zealand"}}, want: []string{"tt0078748", "tt0120737"}},
+		{name: "writer without note", params: api.SearchParams{Writers: []string{"Dan O'Bannon"}}, want: []string{"tt0078748"}},
+		{name: "notes are not searched", params: api.SearchParams{Writers: []string{"novel"}}, want: nil},
+		{name: "writer and country", params: api.SearchParams{Writers: []string{"Wachowski"}, Countries: []string{"United Kingdom"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := s.SearchMovie(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Movie.ImdbID)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("SearchMovie() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("country page", func(t *testing.T) {
+		var id int64
+		if err := s.DB.QueryRow(`SELECT id FROM countries WHERE name = 'United States'`).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		movies, err := s.GetCountryMovies(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range movies {
+			got = append(got, m.Movie.Title)
+		}
+		// newest first
+		want := []string{"The Fellowship of the Ring", "The Matrix", "Alien"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("GetCountryMovies() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestSQLiteStorage_migrateDirectors(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSQLiteStore(db)
+	t.Cleanup(func() { s.Close() })
+	// a database at schema version 4, before directors had their own table
+	all := migrations
+	migrations = migrations[:4]
+	err = s.InitDatabaseTables()
+	migrations = all
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, director := range map[string]string{
+		"tt0133093": "Lana Wachowski, Lilly Wachowski",
+		"tt0078748": "Ridley Scott",
+		"tt0000001": "N/A",
+	} {
+		_, err := db.Exec(`INSERT INTO media (id, title, year, director, rated, released, plot, poster, media_type)
+			VALUES (?, ?, '1999', ?, '', '', '', '', 'movie')`, id, id, director)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.migrate(); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string][]string{
+		"tt0133093": {"Lana Wachowski", "Lilly Wachowski"},
+		"tt0078748": {"Ridley Scott"},
+		"tt0000001": nil,
+	} {
+		_, got, err := s.getMediaNames(directorList, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("directors of %s mismatch (-want +got):\n%s", id, diff)
+		}
+	}
+	if n := countRows(t, s, "people", "name = 'N/A'"); n != 0 {
+		t.Errorf("got %d people named N/A, want none", n)
+	}
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// CreateEvent stores an event and queues a delivery for every active webhook subscribed to its type
+func (s *SQLiteStorage) CreateEvent(e *api.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		INSERT INTO events (type, username, media_id, title, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?);
+		`, e.Type, e.Username, e.MediaID, e.Title, string(data), timestamp(e.CreatedAt))
+	if err != nil {
+		return err
+	}
+	if e.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, status, next_attempt_at, created_at)
+		SELECT id, ?, ?, ?, ?
+		FROM webhooks
+		WHERE active = 1 AND (events = '' OR ',' || events || ',' LIKE '%,' || ? || ',%');
+		`, e.ID, api.DeliveryPending, timestamp(e.CreatedAt), timestamp(e.CreatedAt), e.Type)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEventsAfter returns up to limit events with an id greater than id, oldest first
+func (s *SQLiteStorage) GetEventsAfter(id int64, limit int) ([]*api.Event, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id, type, username, media_id, title, data, created_at
+		FROM events
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?;
+		`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*api.Event
+	for rows.Next() {
+		var e api.Event
+		var data string
+		if err := rows.Scan(&e.ID, &e.Type, &e.Username, &e.MediaID, &e.Title, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CreateWebhook stores a webhook, its secret is left out of the audit log
+func (s *SQLiteStorage) CreateWebhook(hook *api.Webhook) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		INSERT INTO webhooks (url, secret, events, active, created_at)
+		VALUES (?, ?, ?, ?, ?);
+		`, hook.URL, hook.Secret, strings.Join(hook.Events, ","), hook.Active, timestamp(hook.CreatedAt))
+	if err != nil {
+		return err
+	}
+	if hook.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	logged := *hook
+	logged.Secret = ""
+	if err := s.audit(tx, api.AuditWebhookCreated, strconv.FormatInt(hook.ID, 10), "", nil, logged); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetWebhooks returns all webhooks without their secrets
+func (s *SQLiteStorage) GetWebhooks() ([]*api.Webhook, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id, url, events, active, created_at
+		FROM webhooks
+		ORDER BY id;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*api.Webhook
+	for rows.Next() {
+		var hook api.Webhook
+		var events string
+		if err := rows.Scan(&hook.ID, &hook.URL, &events, &hook.Active, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		hook.Events = splitEvents(events)
+		hooks = append(hooks, &hook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// DeleteWebhook removes a webhook together with its queued deliveries and delivery log
+func (s *SQLiteStorage) DeleteWebhook(id int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var before api.Webhook
+	var events string
+	err = tx.QueryRow(`SELECT id, url, events, active, created_at FROM webhooks WHERE id = ?`, id).
+		Scan(&before.ID, &before.URL, &events, &before.Active, &before.CreatedAt)
+	if err != nil {
+		return err
+	}
+	before.Events = splitEvents(events)
+	if _, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditWebhookDeleted, strconv.FormatInt(id, 10), "", before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due, oldest first,
+// together with the webhook and event needed to send them
+func (s *SQLiteStorage) GetDueDeliveries(now time.Time, limit int) ([]*api.WebhookDelivery, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT d.id, d.webhook_id, d.event_id, d.status, d.attempts, d.next_attempt_at, d.status_code, d.error, d.created_at,
+			w.url, w.secret,
+			e.type, e.username, e.media_id, e.title, e.data, e.created_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		JOIN events e ON e.id = d.event_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?;
+		`, api.DeliveryPending, timestamp(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*api.WebhookDelivery
+	for rows.Next() {
+		var d api.WebhookDelivery
+		var e api.Event
+		var data string
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.StatusCode, &d.Error, &d.CreatedAt,
+			&d.URL, &d.Secret,
+			&e.Type, &e.Username, &e.MediaID, &e.Title, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, err
+		}
+		e.ID = d.EventID
+		d.EventType = e.Type
+		d.Event = &e
+		deliveries = append(deliveries, &d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt
+func (s *SQLiteStorage) UpdateDelivery(d *api.WebhookDelivery) error {
+	var deliveredAt sql.NullString
+	if d.DeliveredAt != nil {
+		deliveredAt = sql.NullString{String: timestamp(*d.DeliveredAt), Valid: true}
+	}
+	_, err := s.DB.Exec( /*sql*/ `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, status_code = ?, error = ?, delivered_at = ?
+		WHERE id = ?;
+		`, d.Status, d.Attempts, timestamp(d.NextAttemptAt), d.StatusCode, d.Error, deliveredAt, d.ID)
+	return err
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first
+func (s *SQLiteStorage) GetDeliveries(webhookID int64, limit int) ([]*api.WebhookDelivery, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT d.id, d.webhook_id, d.event_id, e.type, d.status, d.attempts, d.next_attempt_at,
+			d.status_code, d.error, d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		WHERE d.webhook_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?;
+		`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*api.WebhookDelivery
+	for rows.Next() {
+		var d api.WebhookDelivery
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.StatusCode, &d.Error, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, &d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func splitEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+	return strings.Split(events, ",")
+}
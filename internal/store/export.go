@@ -0,0 +1,80 @@
+package store
+
+import (
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// exportPageSize is the number of movie ids read per query while exporting
+const exportPageSize = 100
+
+// ExportMovies calls fn for every movie ordered by id, stopping at the first error
+// ids are read page by page, so only a single page and record are held in memory
+func (s *SQLiteStorage) ExportMovies(fn func(*api.ExportRecord) error) error {
+	after := ""
+	for {
+		ids, err := s.movieIDsAfter(after)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			record, err := s.exportRecord(id)
+			if err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		if len(ids) < exportPageSize {
+			return nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+func (s *SQLiteStorage) movieIDsAfter(after string) ([]string, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id
+		FROM media
+		WHERE media_type = 'movie' AND deleted_at IS NULL AND id > ?
+		ORDER BY id
+		LIMIT ?;
+		`, after, exportPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SQLiteStorage) exportRecord(id string) (*api.ExportRecord, error) {
+	mov, err := s.GetMovieByID(id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.GetEntries(id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.GetWatchEvents(id)
+	if err != nil {
+		return nil, err
+	}
+	ratings, err := s.GetUserRatings(id)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ExportRecord{Movie: mov, Entries: entries, WatchEvents: events, Ratings: ratings}, nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// updateRatings stores the latest ratings of the media
+// a snapshot is appended for every source whose value changed or which was not rated before
+func updateRatings(tx *sql.Tx, m api.Media) error {
+	for _, rating := range m.GetRatings() {
+		var current string
+		err := tx.QueryRow( /*sql*/ `
+			SELECT value
+			FROM ratings
+			WHERE media_id = ? AND source = ?;
+			`, m.GetID(), rating.Source).Scan(&current)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			_, err = tx.Exec( /*sql*/ `
+				INSERT INTO ratings (media_id, source, value, score)
+				VALUES (?, ?, ?, ?);
+				`, m.GetID(), rating.Source, rating.Value, rating.Score)
+		case err != nil:
+			return err
+		case current == rating.Value:
+			continue
+		default:
+			_, err = tx.Exec( /*sql*/ `
+				UPDATE ratings
+				SET value = ?, score = ?, timestamp = CURRENT_TIMESTAMP
+				WHERE media_id = ? AND source = ?;
+				`, rating.Value, rating.Score, m.GetID(), rating.Source)
+		}
+		if err != nil {
+			return err
+		}
+		if err := insertRatingSnapshot(tx, m.GetID(), rating); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertRatingSnapshot(db execer, mediaID string, r api.Rating) error {
+	_, err := db.Exec( /*sql*/ `
+		INSERT INTO rating_snapshots (media_id, source, value, score, taken_at)
+		VALUES (?, ?, ?, ?, ?);
+		`, mediaID, r.Source, r.Value, r.Score, timestamp(time.Now()))
+	return err
+}
+
+// GetRatingHistory returns all rating snapshots of the media, oldest first
+func (s *SQLiteStorage) GetRatingHistory(mediaID string) ([]*api.RatingSnapshot, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT source, value, score, taken_at
+		FROM rating_snapshots
+		WHERE media_id = ?
+		ORDER BY taken_at, id;
+		`, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*api.RatingSnapshot
+	for rows.Next() {
+		snapshot := api.RatingSnapshot{MediaID: mediaID}
+		if err := rows.Scan(&snapshot.Source, &snapshot.Value, &snapshot.Score, &snapshot.TakenAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
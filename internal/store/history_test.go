@@ -0,0 +1,36 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func TestSQLiteStorage_GetRatingHistory(t *testing.T) {
+	s := newTestStore(t)
+	mov := &api.Movie{ImdbID: "tt0078748", Title: "Alien", Type: "movie", Ratings: []api.Rating{{Source: "Metacritic", Value: "89/100"}}}
+	if _, err := s.CreateMovie(mov); err != nil {
+		t.Fatal(err)
+	}
+	// snapshots written by the migration use the format of CURRENT_TIMESTAMP
+	if _, err := s.DB.Exec(`INSERT INTO rating_snapshots (media_id, source, value, taken_at) VALUES (?, 'Metacritic', '90/100', ?)`,
+		mov.ImdbID, time.Now().UTC().Add(time.Hour).Format(time.DateTime)); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, s, "rating_snapshots", "taken_at LIKE '%T%'"); n != 0 {
+		t.Errorf("got %d snapshots with an RFC 3339 timestamp, want all in the format of CURRENT_TIMESTAMP", n)
+	}
+
+	history, err := s.GetRatingHistory(mov.ImdbID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for _, snapshot := range history {
+		values = append(values, snapshot.Value)
+	}
+	if len(values) != 2 || values[0] != "89/100" || values[1] != "90/100" {
+		t.Errorf("got snapshots %v, want 89/100 then 90/100", values)
+	}
+}
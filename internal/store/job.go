@@ -0,0 +1,102 @@
+package store
+
+import (
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// timestamp formats t like CURRENT_TIMESTAMP so both can be compared in queries
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
+
+// GetStaleMovies returns the ids of movies due for a refresh, most urgent first
+// movies without poster or ratings come first, then recent releases, then the ones refreshed longest ago
+func (s *SQLiteStorage) GetStaleMovies(params api.StaleParams) ([]string, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id
+		FROM (
+			SELECT id, refreshed_at,
+				poster = 'N/A' OR NOT EXISTS (SELECT 1 FROM ratings r WHERE r.media_id = m.id) AS incomplete,
+				COALESCE(released_at >= ?, 0) AS recent
+			FROM media m
+			WHERE media_type = 'movie' AND deleted_at IS NULL
+		)
+		WHERE refreshed_at IS NULL
+			OR refreshed_at < ?
+			OR ((incomplete OR recent) AND refreshed_at < ?)
+		ORDER BY incomplete DESC, recent DESC, refreshed_at
+		LIMIT ?;
+		`, params.ReleasedAfter.Format(dateLayout), timestamp(params.RefreshedBefore),
+		timestamp(params.RecentRefreshedBefore), params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SQLiteStorage) CreateJobRun(run *api.JobRun) error {
+	res, err := s.DB.Exec( /*sql*/ `
+		INSERT INTO job_runs (job, started_at, finished_at, processed, failed, requests, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?);
+		`, run.Job, timestamp(run.StartedAt), timestamp(run.FinishedAt), run.Processed, run.Failed, run.Requests, run.Error)
+	if err != nil {
+		return err
+	}
+	run.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetLastJobRuns returns the latest run of every job
+func (s *SQLiteStorage) GetLastJobRuns() ([]*api.JobRun, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id, job, started_at, finished_at, processed, failed, requests, error
+		FROM job_runs
+		WHERE id IN (SELECT MAX(id) FROM job_runs GROUP BY job)
+		ORDER BY job;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*api.JobRun
+	for rows.Next() {
+		var run api.JobRun
+		if err := rows.Scan(&run.ID, &run.Job, &run.StartedAt, &run.FinishedAt,
+			&run.Processed, &run.Failed, &run.Requests, &run.Error); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetJobRequests returns how many OMDb requests the runs of all jobs started since the given time sent
+// the jobs share the daily OMDb quota
+func (s *SQLiteStorage) GetJobRequests(since time.Time) (int, error) {
+	var requests int
+	err := s.DB.QueryRow( /*sql*/ `
+		SELECT COALESCE(SUM(requests), 0)
+		FROM job_runs
+		WHERE started_at >= ?;
+		`, timestamp(since)).Scan(&requests)
+	return requests, err
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// GetLibraryItems returns all files found in the media library ordered by path
+func (s *SQLiteStorage) GetLibraryItems() ([]*api.LibraryItem, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT path, COALESCE(media_id, ''), title, year, status, reason, size, mod_time, scanned_at
+		FROM library_items
+		ORDER BY path;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*api.LibraryItem
+	for rows.Next() {
+		var item api.LibraryItem
+		if err := rows.Scan(&item.Path, &item.MediaID, &item.Title, &item.Year, &item.Status,
+			&item.Reason, &item.Size, &item.ModTime, &item.ScannedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SaveLibraryItem stores a scanned file, replacing an earlier scan of the same path
+// a matched movie is created if needed and added to the library list once
+func (s *SQLiteStorage) SaveLibraryItem(item *api.LibraryItem, movie *api.Movie) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var mediaID sql.NullString
+	if movie != nil {
+		item.MediaID = movie.ImdbID
+		mediaID = sql.NullString{String: movie.ImdbID, Valid: true}
+		var listed bool
+		err := tx.QueryRow( /*sql*/ `
+			SELECT EXISTS(SELECT 1 FROM entries WHERE name = ? AND media_id = ?);
+			`, api.LibraryList, movie.ImdbID).Scan(&listed)
+		if err != nil {
+			return err
+		}
+		if !listed {
+			entry := api.NewEntry(api.LibraryList, false, "")
+			if _, err := s.CreateEntryTx(tx, entry, movie); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = tx.Exec( /*sql*/ `
+		INSERT INTO library_items (path, media_id, title, year, status, reason, size, mod_time, scanned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (path)
+		DO UPDATE SET media_id = excluded.media_id, title = excluded.title, year = excluded.year,
+			status = excluded.status, reason = excluded.reason, size = excluded.size,
+			mod_time = excluded.mod_time, scanned_at = excluded.scanned_at;
+		`, item.Path, mediaID, item.Title, item.Year, item.Status, item.Reason, item.Size,
+		timestamp(item.ModTime), timestamp(item.ScannedAt))
+	if err != nil {
+		return err
+	}
+	if err := removeUnusedLibraryEntries(tx); err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditLibrarySaved, item.Path, item.MediaID, nil, item); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteLibraryItem removes a file which is no longer in the media library
+// its movie leaves the library list when no other file refers to it
+func (s *SQLiteStorage) DeleteLibraryItem(path string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var mediaID sql.NullString
+	err = tx.QueryRow(`DELETE FROM library_items WHERE path = ? RETURNING media_id`, path).Scan(&mediaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := removeUnusedLibraryEntries(tx); err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditLibraryDeleted, path, mediaID.String, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// removeUnusedLibraryEntries deletes library list entries of movies without a matched file
+func removeUnusedLibraryEntries(tx *sql.Tx) error {
+	_, err := tx.Exec( /*sql*/ `
+		DELETE FROM entries
+		WHERE name = ? AND media_id NOT IN (
+			SELECT media_id FROM library_items WHERE status = ? AND media_id IS NOT NULL
+		);
+		`, api.LibraryList, api.LibraryMatched)
+	return err
+}
+
+// GetLocalMediaIDs returns the ids of all movies with a file in the media library
+func (s *SQLiteStorage) GetLocalMediaIDs() (map[string]bool, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT DISTINCT media_id
+		FROM library_items
+		WHERE status = ? AND media_id IS NOT NULL;
+		`, api.LibraryMatched)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
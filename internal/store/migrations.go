@@ -0,0 +1,234 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// migrations change existing data after InitDatabaseTables created all tables
+// the index of the last applied migration plus one is kept in PRAGMA user_version,
+// so new migrations must only ever be appended
+var migrations = []func(tx *sql.Tx) error{
+	migrateWatchedFlagToEvents,
+	migrateTypedMediaColumns,
+	migrateRatingSnapshots,
+	migrateRefreshedAt,
+	migrateDirectors,
+	migrateActorPeople,
+	migrateMediaTrash,
+	migrateEntriesTrash,
+	migrateEntriesTrashBatch,
+}
+
+// SchemaVersion is the user_version of a database with all migrations applied
+var SchemaVersion = len(migrations)
+
+func (s *SQLiteStorage) migrate() error {
+	var version int
+	if err := s.DB.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("could not read schema version: %w", err)
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.DB.Begin()
+		if err != nil {
+			return err
+		}
+		if err := migrations[i](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		slog.Info("applied database migration", "version", i+1)
+	}
+	return nil
+}
+
+// migrateWatchedFlagToEvents creates an undated watch event for every entry marked as watched
+func migrateWatchedFlagToEvents(tx *sql.Tx) error {
+	_, err := tx.Exec( /*sql*/ `
+		INSERT INTO watch_events (username, media_id, watched_at, note)
+		SELECT name, media_id, NULL, ''
+		FROM entries
+		WHERE watched = 1;
+		`)
+	return err
+}
+
+// migrateTypedMediaColumns adds typed columns for values OMDb returns as strings
+// and fills them by parsing the strings already stored
+func migrateTypedMediaColumns(tx *sql.Tx) error {
+	for _, stmt := range []string{
+		`ALTER TABLE media ADD COLUMN runtime_minutes INTEGER`,
+		`ALTER TABLE media ADD COLUMN released_at DATE`,
+		`ALTER TABLE media ADD COLUMN box_office VARCHAR(50) NOT NULL DEFAULT 'N/A'`,
+		`ALTER TABLE media ADD COLUMN box_office_cents INTEGER`,
+		`ALTER TABLE ratings ADD COLUMN score REAL`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	var movies []*api.Movie
+	rows, err := tx.Query(`SELECT id, runtime, released FROM media`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var m api.Movie
+		var runtime sql.NullString
+		if err := rows.Scan(&m.ImdbID, &runtime, &m.Released); err != nil {
+			rows.Close()
+			return err
+		}
+		m.Runtime = runtime.String
+		movies = append(movies, &m)
+	}
+	rows.Close()
+	for _, m := range movies {
+		m.Normalise()
+		_, err := tx.Exec(`UPDATE media SET runtime_minutes = ?, released_at = ? WHERE id = ?`,
+			nullInt(int64(m.RuntimeMinutes)), nullDate(m.ReleasedAt), m.ImdbID)
+		if err != nil {
+			return err
+		}
+	}
+
+	scores := make(map[int64]*float64)
+	rows, err = tx.Query(`SELECT id, value FROM ratings`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var r api.Rating
+		if err := rows.Scan(&id, &r.Value); err != nil {
+			rows.Close()
+			return err
+		}
+		r.Normalise()
+		scores[id] = r.Score
+	}
+	rows.Close()
+	for id, score := range scores {
+		if _, err := tx.Exec(`UPDATE ratings SET score = ? WHERE id = ?`, score, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateRatingSnapshots records the current ratings as the first snapshot of every source
+func migrateRatingSnapshots(tx *sql.Tx) error {
+	_, err := tx.Exec( /*sql*/ `
+		INSERT INTO rating_snapshots (media_id, source, value, score, taken_at)
+		SELECT media_id, source, value, score, timestamp
+		FROM ratings;
+		`)
+	return err
+}
+
+// migrateRefreshedAt tracks when media was last fetched from OMDb
+// existing media counts as refreshed when its ratings were last written
+func migrateRefreshedAt(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE media ADD COLUMN refreshed_at TIMESTAMP`); err != nil {
+		return err
+	}
+	_, err := tx.Exec( /*sql*/ `
+		UPDATE media
+		SET refreshed_at = (SELECT MAX(timestamp) FROM ratings WHERE ratings.media_id = media.id);
+		`)
+	return err
+}
+
+// migrateDirectors fills the directors table from the director column of stored media
+// writers, languages and countries were not stored before and are filled when media is refreshed
+func migrateDirectors(tx *sql.Tx) error {
+	directors := make(map[string]string)
+	rows, err := tx.Query(`SELECT id, director FROM media`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, director string
+		if err := rows.Scan(&id, &director); err != nil {
+			rows.Close()
+			return err
+		}
+		directors[id] = director
+	}
+	rows.Close()
+	for id, director := range directors {
+		if err := setMediaNames(tx, directorList, id, splitCredits(director)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateActorPeople adds every stored actor to people so actors have a person page
+func migrateActorPeople(tx *sql.Tx) error {
+	_, err := tx.Exec(`INSERT OR IGNORE INTO people (name) SELECT name FROM actors`)
+	return err
+}
+
+// migrateMediaTrash lets deleted media stay in the trash until it is restored or purged
+func migrateMediaTrash(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE media ADD COLUMN deleted_at TIMESTAMP`)
+	return err
+}
+
+// migrateEntriesTrash rebuilds entries so they can be kept in the trash and are removed with their media,
+// entries was declared ON DELETE SET NULL on a NOT NULL column, entries of already removed media are dropped
+func migrateEntriesTrash(tx *sql.Tx) error {
+	for _, stmt := range []string{
+		`--sql
+		CREATE TABLE entries_new (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL,
+		watched INTEGER DEFAULT 0,
+		comment TEXT,
+		media_id VARCHAR(9) NOT NULL,
+		deleted_at TIMESTAMP,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE);
+		`,
+		`--sql
+		INSERT INTO entries_new (id, name, watched, comment, media_id)
+		SELECT id, name, watched, comment, media_id
+		FROM entries
+		WHERE media_id IN (SELECT id FROM media);
+		`,
+		`DROP TABLE entries`,
+		`ALTER TABLE entries_new RENAME TO entries`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateEntriesTrashBatch marks the trashed entries which were deleted together with their media,
+// so restoring the media does not bring back entries trashed on their own at the same time
+func migrateEntriesTrashBatch(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE entries ADD COLUMN deleted_with_media INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		UPDATE entries
+		SET deleted_with_media = 1
+		WHERE deleted_at = (SELECT m.deleted_at FROM media m WHERE m.id = entries.media_id);
+		`)
+	return err
+}
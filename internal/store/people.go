@@ -0,0 +1,208 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/util"
+)
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// resolveAliases replaces merged names among actors, directors and writers of the movie by the name of their person,
+// so media refreshed from OMDb stays credited to the merged person
+func resolveAliases(db queryer, m *api.Movie) error {
+	rows, err := db.Query( /*sql*/ `
+		SELECT pa.alias, p.name
+		FROM person_aliases pa
+		JOIN people p ON p.id = pa.person_id;
+		`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, name string
+		if err := rows.Scan(&alias, &name); err != nil {
+			return err
+		}
+		aliases[alias] = name
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+	m.Actors = canonicalNames(aliases, m.Actors)
+	m.Director = canonicalNames(aliases, m.Director)
+	m.Writer = canonicalNames(aliases, m.Writer)
+	return nil
+}
+
+// canonicalNames replaces the aliases in a list given by OMDb, notes of replaced names are dropped
+func canonicalNames(aliases map[string]string, s string) string {
+	names := util.SplitIMDBString(s)
+	for i, name := range names {
+		if canonical, ok := aliases[strings.TrimSpace(creditNote.ReplaceAllString(name, ""))]; ok {
+			names[i] = canonical
+		}
+	}
+	return util.JoinIMDBStrings(names)
+}
+
+// GetPerson returns the person with id
+func (s *SQLiteStorage) GetPerson(id int64) (*api.Person, error) {
+	var p api.Person
+	err := s.DB.QueryRow(`SELECT id, name FROM people WHERE id = ?`, id).Scan(&p.ID, &p.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetPersonByName returns the person with the exact name
+func (s *SQLiteStorage) GetPersonByName(name string) (*api.Person, error) {
+	var p api.Person
+	err := s.DB.QueryRow(`SELECT id, name FROM people WHERE name = ?`, name).Scan(&p.ID, &p.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetPersonFilms returns the stored movies the person acted in, directed or wrote, newest first
+func (s *SQLiteStorage) GetPersonFilms(personID int64) ([]*api.PersonFilm, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		WITH credits (media_id, role, ord) AS (
+			SELECT ma.media_id, 'Actor', 1
+			FROM media_actors ma
+			JOIN actors a ON a.id = ma.actor_id
+			JOIN people p ON p.name = a.name
+			WHERE p.id = ?
+			UNION ALL
+			SELECT media_id, 'Director', 2 FROM media_directors WHERE person_id = ?
+			UNION ALL
+			SELECT media_id, 'Writer', 3 FROM media_writers WHERE person_id = ?
+		)
+		SELECT m.id, m.title, m.year,
+			(SELECT group_concat(role, '|') FROM (SELECT role FROM credits c2 WHERE c2.media_id = m.id ORDER BY ord)),
+			(SELECT COUNT(*) FROM watch_events we WHERE we.media_id = m.id),
+			(SELECT COUNT(*) FROM user_ratings ur WHERE ur.media_id = m.id),
+			COALESCE((SELECT AVG(score) FROM user_ratings ur WHERE ur.media_id = m.id), 0)
+		FROM media m
+		WHERE m.id IN (SELECT media_id FROM credits) AND m.media_type = 'movie' AND m.deleted_at IS NULL
+		ORDER BY m.year DESC, m.title;
+		`, personID, personID, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	films := []*api.PersonFilm{}
+	for rows.Next() {
+		var f api.PersonFilm
+		var roles string
+		if err := rows.Scan(&f.MediaID, &f.Title, &f.Year, &roles, &f.Watches, &f.RatingCount, &f.AverageRating); err != nil {
+			return nil, err
+		}
+		f.Roles = splitNonEmpty(roles, "|")
+		films = append(films, &f)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return films, nil
+}
+
+// GetPersonAliases returns all merged names with the person they were merged into
+func (s *SQLiteStorage) GetPersonAliases() ([]*api.PersonAlias, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT pa.alias, p.id, p.name
+		FROM person_aliases pa
+		JOIN people p ON p.id = pa.person_id
+		ORDER BY p.name, pa.alias;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []*api.PersonAlias{}
+	for rows.Next() {
+		var a api.PersonAlias
+		if err := rows.Scan(&a.Alias, &a.PersonID, &a.Name); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, &a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// MergePeople credits everything of the person alias to the person into and removes alias
+// the name of alias is kept as an alias of into so it is merged again when media is refreshed
+func (s *SQLiteStorage) MergePeople(alias, into *api.Person) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, joinTable := range []string{"media_directors", "media_writers"} {
+		// credits both people share are left behind by the update and removed with alias
+		_, err := tx.Exec(`UPDATE OR IGNORE `+joinTable+` SET person_id = ? WHERE person_id = ?`, into.ID, alias.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var aliasActor, intoActor int64
+	err = tx.QueryRow(`SELECT id FROM actors WHERE name = ?`, alias.Name).Scan(&aliasActor)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		err = tx.QueryRow(`SELECT id FROM actors WHERE name = ?`, into.Name).Scan(&intoActor)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if _, err := tx.Exec(`UPDATE actors SET name = ? WHERE id = ?`, into.Name, aliasActor); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			_, err := tx.Exec(`UPDATE OR IGNORE media_actors SET actor_id = ? WHERE actor_id = ?`, intoActor, aliasActor)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`DELETE FROM actors WHERE id = ?`, aliasActor); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE person_aliases SET person_id = ? WHERE person_id = ?`, into.ID, alias.ID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO person_aliases (alias, person_id) VALUES (?, ?)`, alias.Name, into.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM people WHERE id = ?`, alias.ID); err != nil {
+		return err
+	}
+	err = s.audit(tx, api.AuditPeopleMerged, alias.Name, "", map[string]any{"alias": alias, "name": into}, into)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// testPerson returns the stored person with name
+func testPerson(t *testing.T, s *SQLiteStorage, name string) *api.Person {
+	t.Helper()
+	p, err := s.GetPersonByName(name)
+	if err != nil {
+		t.Fatalf("person %s: %v", name, err)
+	}
+	return p
+}
+
+func TestSQLiteStorage_MergePeople(t *testing.T) {
+	s := newTestStore(t)
+	bladeRunner := &api.Movie{ImdbID: "tt0083658", Title: "Blade Runner", Year: "1982", Type: "movie",
+		Director: "R. Scott", Writer: "R. Scott (uncredited)", Actors: "Harrison Ford, R. Scott"}
+	for _, mov := range []*api.Movie{
+		{ImdbID: "tt0078748", Title: "Alien", Year: "1979", Type: "movie", Director: "Ridley Scott", Actors: "Sigourney Weaver"},
+		bladeRunner,
+		// a credit both people share
+		{ImdbID: "tt0172495", Title: "Gladiator", Year: "2000", Type: "movie", Director: "Ridley Scott, R. Scott", Actors: "Russell Crowe, Ridley Scott, R. Scott"},
+		{ImdbID: "tt1446714", Title: "Prometheus", Year: "2012", Type: "movie", Director: "Sir Ridley Scott", Actors: "Noomi Rapace"},
+	} {
+		if _, err := s.CreateMovie(mov); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an alias of the merged person moves along with it
+	if err := s.MergePeople(testPerson(t, s, "Sir Ridley Scott"), testPerson(t, s, "R. Scott")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MergePeople(testPerson(t, s, "R. Scott"), testPerson(t, s, "Ridley Scott")); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCredits := map[string]map[string][]string{
+		"tt0083658": {"directors": {"Ridley Scott"}, "writers": {"Ridley Scott"}, "actors": {"Harrison Ford", "Ridley Scott"}},
+		"tt0172495": {"directors": {"Ridley Scott"}, "actors": {"Russell Crowe", "Ridley Scott"}},
+		"tt1446714": {"directors": {"Ridley Scott"}, "actors": {"Noomi Rapace"}},
+	}
+	checkCredits := func(t *testing.T) {
+		t.Helper()
+		for id, want := range wantCredits {
+			credits, err := s.GetMediaCredits(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, creditNames(credits)); diff != "" {
+				t.Errorf("credits of %s mismatch (-want +got):\n%s", id, diff)
+			}
+		}
+		for _, table := range []string{"people", "actors"} {
+			if n := countRows(t, s, table, "name IN ('R. Scott', 'Sir Ridley Scott')"); n != 0 {
+				t.Errorf("got %d merged names in %s, want none", n, table)
+			}
+		}
+	}
+	checkCredits(t)
+
+	aliases, err := s.GetPersonAliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range aliases {
+		got = append(got, a.Alias+" -> "+a.Name)
+	}
+	if diff := cmp.Diff([]string{"R. Scott -> Ridley Scott", "Sir Ridley Scott -> Ridley Scott"}, got); diff != "" {
+		t.Errorf("aliases mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("refresh resolves aliases", func(t *testing.T) {
+		// OMDb still lists the merged name
+		refreshed := *bladeRunner
+		if _, err := s.UpdateMovie(&refreshed); err != nil {
+			t.Fatal(err)
+		}
+		checkCredits(t)
+	})
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// GetPickCandidates returns all movies nobody has watched yet which match the params
+// a movie counts as watched when an entry is marked watched or a viewing was logged
+func (s *SQLiteStorage) GetPickCandidates(params api.PickParams) ([]*api.PickCandidate, error) {
+	filters := []string{}
+	args := []any{}
+
+	if len(params.Genres) > 0 {
+		genrePlaceholders := make([]string, len(params.Genres))
+		for i, genre := range params.Genres {
+			genrePlaceholders[i] = "g.name LIKE ?"
+			args = append(args, "%"+genre+"%")
+		}
+		filters = append(filters, `m.id IN (
+			SELECT mg.media_id FROM media_genres mg JOIN genres g ON g.id = mg.genre_id
+			WHERE `+strings.Join(genrePlaceholders, " OR ")+`)`)
+	}
+
+	if params.MaxRuntime > 0 {
+		filters = append(filters, "m.runtime_minutes <= ?")
+		args = append(args, params.MaxRuntime)
+	}
+
+	if params.MinIMDb > 0 {
+		filters = append(filters, "m.id IN (SELECT media_id FROM ratings WHERE source = ? AND score >= ?)")
+		args = append(args, api.SourceIMDb, params.MinIMDb)
+	}
+
+	if params.Decade > 0 {
+		filters = append(filters, "CAST(substr(m.year, 1, 4) AS INTEGER) BETWEEN ? AND ?")
+		args = append(args, params.Decade, params.Decade+9)
+	}
+
+	if params.ExcludeUser != "" {
+		filters = append(filters, "NOT EXISTS (SELECT 1 FROM entries e WHERE e.media_id = m.id AND e.deleted_at IS NULL AND lower(e.name) = lower(?))")
+		args = append(args, params.ExcludeUser)
+	}
+
+	query := /*sql*/ `
+		SELECT m.id, m.title,
+			(SELECT COUNT(DISTINCT lower(e.name)) FROM entries e WHERE e.media_id = m.id AND e.deleted_at IS NULL),
+			(SELECT MAX(p.picked_at) FROM pick_history p WHERE p.media_id = m.id)
+		FROM media m
+		WHERE m.media_type = 'movie' AND m.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM entries e WHERE e.media_id = m.id AND e.deleted_at IS NULL AND e.watched = 1)
+			AND NOT EXISTS (SELECT 1 FROM watch_events w WHERE w.media_id = m.id)
+		`
+	for _, filter := range filters {
+		query += "AND " + filter + "\n"
+	}
+	query += "ORDER BY m.title;"
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []*api.PickCandidate
+	for rows.Next() {
+		var c api.PickCandidate
+		var lastPicked sql.NullString
+		if err := rows.Scan(&c.MediaID, &c.Title, &c.Members, &lastPicked); err != nil {
+			return nil, err
+		}
+		// MAX loses the column type, so the timestamp comes back as text
+		if lastPicked.Valid {
+			if c.LastPickedAt, err = time.Parse(time.DateTime, lastPicked.String); err != nil {
+				return nil, err
+			}
+		}
+		candidates = append(candidates, &c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// RecordPick remembers that a movie was suggested, so weighted picks can avoid it for a while
+func (s *SQLiteStorage) RecordPick(username, mediaID string, at time.Time) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec( /*sql*/ `
+		INSERT INTO pick_history (username, media_id, picked_at)
+		VALUES (?, ?, ?);
+		`, username, mediaID, timestamp(at))
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditPickRecorded, mediaID, mediaID, nil, map[string]any{"username": username}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
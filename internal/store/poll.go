@@ -0,0 +1,293 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// CreatePoll stores a poll with its options in the given order
+func (s *SQLiteStorage) CreatePoll(poll *api.Poll) (*api.Poll, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		INSERT INTO polls (token, title, created_by, status, created_at)
+		VALUES (?, ?, ?, ?, ?);
+		`, poll.Token, poll.Title, poll.CreatedBy, api.PollOpen, timestamp(poll.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	if poll.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	for position, option := range poll.Options {
+		_, err := tx.Exec( /*sql*/ `
+			INSERT INTO poll_options (poll_id, media_id, position)
+			VALUES (?, ?, ?);
+			`, poll.ID, option.MediaID, position)
+		if err != nil {
+			return nil, err
+		}
+	}
+	poll.Status = api.PollOpen
+	if err := s.audit(tx, api.AuditPollCreated, poll.Token, "", nil, poll); err != nil {
+		return nil, err
+	}
+	return poll, tx.Commit()
+}
+
+// GetPolls returns all polls without options and voters, newest first
+func (s *SQLiteStorage) GetPolls() ([]*api.Poll, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT p.id, p.token, p.title, p.created_by, p.status,
+			COALESCE(p.winner_id, ''), COALESCE(m.title, ''), p.created_at, p.closed_at
+		FROM polls p
+		LEFT JOIN media m ON m.id = p.winner_id
+		ORDER BY p.created_at DESC, p.id DESC;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var polls []*api.Poll
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, err
+		}
+		polls = append(polls, poll)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
+
+// GetPoll returns the poll shared by token with its options and voters
+func (s *SQLiteStorage) GetPoll(token string) (*api.Poll, error) {
+	poll, err := scanPoll(s.DB.QueryRow( /*sql*/ `
+		SELECT p.id, p.token, p.title, p.created_by, p.status,
+			COALESCE(p.winner_id, ''), COALESCE(m.title, ''), p.created_at, p.closed_at
+		FROM polls p
+		LEFT JOIN media m ON m.id = p.winner_id
+		WHERE p.token = ?;
+		`, token))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT o.media_id, m.title
+		FROM poll_options o
+		JOIN media m ON m.id = o.media_id
+		WHERE o.poll_id = ?
+		ORDER BY o.position;
+		`, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var option api.PollOption
+		if err := rows.Scan(&option.MediaID, &option.Title); err != nil {
+			return nil, err
+		}
+		poll.Options = append(poll.Options, &option)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	voters, err := s.DB.Query( /*sql*/ `
+		SELECT DISTINCT username FROM poll_votes WHERE poll_id = ? ORDER BY username;
+		`, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer voters.Close()
+	for voters.Next() {
+		var username string
+		if err := voters.Scan(&username); err != nil {
+			return nil, err
+		}
+		poll.Voters = append(poll.Voters, username)
+	}
+	if err = voters.Err(); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// rowScanner is implemented by sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPoll(row rowScanner) (*api.Poll, error) {
+	var poll api.Poll
+	var closedAt sql.NullTime
+	err := row.Scan(&poll.ID, &poll.Token, &poll.Title, &poll.CreatedBy, &poll.Status,
+		&poll.WinnerID, &poll.WinnerTitle, &poll.CreatedAt, &closedAt)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		poll.ClosedAt = &closedAt.Time
+	}
+	return &poll, nil
+}
+
+// SaveBallot replaces the ballot of a user
+func (s *SQLiteStorage) SaveBallot(pollID int64, ballot *api.Ballot) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var token string
+	if err := tx.QueryRow(`SELECT token FROM polls WHERE id = ?`, pollID).Scan(&token); err != nil {
+		return err
+	}
+	before := &api.Ballot{Username: ballot.Username}
+	rows, err := tx.Query( /*sql*/ `
+		SELECT media_id FROM poll_votes WHERE poll_id = ? AND username = ? ORDER BY rank;
+		`, pollID, ballot.Username)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mediaID string
+		if err := rows.Scan(&mediaID); err != nil {
+			return err
+		}
+		before.Ranking = append(before.Ranking, mediaID)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(before.Ranking) == 0 {
+		before = nil
+	}
+
+	_, err = tx.Exec( /*sql*/ `
+		DELETE FROM poll_votes WHERE poll_id = ? AND username = ?;
+		`, pollID, ballot.Username)
+	if err != nil {
+		return err
+	}
+	for rank, mediaID := range ballot.Ranking {
+		_, err := tx.Exec( /*sql*/ `
+			INSERT INTO poll_votes (poll_id, username, media_id, rank)
+			VALUES (?, ?, ?, ?);
+			`, pollID, ballot.Username, mediaID, rank+1)
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.audit(tx, api.AuditPollVoted, token, "", before, ballot); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetBallots returns the ballots of all voters of a poll
+func (s *SQLiteStorage) GetBallots(pollID int64) ([]*api.Ballot, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT username, media_id
+		FROM poll_votes
+		WHERE poll_id = ?
+		ORDER BY username, rank;
+		`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ballots []*api.Ballot
+	for rows.Next() {
+		var username, mediaID string
+		if err := rows.Scan(&username, &mediaID); err != nil {
+			return nil, err
+		}
+		if len(ballots) == 0 || ballots[len(ballots)-1].Username != username {
+			ballots = append(ballots, &api.Ballot{Username: username})
+		}
+		last := ballots[len(ballots)-1]
+		last.Ranking = append(last.Ranking, mediaID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ballots, nil
+}
+
+// ClosePoll closes an open poll with its winner and logs a viewing of the winner for every attendee,
+// which marks the entries of the attendees as watched, attendees without an entry get one
+// returns sql.ErrNoRows if the poll is not open
+func (s *SQLiteStorage) ClosePoll(pollID int64, winnerID string, attendees []string, at time.Time) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		UPDATE polls
+		SET status = ?, winner_id = ?, closed_at = ?
+		WHERE id = ? AND status = ?;
+		`, api.PollClosed, winnerID, timestamp(at), pollID, api.PollOpen)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	var token, title string
+	if err := tx.QueryRow(`SELECT token, title FROM polls WHERE id = ?`, pollID).Scan(&token, &title); err != nil {
+		return err
+	}
+	for _, attendee := range attendees {
+		_, err := tx.Exec( /*sql*/ `
+			INSERT INTO watch_events (username, media_id, watched_at, note)
+			VALUES (?, ?, ?, ?);
+			`, attendee, winnerID, at.Format(dateLayout), title)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec( /*sql*/ `
+			INSERT INTO entries (name, watched, comment, media_id)
+			SELECT ?, 1, '', ?
+			WHERE NOT EXISTS (SELECT 1 FROM entries WHERE media_id = ? AND lower(name) = lower(?) AND deleted_at IS NULL);
+			`, attendee, winnerID, winnerID, attendee)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec( /*sql*/ `
+			UPDATE entries
+			SET watched = 1
+			WHERE media_id = ? AND lower(name) = lower(?) AND deleted_at IS NULL;
+			`, winnerID, attendee)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.audit(tx, api.AuditPollClosed, token, winnerID, map[string]any{"status": api.PollOpen}, map[string]any{
+		"status":    api.PollClosed,
+		"winner":    winnerID,
+		"attendees": attendees,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func TestSQLiteStorage_ClosePoll(t *testing.T) {
+	s := newTestStore(t)
+	mov := testMovie(t, s, "tt0078748", "Alien")
+	testEntry(t, s, mov, "Alice")
+	testEntry(t, s, mov, "bob")
+	poll, err := s.CreatePoll(&api.Poll{
+		Token:     "token",
+		Title:     "Friday",
+		CreatedBy: "alice",
+		Options:   []*api.PollOption{{MediaID: mov.ImdbID}},
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ClosePoll(poll.ID, mov.ImdbID, []string{"alice", "carol"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := s.GetEntries(mov.ImdbID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"Alice": true, "bob": false, "carol": true}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		if e.Watched != want[e.Name] {
+			t.Errorf("entry of %s watched = %v, want %v", e.Name, e.Watched, want[e.Name])
+		}
+	}
+	if err := s.ClosePoll(poll.ID, mov.ImdbID, nil, time.Now()); err == nil {
+		t.Error("closing a closed poll did not fail")
+	}
+}
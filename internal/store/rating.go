@@ -0,0 +1,157 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// SetUserRating stores the rating of a user, replacing an earlier rating of the same movie
+func (s *SQLiteStorage) SetUserRating(r *api.UserRating) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getUserRating(tx, r.Username, r.MediaID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		INSERT INTO user_ratings (username, media_id, score, rated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (username, media_id)
+		DO UPDATE SET score = excluded.score, rated_at = excluded.rated_at;
+		`, r.Username, r.MediaID, r.Score, r.RatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditRatingSet, r.Username, r.MediaID, before, r); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) DeleteUserRating(username, mediaID string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getUserRating(tx, username, mediaID)
+	if err != nil || before == nil {
+		return err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		DELETE FROM user_ratings
+		WHERE username = ? AND media_id = ?;
+		`, username, mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditRatingDeleted, username, mediaID, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getUserRating returns the rating of a user for a movie, or nil if the user did not rate it
+func getUserRating(tx *sql.Tx, username, mediaID string) (*api.UserRating, error) {
+	var r api.UserRating
+	err := tx.QueryRow( /*sql*/ `
+		SELECT username, media_id, score, rated_at
+		FROM user_ratings
+		WHERE username = ? AND media_id = ?;
+		`, username, mediaID).Scan(&r.Username, &r.MediaID, &r.Score, &r.RatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetUserRatings returns the ratings of all users for a movie, best first
+func (s *SQLiteStorage) GetUserRatings(mediaID string) ([]*api.UserRating, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT username, media_id, score, rated_at
+		FROM user_ratings
+		WHERE media_id = ?
+		ORDER BY score DESC, username;
+		`, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []*api.UserRating
+	for rows.Next() {
+		var rating api.UserRating
+		if err := rows.Scan(&rating.Username, &rating.MediaID, &rating.Score, &rating.RatedAt); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, &rating)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+// GetRatingsByUser returns all scores of a user keyed by media id
+func (s *SQLiteStorage) GetRatingsByUser(username string) (map[string]float64, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT media_id, score
+		FROM user_ratings
+		WHERE username = ?;
+		`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := make(map[string]float64)
+	for rows.Next() {
+		var mediaID string
+		var score float64
+		if err := rows.Scan(&mediaID, &score); err != nil {
+			return nil, err
+		}
+		ratings[mediaID] = score
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+// GetGroupRatings returns the average score of every rated movie keyed by media id
+func (s *SQLiteStorage) GetGroupRatings() (map[string]api.GroupRating, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT media_id, AVG(score), COUNT(*)
+		FROM user_ratings
+		GROUP BY media_id;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := make(map[string]api.GroupRating)
+	for rows.Next() {
+		var mediaID string
+		var rating api.GroupRating
+		if err := rows.Scan(&mediaID, &rating.Average, &rating.Count); err != nil {
+			return nil, err
+		}
+		ratings[mediaID] = rating
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// GetMediaFeatures returns the genres, actors and directors of every stored movie
+func (s *SQLiteStorage) GetMediaFeatures() ([]*api.MediaFeatures, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT m.id, m.title,
+			COALESCE((SELECT group_concat(p.name, '|') FROM media_directors md JOIN people p ON p.id = md.person_id
+				WHERE md.media_id = m.id), ''),
+			COALESCE((SELECT group_concat(g.name, '|') FROM media_genres mg JOIN genres g ON g.id = mg.genre_id
+				WHERE mg.media_id = m.id), ''),
+			COALESCE((SELECT group_concat(a.name, '|') FROM media_actors ma JOIN actors a ON a.id = ma.actor_id
+				WHERE ma.media_id = m.id), '')
+		FROM media m
+		WHERE m.media_type = 'movie' AND m.deleted_at IS NULL
+		ORDER BY m.title;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var features []*api.MediaFeatures
+	for rows.Next() {
+		var f api.MediaFeatures
+		var directors, genres, actors string
+		if err := rows.Scan(&f.MediaID, &f.Title, &directors, &genres, &actors); err != nil {
+			return nil, err
+		}
+		f.Directors = splitNonEmpty(directors, "|")
+		f.Genres = splitNonEmpty(genres, "|")
+		f.Actors = splitNonEmpty(actors, "|")
+		features = append(features, &f)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// GetSeenMediaIDs returns the ids of all media the user has seen,
+// marked as watched in an entry of the same name, logged as viewing or rated
+func (s *SQLiteStorage) GetSeenMediaIDs(username string) ([]string, error) {
+	return s.queryMediaIDs( /*sql*/ `
+		SELECT media_id FROM entries WHERE lower(name) = lower(?) AND watched = 1 AND deleted_at IS NULL
+		UNION
+		SELECT media_id FROM watch_events WHERE username = ?
+		UNION
+		SELECT media_id FROM user_ratings WHERE username = ?;
+		`, username, username, username)
+}
+
+// GetListedMediaIDs returns the ids of all movies the user has an entry of the same name for
+func (s *SQLiteStorage) GetListedMediaIDs(username string) ([]string, error) {
+	return s.queryMediaIDs( /*sql*/ `
+		SELECT e.media_id
+		FROM entries e
+		JOIN media m ON m.id = e.media_id
+		WHERE lower(e.name) = lower(?) AND e.deleted_at IS NULL AND m.deleted_at IS NULL;
+		`, username)
+}
+
+// queryMediaIDs returns the ids selected by query
+func (s *SQLiteStorage) queryMediaIDs(query string, args ...any) ([]string, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// splitNonEmpty splits s at sep and returns the trimmed non-empty parts
+func splitNonEmpty(s, sep string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
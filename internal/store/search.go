@@ -0,0 +1,105 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// CreateSavedSearch stores a search query under a name unique for the user
+func (s *SQLiteStorage) CreateSavedSearch(search *api.SavedSearch) (*api.SavedSearch, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		INSERT INTO saved_searches (username, name, query, created_at)
+		VALUES (?, ?, ?, ?);
+		`, search.Username, search.Name, search.Query, timestamp(search.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	if search.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := s.audit(tx, api.AuditSearchCreated, strconv.FormatInt(search.ID, 10), "", nil, search); err != nil {
+		return nil, err
+	}
+	return search, tx.Commit()
+}
+
+// GetSavedSearches returns the saved searches of a user by name
+func (s *SQLiteStorage) GetSavedSearches(username string) ([]*api.SavedSearch, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id, username, name, query, created_at
+		FROM saved_searches
+		WHERE username = ?
+		ORDER BY name;
+		`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var searches []*api.SavedSearch
+	for rows.Next() {
+		var search api.SavedSearch
+		if err := rows.Scan(&search.ID, &search.Username, &search.Name, &search.Query, &search.CreatedAt); err != nil {
+			return nil, err
+		}
+		searches = append(searches, &search)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// GetSavedSearch returns the saved search with id
+func (s *SQLiteStorage) GetSavedSearch(id int64) (*api.SavedSearch, error) {
+	return getSavedSearch(s.DB.QueryRow, id)
+}
+
+func getSavedSearch(queryRow func(query string, args ...any) *sql.Row, id int64) (*api.SavedSearch, error) {
+	var search api.SavedSearch
+	err := queryRow( /*sql*/ `
+		SELECT id, username, name, query, created_at
+		FROM saved_searches
+		WHERE id = ?;
+		`, id).Scan(&search.ID, &search.Username, &search.Name, &search.Query, &search.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// DeleteSavedSearch removes a saved search of the user
+// returns sql.ErrNoRows if the user has no saved search with id
+func (s *SQLiteStorage) DeleteSavedSearch(id int64, username string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getSavedSearch(tx.QueryRow, id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM saved_searches WHERE id = ? AND username = ?`, id, username)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	if err := s.audit(tx, api.AuditSearchDeleted, strconv.FormatInt(id, 10), "", before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log/slog"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jhachmer/gomovie/internal/api"
 	"github.com/jhachmer/gomovie/internal/auth"
@@ -17,6 +19,8 @@ import (
 
 type SQLiteStorage struct {
 	DB *sql.DB
+	// actor is recorded in the audit log for every change made through the store
+	actor string
 }
 
 func NewSQLiteStore(db *sql.DB) *SQLiteStorage {
@@ -25,6 +29,11 @@ func NewSQLiteStore(db *sql.DB) *SQLiteStorage {
 	}
 }
 
+// As returns a store on the same database whose changes are audited as made by actor
+func (s *SQLiteStorage) As(actor string) Store {
+	return &SQLiteStorage{DB: s.DB, actor: actor}
+}
+
 func (s *SQLiteStorage) Close() error {
 	if err := s.DB.Close(); err != nil {
 		return err
@@ -51,14 +60,28 @@ func (s *SQLiteStorage) CreateAdminAccount(config config.Config) error {
 		slog.Error("error creating admin account")
 		return err
 	}
-	_, err = s.DB.Exec(`--sql
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`--sql
 	INSERT OR IGNORE INTO useraccounts (Username, PasswordHash, Active, IsAdmin)
 	VALUES (?, ?, ?, ?)
 	`, config.AdminName, hashedPW, 1, 1)
 	if err != nil {
 		return fmt.Errorf("error inserting admin acc %w", err)
 	}
-	return nil
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return nil
+	}
+	if err := s.audit(tx, api.AuditUserRegistered, config.AdminName, "", nil, map[string]any{"username": config.AdminName, "admin": true}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *SQLiteStorage) InitDatabaseTables() error {
@@ -163,17 +186,357 @@ func (s *SQLiteStorage) InitDatabaseTables() error {
 	if err != nil {
 		return err
 	}
-	return nil
+	// People directing or writing media
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS people (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL UNIQUE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Person Aliases, names merged into a person by an admin
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS person_aliases (
+		alias VARCHAR(255) PRIMARY KEY,
+		person_id INTEGER NOT NULL,
+		FOREIGN KEY (person_id) REFERENCES people(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Media Directors MN
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS media_directors (
+		media_id VARCHAR(9) NOT NULL,
+		person_id INTEGER NOT NULL,
+		PRIMARY KEY (media_id, person_id),
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE,
+		FOREIGN KEY (person_id) REFERENCES people(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Media Writers MN
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS media_writers (
+		media_id VARCHAR(9) NOT NULL,
+		person_id INTEGER NOT NULL,
+		PRIMARY KEY (media_id, person_id),
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE,
+		FOREIGN KEY (person_id) REFERENCES people(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Languages
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS languages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL UNIQUE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Media Languages MN
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS media_languages (
+		media_id VARCHAR(9) NOT NULL,
+		language_id INTEGER NOT NULL,
+		PRIMARY KEY (media_id, language_id),
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE,
+		FOREIGN KEY (language_id) REFERENCES languages(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Countries
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS countries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL UNIQUE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Media Countries MN
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS media_countries (
+		media_id VARCHAR(9) NOT NULL,
+		country_id INTEGER NOT NULL,
+		PRIMARY KEY (media_id, country_id),
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE,
+		FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Tags
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(50) NOT NULL UNIQUE COLLATE NOCASE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Media Tags MN
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS media_tags (
+		media_id VARCHAR(9) NOT NULL,
+		tag_id INTEGER NOT NULL,
+		PRIMARY KEY (media_id, tag_id),
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE,
+		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Collections
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS collections (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE,
+		tags TEXT NOT NULL,
+		match_all INTEGER NOT NULL DEFAULT 0,
+		created_by VARCHAR(255) NOT NULL);
+		`)
+	if err != nil {
+		return err
+	}
+	// Saved Searches
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS saved_searches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL COLLATE NOCASE,
+		query TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE (username, name));
+		`)
+	if err != nil {
+		return err
+	}
+	// Watch Events
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS watch_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username VARCHAR(255) NOT NULL,
+		media_id VARCHAR(9) NOT NULL,
+		watched_at DATE,
+		note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// User Ratings
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS user_ratings (
+		username VARCHAR(255) NOT NULL,
+		media_id VARCHAR(9) NOT NULL,
+		score REAL NOT NULL CHECK (score >= 0.5 AND score <= 5),
+		rated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (username, media_id),
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Job Runs
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS job_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job VARCHAR(255) NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP NOT NULL,
+		processed INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		requests INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '');
+		`)
+	if err != nil {
+		return err
+	}
+	// Rating Snapshots
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS rating_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		media_id VARCHAR(9) NOT NULL,
+		source VARCHAR(255) NOT NULL,
+		value VARCHAR(50) NOT NULL,
+		score REAL,
+		taken_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Library Items
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS library_items (
+		path TEXT PRIMARY KEY,
+		media_id VARCHAR(9),
+		title VARCHAR(255) NOT NULL,
+		year INTEGER NOT NULL DEFAULT 0,
+		status VARCHAR(20) NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		size INTEGER NOT NULL,
+		mod_time TIMESTAMP NOT NULL,
+		scanned_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE SET NULL);
+		`)
+	if err != nil {
+		return err
+	}
+	// Events
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type VARCHAR(50) NOT NULL,
+		username VARCHAR(255) NOT NULL DEFAULT '',
+		media_id VARCHAR(9) NOT NULL DEFAULT '',
+		title VARCHAR(255) NOT NULL DEFAULT '',
+		data TEXT NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP NOT NULL);
+		`)
+	if err != nil {
+		return err
+	}
+	// Webhooks
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret VARCHAR(255) NOT NULL,
+		events TEXT NOT NULL DEFAULT '',
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMP NOT NULL);
+		`)
+	if err != nil {
+		return err
+	}
+	// Webhook Deliveries
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMP NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		delivered_at TIMESTAMP,
+		FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+		FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(`--sql
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+		`)
+	if err != nil {
+		return err
+	}
+	// Pick History
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS pick_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username VARCHAR(255) NOT NULL,
+		media_id VARCHAR(9) NOT NULL,
+		picked_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Polls
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS polls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		token VARCHAR(64) NOT NULL UNIQUE,
+		title VARCHAR(255) NOT NULL,
+		created_by VARCHAR(255) NOT NULL,
+		status VARCHAR(10) NOT NULL DEFAULT 'open',
+		winner_id VARCHAR(9),
+		created_at TIMESTAMP NOT NULL,
+		closed_at TIMESTAMP,
+		FOREIGN KEY (winner_id) REFERENCES media(id) ON DELETE SET NULL);
+		`)
+	if err != nil {
+		return err
+	}
+	// Poll Options
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS poll_options (
+		poll_id INTEGER NOT NULL,
+		media_id VARCHAR(9) NOT NULL,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (poll_id, media_id),
+		FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Poll Votes, a ballot is the ranked votes of a user
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS poll_votes (
+		poll_id INTEGER NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		media_id VARCHAR(9) NOT NULL,
+		rank INTEGER NOT NULL,
+		PRIMARY KEY (poll_id, username, media_id),
+		FOREIGN KEY (poll_id, media_id) REFERENCES poll_options(poll_id, media_id) ON DELETE CASCADE);
+		`)
+	if err != nil {
+		return err
+	}
+	// Audit Log, rows are never changed or removed
+	_, err = s.DB.Exec(`--sql
+		CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor VARCHAR(255) NOT NULL,
+		action VARCHAR(50) NOT NULL,
+		target VARCHAR(255) NOT NULL,
+		media_id VARCHAR(9) NOT NULL DEFAULT '',
+		before TEXT,
+		after TEXT,
+		created_at TIMESTAMP NOT NULL);
+		`)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(`--sql
+		CREATE INDEX IF NOT EXISTS audit_log_created ON audit_log (created_at);
+		`)
+	if err != nil {
+		return err
+	}
+	for _, op := range []string{"UPDATE", "DELETE"} {
+		_, err = s.DB.Exec(`CREATE TRIGGER IF NOT EXISTS audit_log_no_` + strings.ToLower(op) + `
+			BEFORE ` + op + ` ON audit_log
+			BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;`)
+		if err != nil {
+			return err
+		}
+	}
+	return s.migrate()
 }
 
 func (s *SQLiteStorage) GetWatchCounts() (*api.WatchStats, error) {
 	var stats api.WatchStats
 	row := s.DB.QueryRow(`--sql
 	SELECT
-    SUM(CASE WHEN watched = 1 THEN 1 ELSE 0 END) AS watched_count,
-    SUM(CASE WHEN watched = 0 THEN 1 ELSE 0 END) AS unwatched_count,
+    COALESCE(SUM(CASE WHEN watched = 1 THEN 1 ELSE 0 END), 0) AS watched_count,
+    COALESCE(SUM(CASE WHEN watched = 0 THEN 1 ELSE 0 END), 0) AS unwatched_count,
     COUNT(*) AS total_movies
-	FROM entries;
+	FROM entries
+	WHERE deleted_at IS NULL;
 	`)
 	err := row.Scan(&stats.NumOfWatched, &stats.NumOfUnwatched, &stats.TotalMovies)
 	if err != nil {
@@ -182,6 +545,55 @@ func (s *SQLiteStorage) GetWatchCounts() (*api.WatchStats, error) {
 	return &stats, nil
 }
 
+func (s *SQLiteStorage) GetGenreCounts() ([]*api.CountStat, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+	SELECT g.name, COUNT(mg.media_id) AS num
+	FROM genres g
+	INNER JOIN media_genres mg ON g.id = mg.genre_id
+	INNER JOIN media m ON m.id = mg.media_id
+	WHERE m.deleted_at IS NULL
+	GROUP BY g.name
+	ORDER BY num DESC, g.name;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCountStats(rows)
+}
+
+func (s *SQLiteStorage) GetReleaseYears() ([]int, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+	SELECT year
+	FROM media
+	WHERE deleted_at IS NULL;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var years []int
+	for rows.Next() {
+		var year string
+		if err := rows.Scan(&year); err != nil {
+			return nil, err
+		}
+		// series years look like "2008–2013", only the first year is used
+		if len(year) < 4 {
+			continue
+		}
+		y, err := strconv.Atoi(year[:4])
+		if err != nil {
+			continue
+		}
+		years = append(years, y)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return years, nil
+}
+
 func (s *SQLiteStorage) CheckCredentials(username, password string) (bool, error) {
 	var hashedPassword string
 	var active bool
@@ -212,7 +624,13 @@ func (s *SQLiteStorage) CreateUser(username, password string) error {
 	if err != nil {
 		return fmt.Errorf("unable to hash pw: %w", err)
 	}
-	_, err = s.DB.Exec( /*sql*/ `
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec( /*sql*/ `
 		INSERT
 		INTO useraccounts (Username, PasswordHash)
 		VALUES (?, ?);
@@ -220,7 +638,10 @@ func (s *SQLiteStorage) CreateUser(username, password string) error {
 	if err != nil {
 		return fmt.Errorf("could not create useraccount: %w", err)
 	}
-	return nil
+	if err := s.audit(tx, api.AuditUserRegistered, username, "", nil, map[string]any{"username": username}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *SQLiteStorage) AdminLoginQuery(username string) (string, error) {
@@ -232,6 +653,15 @@ func (s *SQLiteStorage) AdminLoginQuery(username string) (string, error) {
 	return passwordHash, nil
 }
 
+// IsAdmin reports if the user has an active admin account
+func (s *SQLiteStorage) IsAdmin(username string) (bool, error) {
+	var isAdmin bool
+	err := s.DB.QueryRow( /*sql*/ `
+		SELECT EXISTS(SELECT 1 FROM useraccounts WHERE Username = ? AND IsAdmin = 1 AND Active = 1);
+		`, username).Scan(&isAdmin)
+	return isAdmin, err
+}
+
 func (s *SQLiteStorage) GetUsers() (*sql.Rows, error) {
 	rows, err := s.DB.Query("SELECT UserID, Username, Active FROM useraccounts")
 	if err != nil {
@@ -240,42 +670,61 @@ func (s *SQLiteStorage) GetUsers() (*sql.Rows, error) {
 	return rows, err
 }
 
+// ToggleUserActive sets whether the account can log in, sql.ErrNoRows is returned for unknown accounts
 func (s *SQLiteStorage) ToggleUserActive(active, id int) error {
-	_, err := s.DB.Exec("UPDATE useraccounts SET Active = ? WHERE UserID = ?", active, id)
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
-}
+	defer tx.Rollback()
 
-func (s *SQLiteStorage) CreateMovie(m *api.Movie) (*api.Movie, error) {
-	_, err := s.DB.Exec( /*sql*/ `
-		INSERT INTO media (id, title, year, director, runtime, rated, released, plot, poster, media_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
-		`, m.ImdbID, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster, m.Type)
+	var username string
+	var before int
+	err = tx.QueryRow("SELECT Username, Active FROM useraccounts WHERE UserID = ?", id).Scan(&username, &before)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	err = s.createMovieRatings(m)
+	_, err = tx.Exec("UPDATE useraccounts SET Active = ? WHERE UserID = ?", active, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	err = s.createMovieGenres(m)
+	err = s.audit(tx, api.AuditUserActiveChanged, strconv.Itoa(id), "",
+		map[string]any{"username": username, "active": before}, map[string]any{"username": username, "active": active})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	err = s.createMovieActors(m)
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) CreateMovie(m *api.Movie) (*api.Movie, error) {
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
-	return m, nil
+	defer tx.Rollback()
+
+	if _, err := s.CreateMovieTx(tx, m); err != nil {
+		return nil, err
+	}
+	return m, tx.Commit()
 }
 
 func (s *SQLiteStorage) CreateMovieTx(tx *sql.Tx, m *api.Movie) (*api.Movie, error) {
+	m.Normalise()
+	if err := resolveAliases(tx, m); err != nil {
+		return nil, err
+	}
+	if restored, err := restoreTrashedMedia(tx, m.ImdbID); err != nil {
+		return nil, err
+	} else if restored {
+		return m, s.audit(tx, api.AuditMovieRestored, m.ImdbID, m.ImdbID, nil, m)
+	}
 	_, err := tx.Exec( /*sql*/ `
-		INSERT INTO media (id, title, year, director, runtime, rated, released, plot, poster, media_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
-		`, m.ImdbID, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster, m.Type)
+		INSERT INTO media (id, title, year, director, runtime, rated, released, plot, poster, media_type,
+		runtime_minutes, released_at, box_office, box_office_cents, refreshed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+		`, m.ImdbID, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster, m.Type,
+		nullInt(int64(m.RuntimeMinutes)), nullDate(m.ReleasedAt), boxOffice(m.BoxOffice), nullInt(m.BoxOfficeCents))
 	if err != nil {
 		return nil, err
 	}
@@ -291,61 +740,102 @@ func (s *SQLiteStorage) CreateMovieTx(tx *sql.Tx, m *api.Movie) (*api.Movie, err
 	if err != nil {
 		return nil, err
 	}
-	return m, nil
+	err = storeCredits(tx, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, s.audit(tx, api.AuditMovieCreated, m.ImdbID, m.ImdbID, nil, m)
 }
 
 func (s *SQLiteStorage) UpdateMovie(m *api.Movie) (*api.Movie, error) {
-	_, err := s.DB.Exec(`--sql
+	m.Normalise()
+	before, err := s.GetMovieByID(m.ImdbID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := resolveAliases(tx, m); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`--sql
 	UPDATE media
-	SET title = ?, year = ?, director = ?, runtime = ?, rated = ?, released = ?, plot = ?, poster = ?
+	SET title = ?, year = ?, director = ?, runtime = ?, rated = ?, released = ?, plot = ?, poster = ?,
+	runtime_minutes = ?, released_at = ?, box_office = ?, box_office_cents = ?, refreshed_at = CURRENT_TIMESTAMP
 	WHERE id = ?;
-	`, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster, m.ImdbID)
+	`, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster,
+		nullInt(int64(m.RuntimeMinutes)), nullDate(m.ReleasedAt), boxOffice(m.BoxOffice), nullInt(m.BoxOfficeCents), m.ImdbID)
 	if err != nil {
 		return nil, err
 	}
-	err = s.updateRatings(*m)
+	err = updateRatings(tx, *m)
 	if err != nil {
 		return nil, err
 	}
-	err = s.updateGenres(*m)
+	err = updateGenres(tx, *m)
 	if err != nil {
 		return nil, err
 	}
-	err = s.updateActors(*m)
+	err = updateActors(tx, *m)
 	if err != nil {
 		return nil, err
 	}
-	return m, nil
+	err = storeCredits(tx, m)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit(tx, api.AuditMovieUpdated, m.ImdbID, m.ImdbID, before, m); err != nil {
+		return nil, err
+	}
+	return m, tx.Commit()
 }
 
+// DeleteMedia moves the media and its entries to the trash
+// sql.ErrNoRows is returned when there is no media with the id outside the trash
 func (s *SQLiteStorage) DeleteMedia(imdbId string) error {
-	_, err := s.DB.Exec( /*sql*/ `
-	DELETE FROM media WHERE id = ?;
-	`, imdbId)
+	before, err := s.GetMovieByID(imdbId)
 	if err != nil {
 		return err
 	}
-	return nil
-}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-func (s *SQLiteStorage) updateRatings(m api.Media) error {
-	for _, rating := range m.GetRatings() {
-		_, err := s.DB.Exec( /*sql*/ `
-			UPDATE ratings
-			SET value = ?
-			WHERE media_id = ? AND source = ?;
-			`, rating.Value, m.GetID(), rating.Source)
-		if err != nil {
-			return err
-		}
+	// entries trashed with the media are marked so restoring the media brings back only them
+	now := timestamp(time.Now())
+	res, err := tx.Exec( /*sql*/ `
+	UPDATE media SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL;
+	`, now, imdbId)
+	if err != nil {
+		return err
 	}
-	return nil
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = tx.Exec( /*sql*/ `
+	UPDATE entries SET deleted_at = ?, deleted_with_media = 1 WHERE media_id = ? AND deleted_at IS NULL;
+	`, now, imdbId)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditMovieTrashed, imdbId, imdbId, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *SQLiteStorage) updateGenres(m api.Media) error {
+func updateGenres(tx *sql.Tx, m api.Media) error {
 	genres := util.SplitIMDBString(m.GetGenres())
 
-	rows, err := s.DB.Query( /*sql*/ `
+	rows, err := tx.Query( /*sql*/ `
 	SELECT g.name
 	FROM genres g
 	INNER JOIN media_genres mg ON g.id = mg.genre_id
@@ -366,7 +856,7 @@ func (s *SQLiteStorage) updateGenres(m api.Media) error {
 
 	for _, g := range genresFromDB {
 		if !slices.Contains(genres, g) {
-			_, err := s.DB.Exec( /*sql*/ `
+			_, err := tx.Exec( /*sql*/ `
 			DELETE FROM media_genres
 			WHERE
 			media_id = ?
@@ -381,13 +871,13 @@ func (s *SQLiteStorage) updateGenres(m api.Media) error {
 
 	for _, genre := range genres {
 		var genreID int64
-		err := s.DB.QueryRow( /*sql*/ `
+		err := tx.QueryRow( /*sql*/ `
 			SELECT id
 			FROM genres
 			WHERE name = ?;
 			`, genre).Scan(&genreID)
 		if errors.Is(err, sql.ErrNoRows) {
-			res, err := s.DB.Exec( /*sql*/ `
+			res, err := tx.Exec( /*sql*/ `
 				INSERT OR IGNORE
        			INTO genres (name)
        			VALUES (?);
@@ -397,7 +887,7 @@ func (s *SQLiteStorage) updateGenres(m api.Media) error {
 			}
 			genreID, _ = res.LastInsertId()
 		}
-		_, err = s.DB.Exec( /*sql*/ `
+		_, err = tx.Exec( /*sql*/ `
 			INSERT OR IGNORE
        		INTO media_genres (media_id, genre_id)
        		VALUES (?, ?);
@@ -409,10 +899,10 @@ func (s *SQLiteStorage) updateGenres(m api.Media) error {
 	return nil
 }
 
-func (s *SQLiteStorage) updateActors(m api.Media) error {
+func updateActors(tx *sql.Tx, m api.Media) error {
 	actors := util.SplitIMDBString(m.GetActors())
 
-	rows, err := s.DB.Query( /*sql*/ `
+	rows, err := tx.Query( /*sql*/ `
 	SELECT a.name
 	FROM actors a
 	INNER JOIN media_actors ma ON a.id = ma.actor_id
@@ -433,7 +923,7 @@ func (s *SQLiteStorage) updateActors(m api.Media) error {
 
 	for _, a := range actorsFromDB {
 		if !slices.Contains(actors, a) {
-			_, err := s.DB.Exec( /*sql*/ `
+			_, err := tx.Exec( /*sql*/ `
 			DELETE FROM media_actors
 			WHERE
 			media_id = ?
@@ -448,13 +938,13 @@ func (s *SQLiteStorage) updateActors(m api.Media) error {
 
 	for _, actor := range actors {
 		var actorID int64
-		err := s.DB.QueryRow( /*sql*/ `
+		err := tx.QueryRow( /*sql*/ `
 			SELECT id
 			FROM actors
 			WHERE name = ?;
 			`, actor).Scan(&actorID)
 		if errors.Is(err, sql.ErrNoRows) {
-			res, err := s.DB.Exec( /*sql*/ `
+			res, err := tx.Exec( /*sql*/ `
 				INSERT OR IGNORE
        			INTO actors (name)
        			VALUES (?);
@@ -464,7 +954,7 @@ func (s *SQLiteStorage) updateActors(m api.Media) error {
 			}
 			actorID, _ = res.LastInsertId()
 		}
-		_, err = s.DB.Exec( /*sql*/ `
+		_, err = tx.Exec( /*sql*/ `
 			INSERT OR IGNORE
        		INTO media_actors (media_id, actor_id)
        		VALUES (?, ?);
@@ -479,16 +969,28 @@ func (s *SQLiteStorage) updateActors(m api.Media) error {
 func (s *SQLiteStorage) GetMovieByID(movieID string) (*api.Movie, error) {
 	var movie api.Movie
 
+	var runtimeMinutes, boxOfficeCents sql.NullInt64
+	var releasedAt sql.NullString
 	err := s.DB.QueryRow( /*sql*/ `
         SELECT
-            id, title, year, rated, released, runtime, plot, poster, director
+            id, title, year, rated, released, runtime, plot, poster, director, media_type,
+            runtime_minutes, released_at, box_office, box_office_cents
         FROM media
-        WHERE id = ?`, movieID).Scan(
+        WHERE id = ? AND deleted_at IS NULL`, movieID).Scan(
 		&movie.ImdbID, &movie.Title, &movie.Year, &movie.Rated,
-		&movie.Released, &movie.Runtime, &movie.Plot, &movie.Poster, &movie.Director)
+		&movie.Released, &movie.Runtime, &movie.Plot, &movie.Poster, &movie.Director, &movie.Type,
+		&runtimeMinutes, &releasedAt, &movie.BoxOffice, &boxOfficeCents)
 	if err != nil {
 		return nil, err
 	}
+	movie.RuntimeMinutes = int(runtimeMinutes.Int64)
+	movie.BoxOfficeCents = boxOfficeCents.Int64
+	if releasedAt.Valid {
+		movie.ReleasedAt, err = time.Parse(dateLayout, releasedAt.String)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	rows, err := s.DB.Query( /*sql*/ `
         SELECT g.name
@@ -530,8 +1032,12 @@ func (s *SQLiteStorage) GetMovieByID(movieID string) (*api.Movie, error) {
 	}
 	movie.Actors = strings.Join(actors, ", ")
 
+	if err := s.fillCredits(&movie); err != nil {
+		return nil, err
+	}
+
 	rows, err = s.DB.Query( /*sql*/ `
-        SELECT source, value
+        SELECT source, value, score
         FROM ratings
         WHERE media_id = ?`, movieID)
 	if err != nil {
@@ -542,7 +1048,7 @@ func (s *SQLiteStorage) GetMovieByID(movieID string) (*api.Movie, error) {
 	var ratings []api.Rating
 	for rows.Next() {
 		var rating api.Rating
-		if err := rows.Scan(&rating.Source, &rating.Value); err != nil {
+		if err := rows.Scan(&rating.Source, &rating.Value, &rating.Score); err != nil {
 			return nil, err
 		}
 		ratings = append(ratings, rating)
@@ -557,7 +1063,7 @@ func (s *SQLiteStorage) GetAllMovies() ([]*api.MovieInfoData, error) {
 	rows, err := s.DB.Query( /*sql*/ `
         SELECT id
         FROM media
-        WHERE media_type = 'movie'
+        WHERE media_type = 'movie' AND deleted_at IS NULL
 		`)
 	if err != nil {
 		return nil, err
@@ -614,6 +1120,69 @@ func (s *SQLiteStorage) SearchMovie(params api.SearchParams) ([]*api.MovieInfoDa
 		args = append(args, params.Years.StartYear, params.Years.EndYear)
 	}
 
+	if params.MinMyRating > 0 {
+		filters = append(filters, "m.id IN (SELECT media_id FROM user_ratings WHERE username = ? AND score >= ?)")
+		args = append(args, params.Username, params.MinMyRating)
+	}
+
+	if params.MinGroupRating > 0 {
+		filters = append(filters, "m.id IN (SELECT media_id FROM user_ratings GROUP BY media_id HAVING AVG(score) >= ?)")
+		args = append(args, params.MinGroupRating)
+	}
+
+	if params.Runtime.Min > 0 {
+		filters = append(filters, "m.runtime_minutes >= ?")
+		args = append(args, params.Runtime.Min)
+	}
+
+	if params.Runtime.Max > 0 {
+		filters = append(filters, "m.runtime_minutes <= ?")
+		args = append(args, params.Runtime.Max)
+	}
+
+	if len(params.Tags) > 0 {
+		tagPlaceholders := make([]string, len(params.Tags))
+		for i, tag := range params.Tags {
+			tagPlaceholders[i] = "?"
+			args = append(args, tag)
+		}
+		filters = append(filters, `m.id IN (
+			SELECT mt.media_id FROM media_tags mt JOIN tags t ON t.id = mt.tag_id
+			WHERE t.name IN (`+strings.Join(tagPlaceholders, ", ")+`))`)
+	}
+
+	if len(params.AllTags) > 0 {
+		tagPlaceholders := make([]string, len(params.AllTags))
+		for i, tag := range params.AllTags {
+			tagPlaceholders[i] = "?"
+			args = append(args, tag)
+		}
+		filters = append(filters, `m.id IN (
+			SELECT mt.media_id FROM media_tags mt JOIN tags t ON t.id = mt.tag_id
+			WHERE t.name IN (`+strings.Join(tagPlaceholders, ", ")+`)
+			GROUP BY mt.media_id HAVING COUNT(*) = ?)`)
+		args = append(args, len(params.AllTags))
+	}
+
+	for _, names := range []struct {
+		list  nameList
+		names []string
+	}{
+		{directorList, params.Directors},
+		{writerList, params.Writers},
+		{languageList, params.Languages},
+		{countryList, params.Countries},
+	} {
+		if len(names.names) > 0 {
+			filters = append(filters, likeAny(names.list, names.names, &args))
+		}
+	}
+
+	for source, minScore := range params.MinScores {
+		filters = append(filters, "m.id IN (SELECT media_id FROM ratings WHERE source = ? AND score >= ?)")
+		args = append(args, source, minScore)
+	}
+
 	query := /*sql*/ `
 		SELECT DISTINCT m.id
 		FROM media m
@@ -621,10 +1190,11 @@ func (s *SQLiteStorage) SearchMovie(params api.SearchParams) ([]*api.MovieInfoDa
 		LEFT JOIN genres g ON mg.genre_id = g.id
 		LEFT JOIN media_actors ma ON m.id = ma.media_id
 		LEFT JOIN actors a ON ma.actor_id = a.id
+		WHERE m.deleted_at IS NULL
 		`
 
-	if len(filters) > 0 {
-		query += "WHERE " + strings.Join(filters, " AND ")
+	for _, filter := range filters {
+		query += "AND (" + filter + ")\n"
 	}
 
 	rows, err := s.DB.Query(query, args...)
@@ -657,58 +1227,16 @@ func (s *SQLiteStorage) SearchMovie(params api.SearchParams) ([]*api.MovieInfoDa
 	return results, nil
 }
 
-func (s *SQLiteStorage) createMovieRatings(m *api.Movie) error {
-	for _, rating := range m.Ratings {
-		_, err := s.DB.Exec( /*sql*/ `
-			INSERT INTO ratings (media_id, source, value)
-			VALUES (?, ?, ?);
-			`, m.ImdbID, rating.Source, rating.Value)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (s *SQLiteStorage) createMovieRatingsTx(tx *sql.Tx, m *api.Movie) error {
 	for _, rating := range m.Ratings {
 		_, err := tx.Exec( /*sql*/ `
-			INSERT INTO ratings (media_id, source, value)
-			VALUES (?, ?, ?);
-			`, m.ImdbID, rating.Source, rating.Value)
+			INSERT INTO ratings (media_id, source, value, score)
+			VALUES (?, ?, ?, ?);
+			`, m.ImdbID, rating.Source, rating.Value, rating.Score)
 		if err != nil {
 			return err
 		}
-	}
-	return nil
-}
-
-func (s *SQLiteStorage) createMovieGenres(m *api.Movie) error {
-	genres := util.SplitIMDBString(m.Genre)
-	for _, genre := range genres {
-		var genreID int64
-		err := s.DB.QueryRow( /*sql*/ `
-			SELECT id
-			FROM genres
-			WHERE name = ?;
-			`, genre).Scan(&genreID)
-		if errors.Is(err, sql.ErrNoRows) {
-			res, err := s.DB.Exec( /*sql*/ `
-				INSERT OR IGNORE
-       			INTO genres (name)
-       			VALUES (?);
-       			`, genre)
-			if err != nil {
-				return err
-			}
-			genreID, _ = res.LastInsertId()
-		}
-		_, err = s.DB.Exec( /*sql*/ `
-			INSERT OR IGNORE
-       		INTO media_genres (media_id, genre_id)
-       		VALUES (?, ?);
-       	`, m.ImdbID, genreID)
-		if err != nil {
+		if err := insertRatingSnapshot(tx, m.ImdbID, rating); err != nil {
 			return err
 		}
 	}
@@ -746,38 +1274,6 @@ func (s *SQLiteStorage) createMovieGenresTx(tx *sql.Tx, m *api.Movie) error {
 	return nil
 }
 
-func (s *SQLiteStorage) createMovieActors(m *api.Movie) error {
-	actors := util.SplitIMDBString(m.Actors)
-	for _, actor := range actors {
-		var actorID int64
-		err := s.DB.QueryRow( /*sql*/ `
-			SELECT id
-			FROM actors
-			WHERE name = ?;
-			`, actor).Scan(&actorID)
-		if errors.Is(err, sql.ErrNoRows) {
-			res, err := s.DB.Exec( /*sql*/ `
-				INSERT OR IGNORE
-       			INTO actors (name)
-       			VALUES (?);
-       			`, actor)
-			if err != nil {
-				return err
-			}
-			actorID, _ = res.LastInsertId()
-		}
-		_, err = s.DB.Exec( /*sql*/ `
-			INSERT OR IGNORE
-       		INTO media_actors (media_id, actor_id)
-       		VALUES (?, ?);
-			`, m.ImdbID, actorID)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (s *SQLiteStorage) createActorsTx(tx *sql.Tx, m *api.Movie) error {
 	actors := util.SplitIMDBString(m.Actors)
 	for _, actor := range actors {
@@ -810,163 +1306,33 @@ func (s *SQLiteStorage) createActorsTx(tx *sql.Tx, m *api.Movie) error {
 	return nil
 }
 
+// CreateSeries stores a series like a movie
 func (s *SQLiteStorage) CreateSeries(m *api.Series) (*api.Series, error) {
-	_, err := s.DB.Exec( /*sql*/ `
-		INSERT INTO media (id, title, year, director, runtime, rated, released, plot, poster, media_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
-		`, m.ImdbID, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster, m.Type)
-	if err != nil {
-		return nil, err
-	}
-	err = s.createSeriesRatings(m)
-	if err != nil {
-		return nil, err
-	}
-	err = s.createSeriesGenres(m)
-	if err != nil {
-		return nil, err
-	}
-	err = s.createSeriesActors(m)
-	if err != nil {
+	if _, err := s.CreateMovie(&m.Movie); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-func (s *SQLiteStorage) createSeriesRatings(m *api.Series) error {
-	for _, rating := range m.Ratings {
-		_, err := s.DB.Exec( /*sql*/ `
-			INSERT INTO ratings (media_id, source, value)
-			VALUES (?, ?, ?);
-			`, m.ImdbID, rating.Source, rating.Value)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func (s *SQLiteStorage) createSeriesGenres(m *api.Series) error {
-	genres := util.SplitIMDBString(m.Genre)
-	for _, genre := range genres {
-		var genreID int64
-		err := s.DB.QueryRow( /*sql*/ `
-			SELECT id
-			FROM genres
-			WHERE name = ?;
-			`, genre).Scan(&genreID)
-		if errors.Is(err, sql.ErrNoRows) {
-			res, err := s.DB.Exec( /*sql*/ `
-				INSERT OR IGNORE
-       			INTO genres (name)
-       			VALUES (?);
-       			`, genre)
-			if err != nil {
-				return err
-			}
-			genreID, _ = res.LastInsertId()
-		}
-		_, err = s.DB.Exec( /*sql*/ `
-			INSERT OR IGNORE
-       		INTO media_genres (media_id, genre_id)
-       		VALUES (?, ?);
-       	`, m.ImdbID, genreID)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func (s *SQLiteStorage) createSeriesActors(m *api.Series) error {
-	actors := util.SplitIMDBString(m.Actors)
-	for _, actor := range actors {
-		var actorID int64
-		err := s.DB.QueryRow( /*sql*/ `
-			SELECT id
-			FROM actors
-			WHERE name = ?;
-			`, actor).Scan(&actorID)
-		if errors.Is(err, sql.ErrNoRows) {
-			res, err := s.DB.Exec( /*sql*/ `
-				INSERT OR IGNORE
-       			INTO actors (name)
-       			VALUES (?);
-       			`, actor)
-			if err != nil {
-				return err
-			}
-			actorID, _ = res.LastInsertId()
-		}
-		_, err = s.DB.Exec( /*sql*/ `
-			INSERT OR IGNORE
-       		INTO media_actors (media_id, actor_id)
-       		VALUES (?, ?);
-			`, m.ImdbID, actorID)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
+// UpdateSeries updates a stored series like a movie
 func (s *SQLiteStorage) UpdateSeries(m *api.Series) (*api.Series, error) {
-	_, err := s.DB.Exec( /*sql*/ `
-	UPDATE media
-	SET title = ?, year = ?, director = ?, runtime = ?, rated = ?, released = ?, plot = ?, poster = ?
-	WHERE id = ?;
-	`, m.Title, m.Year, m.Director, m.Runtime, m.Rated, m.Released, m.Plot, m.Poster, m.ImdbID)
-	if err != nil {
-		return nil, err
-	}
-	err = s.updateRatings(*m)
-	if err != nil {
-		return nil, err
-	}
-	err = s.updateGenres(*m)
-	if err != nil {
-		return nil, err
-	}
-	err = s.updateActors(*m)
-	if err != nil {
+	if _, err := s.UpdateMovie(&m.Movie); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (s *SQLiteStorage) CreateEntry(e *api.Entry, mov *api.Movie) (*api.Entry, error) {
-	var exists bool
-	row := s.DB.QueryRow( /*sql*/ `
-		SELECT EXISTS(SELECT media.title
-		FROM media
-		WHERE media.id = ?);
-		`, mov.ImdbID)
-	if err := row.Scan(&exists); err != nil {
-		slog.Error("checking if movie already exists", "exists", exists)
-		return nil, err
-	} else if !exists {
-		_, err := s.CreateMovie(mov)
-		if err != nil {
-			return nil, err
-		}
-	}
-	var watchedInt = 0
-	if e.Watched {
-		watchedInt = 1
-	}
-	res, err := s.DB.Exec( /*sql*/ `
-		INSERT INTO entries
-		(name, watched, comment, media_id)
-		VALUES (?, ?, ?, ?);
-		`, e.Name, watchedInt, e.Comment, mov.ImdbID)
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
-	e.ID, err = res.LastInsertId()
-	if err != nil {
+	defer tx.Rollback()
+
+	if _, err := s.CreateEntryTx(tx, e, mov); err != nil {
 		return nil, err
 	}
-	return e, nil
+	return e, tx.Commit()
 }
 
 func (s *SQLiteStorage) CreateEntryTx(tx *sql.Tx, e *api.Entry, mov *api.Movie) (*api.Entry, error) {
@@ -974,7 +1340,7 @@ func (s *SQLiteStorage) CreateEntryTx(tx *sql.Tx, e *api.Entry, mov *api.Movie)
 	row := tx.QueryRow( /*sql*/ `
 		SELECT EXISTS(SELECT media.title
 		FROM media
-		WHERE media.id = ?);
+		WHERE media.id = ? AND media.deleted_at IS NULL);
 		`, mov.ImdbID)
 	if err := row.Scan(&exists); err != nil {
 		slog.Error("checking if movie already exists", "exists", exists)
@@ -1001,7 +1367,7 @@ func (s *SQLiteStorage) CreateEntryTx(tx *sql.Tx, e *api.Entry, mov *api.Movie)
 	if err != nil {
 		return nil, err
 	}
-	return e, nil
+	return e, s.audit(tx, api.AuditEntryCreated, strconv.FormatInt(e.ID, 10), mov.ImdbID, nil, auditEntries([]*api.Entry{e}))
 }
 
 func (s *SQLiteStorage) UpdateEntry(movieId, name, comment string, watched bool) (*api.Entry, error) {
@@ -1009,10 +1375,20 @@ func (s *SQLiteStorage) UpdateEntry(movieId, name, comment string, watched bool)
 	if watched {
 		watchedInt = 1
 	}
-	res, err := s.DB.Exec( /*sql*/ `
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := getEntries(tx, movieId)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec( /*sql*/ `
 		UPDATE entries
 		SET name = ?, comment = ?, watched = ?
-		WHERE media_id = ?
+		WHERE media_id = ? AND deleted_at IS NULL
 	`, name, comment, watchedInt, movieId)
 	if err != nil {
 		return nil, err
@@ -1021,31 +1397,92 @@ func (s *SQLiteStorage) UpdateEntry(movieId, name, comment string, watched bool)
 	if err != nil {
 		return nil, err
 	}
+	after, err := getEntries(tx, movieId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit(tx, api.AuditEntryUpdated, movieId, movieId, auditEntries(before), auditEntries(after)); err != nil {
+		return nil, err
+	}
 	entry := api.Entry{
 		ID:      resID,
 		Name:    name,
 		Comment: []byte(comment),
 		Watched: watched,
 	}
-	return &entry, nil
+	return &entry, tx.Commit()
+}
+
+// MarkEntryWatched marks the entry of the user for the media as watched, names are compared case insensitive
+func (s *SQLiteStorage) MarkEntryWatched(mediaID, name string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getEntries(tx, mediaID)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec( /*sql*/ `
+		UPDATE entries
+		SET watched = 1
+		WHERE media_id = ? AND lower(name) = lower(?) AND deleted_at IS NULL;
+		`, mediaID, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	after, err := getEntries(tx, mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditEntryUpdated, mediaID, mediaID, auditEntries(before), auditEntries(after)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+// DeleteEntry moves the entries of the media to the trash
 func (s *SQLiteStorage) DeleteEntry(imdbId string) error {
-	_, err := s.DB.Exec( /*sql*/ `
-		DELETE FROM entries
-		WHERE media_id = ?
-	`, imdbId)
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getEntries(tx, imdbId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		UPDATE entries
+		SET deleted_at = ?
+		WHERE media_id = ? AND deleted_at IS NULL
+	`, timestamp(time.Now()), imdbId)
 	if err != nil {
 		return fmt.Errorf("error deleting entry for movie: %s\n%w", imdbId, err)
 	}
-	return nil
+	if err := s.audit(tx, api.AuditEntryTrashed, imdbId, imdbId, auditEntries(before), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *SQLiteStorage) GetEntries(id string) ([]*api.Entry, error) {
-	rows, err := s.DB.Query(`
+	return getEntries(s.DB, id)
+}
+
+func getEntries(db queryer, id string) ([]*api.Entry, error) {
+	rows, err := db.Query(`
 		SELECT id, name, watched, comment
 		FROM entries
-		WHERE media_id = ?;
+		WHERE media_id = ? AND deleted_at IS NULL;
 		`, id)
 	if err != nil {
 		return nil, err
@@ -1066,3 +1503,23 @@ func (s *SQLiteStorage) GetEntries(id string) ([]*api.Entry, error) {
 	}
 	return entries, nil
 }
+
+// nullInt stores zero values as NULL
+func nullInt(i int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: i, Valid: i != 0}
+}
+
+// nullDate stores zero times as NULL and all others as date without time
+func nullDate(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.Format(dateLayout), Valid: true}
+}
+
+func boxOffice(s string) string {
+	if s == "" {
+		return api.NotAvailable
+	}
+	return s
+}
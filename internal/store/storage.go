@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 
+	"github.com/jhachmer/gomovie/internal/api"
 	"github.com/jhachmer/gomovie/internal/config"
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
@@ -30,7 +31,7 @@ func SetupDatabase(cfg config.Config) (Store, error) {
 		return nil, err
 	}
 
-	if err := store.CreateAdminAccount(cfg); err != nil {
+	if err := store.As(api.SystemActor("setup")).CreateAdminAccount(cfg); err != nil {
 		return nil, err
 	}
 
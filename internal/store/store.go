@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/jhachmer/gomovie/internal/api"
 	"github.com/jhachmer/gomovie/internal/config"
@@ -15,13 +16,30 @@ type Store interface {
 	UserStore
 	MediaStore
 	EntryStore
+	WatchStore
+	UserRatingStore
 	StatsStore
+	JobStore
+	ExportStore
+	BackupStore
+	LibraryStore
+	EventStore
+	WebhookStore
+	PickStore
+	RecommendStore
+	PollStore
+	TagStore
+	SavedSearchStore
+	CreditStore
+	TrashStore
+	AuditStore
 }
 
 type UserStore interface {
 	CreateUser(username, password string) error
 	CheckCredentials(username, password string) (bool, error)
 	AdminLoginQuery(username string) (string, error)
+	IsAdmin(username string) (bool, error)
 	GetUsers() (*sql.Rows, error)
 	ToggleUserActive(userID, status int) error
 }
@@ -31,6 +49,7 @@ type MediaStore interface {
 	UpdateMovie(*api.Movie) (*api.Movie, error)
 	GetMovieByID(string) (*api.Movie, error)
 	GetAllMovies() ([]*api.MovieInfoData, error)
+	GetRatingHistory(string) ([]*api.RatingSnapshot, error)
 
 	CreateSeries(*api.Series) (*api.Series, error)
 	UpdateSeries(*api.Series) (*api.Series, error)
@@ -46,9 +65,130 @@ type EntryStore interface {
 	CreateEntry(entry *api.Entry, movie *api.Movie) (*api.Entry, error)
 	GetEntries(userID string) ([]*api.Entry, error)
 	UpdateEntry(entryID, field, newValue string, watched bool) (*api.Entry, error)
+	MarkEntryWatched(mediaID, name string) error
 	DeleteEntry(entryID string) error
 }
 
+type WatchStore interface {
+	CreateWatchEvent(event *api.WatchEvent) (*api.WatchEvent, error)
+	GetWatchEvents(mediaID string) ([]*api.WatchEvent, error)
+	DeleteWatchEvent(id int64) error
+}
+
+type UserRatingStore interface {
+	SetUserRating(rating *api.UserRating) error
+	DeleteUserRating(username, mediaID string) error
+	GetUserRatings(mediaID string) ([]*api.UserRating, error)
+	GetRatingsByUser(username string) (map[string]float64, error)
+	GetGroupRatings() (map[string]api.GroupRating, error)
+}
+
 type StatsStore interface {
 	GetWatchCounts() (*api.WatchStats, error)
+	GetGenreCounts() ([]*api.CountStat, error)
+	GetReleaseYears() ([]int, error)
+	GetWatchesPerMonth() ([]*api.CountStat, error)
+	GetRewatchCounts() ([]*api.CountStat, error)
+}
+
+type JobStore interface {
+	GetStaleMovies(params api.StaleParams) ([]string, error)
+	CreateJobRun(run *api.JobRun) error
+	GetLastJobRuns() ([]*api.JobRun, error)
+	GetJobRequests(since time.Time) (int, error)
+}
+
+type ExportStore interface {
+	ExportMovies(fn func(*api.ExportRecord) error) error
+}
+
+type BackupStore interface {
+	Backup(path string) error
+	Restore(path string) error
+}
+
+type LibraryStore interface {
+	GetLibraryItems() ([]*api.LibraryItem, error)
+	SaveLibraryItem(item *api.LibraryItem, movie *api.Movie) error
+	DeleteLibraryItem(path string) error
+	GetLocalMediaIDs() (map[string]bool, error)
+}
+
+type EventStore interface {
+	CreateEvent(event *api.Event) error
+	GetEventsAfter(id int64, limit int) ([]*api.Event, error)
+}
+
+type WebhookStore interface {
+	CreateWebhook(hook *api.Webhook) error
+	GetWebhooks() ([]*api.Webhook, error)
+	DeleteWebhook(id int64) error
+	GetDueDeliveries(now time.Time, limit int) ([]*api.WebhookDelivery, error)
+	UpdateDelivery(delivery *api.WebhookDelivery) error
+	GetDeliveries(webhookID int64, limit int) ([]*api.WebhookDelivery, error)
+}
+
+type PickStore interface {
+	GetPickCandidates(params api.PickParams) ([]*api.PickCandidate, error)
+	RecordPick(username, mediaID string, at time.Time) error
+}
+
+type RecommendStore interface {
+	GetMediaFeatures() ([]*api.MediaFeatures, error)
+	GetSeenMediaIDs(username string) ([]string, error)
+	GetListedMediaIDs(username string) ([]string, error)
+}
+
+type PollStore interface {
+	CreatePoll(poll *api.Poll) (*api.Poll, error)
+	GetPolls() ([]*api.Poll, error)
+	GetPoll(token string) (*api.Poll, error)
+	SaveBallot(pollID int64, ballot *api.Ballot) error
+	GetBallots(pollID int64) ([]*api.Ballot, error)
+	ClosePoll(pollID int64, winnerID string, attendees []string, at time.Time) error
+}
+
+type TagStore interface {
+	GetTags() ([]*api.CountStat, error)
+	GetMediaTags() (map[string][]string, error)
+	AddMediaTags(mediaID string, tags []string) error
+	RemoveMediaTag(mediaID, tag string) error
+	CreateCollection(c *api.Collection) (*api.Collection, error)
+	GetCollections() ([]*api.Collection, error)
+	GetCollection(id int64) (*api.Collection, error)
+	DeleteCollection(id int64) error
+}
+
+type SavedSearchStore interface {
+	CreateSavedSearch(search *api.SavedSearch) (*api.SavedSearch, error)
+	GetSavedSearches(username string) ([]*api.SavedSearch, error)
+	GetSavedSearch(id int64) (*api.SavedSearch, error)
+	DeleteSavedSearch(id int64, username string) error
+}
+
+type CreditStore interface {
+	GetMediaCredits(mediaID string) (*api.MediaCredits, error)
+	GetPerson(id int64) (*api.Person, error)
+	GetPersonByName(name string) (*api.Person, error)
+	GetPersonFilms(personID int64) ([]*api.PersonFilm, error)
+	GetPersonAliases() ([]*api.PersonAlias, error)
+	MergePeople(alias, into *api.Person) error
+	GetCountry(id int64) (*api.Country, error)
+	GetCountryMovies(countryID int64) ([]*api.MovieInfoData, error)
+}
+
+type TrashStore interface {
+	GetTrashedMovies() ([]*api.TrashedMovie, error)
+	GetTrashedEntries() ([]*api.TrashedEntry, error)
+	RestoreMedia(id string) error
+	RestoreEntry(id int64) error
+	PurgeMedia(id string) error
+	PurgeEntry(id int64) error
+	PurgeTrash(before time.Time) (int, error)
+}
+
+type AuditStore interface {
+	// As returns a store whose changes are logged as made by actor
+	As(actor string) Store
+	GetAuditEntries(filter api.AuditFilter) ([]*api.AuditEntry, error)
 }
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// newTestStore returns a store on a fresh database in a temporary directory
+func newTestStore(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSQLiteStore(db)
+	t.Cleanup(func() { s.Close() })
+	if err := s.InitDatabaseTables(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// testMovie stores a movie with the given id and title
+func testMovie(t *testing.T, s *SQLiteStorage, id, title string) *api.Movie {
+	t.Helper()
+	mov := &api.Movie{ImdbID: id, Title: title, Year: "1979", Type: "movie"}
+	if _, err := s.CreateMovie(mov); err != nil {
+		t.Fatal(err)
+	}
+	return mov
+}
+
+// testEntry stores an entry of the user for the movie
+func testEntry(t *testing.T, s *SQLiteStorage, mov *api.Movie, name string) *api.Entry {
+	t.Helper()
+	e, err := s.CreateEntry(api.NewEntry(name, false, ""), mov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
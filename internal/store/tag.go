@@ -0,0 +1,224 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// GetTags returns all tags in use with the number of their movies, by name
+func (s *SQLiteStorage) GetTags() ([]*api.CountStat, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT t.name, COUNT(*)
+		FROM tags t
+		JOIN media_tags mt ON mt.tag_id = t.id
+		JOIN media m ON m.id = mt.media_id
+		WHERE m.deleted_at IS NULL
+		GROUP BY t.id
+		ORDER BY t.name COLLATE NOCASE;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*api.CountStat
+	for rows.Next() {
+		var tag api.CountStat
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetMediaTags returns the tags of every tagged movie keyed by media id
+func (s *SQLiteStorage) GetMediaTags() (map[string][]string, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT mt.media_id, t.name
+		FROM media_tags mt
+		JOIN tags t ON t.id = mt.tag_id
+		ORDER BY t.name COLLATE NOCASE;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddMediaTags tags a movie, new tags are created and tags differing only in case are the same tag
+func (s *SQLiteStorage) AddMediaTags(mediaID string, tags []string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, tag := range tags {
+		_, err := tx.Exec( /*sql*/ `
+			INSERT OR IGNORE INTO tags (name) VALUES (?);
+			`, tag)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec( /*sql*/ `
+			INSERT OR IGNORE INTO media_tags (media_id, tag_id)
+			SELECT ?, id FROM tags WHERE name = ?;
+			`, mediaID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.audit(tx, api.AuditTagsAdded, mediaID, mediaID, nil, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveMediaTag removes a tag from a movie, a tag no movie has anymore is deleted
+// returns sql.ErrNoRows if the movie did not have the tag
+func (s *SQLiteStorage) RemoveMediaTag(mediaID, tag string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		DELETE FROM media_tags
+		WHERE media_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?);
+		`, mediaID, tag)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = tx.Exec( /*sql*/ `
+		DELETE FROM tags
+		WHERE name = ? AND NOT EXISTS (SELECT 1 FROM media_tags WHERE tag_id = tags.id);
+		`, tag)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditTagRemoved, mediaID, mediaID, tag, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateCollection stores a collection, its tags are kept as a comma separated list
+func (s *SQLiteStorage) CreateCollection(c *api.Collection) (*api.Collection, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec( /*sql*/ `
+		INSERT INTO collections (name, tags, match_all, created_by)
+		VALUES (?, ?, ?, ?);
+		`, c.Name, strings.Join(c.Tags, ","), c.MatchAll, c.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if c.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := s.audit(tx, api.AuditCollectionCreated, strconv.FormatInt(c.ID, 10), "", nil, c); err != nil {
+		return nil, err
+	}
+	return c, tx.Commit()
+}
+
+// GetCollections returns all collections by name
+func (s *SQLiteStorage) GetCollections() ([]*api.Collection, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id, name, tags, match_all, created_by
+		FROM collections
+		ORDER BY name;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collections []*api.Collection
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// GetCollection returns the collection with id
+func (s *SQLiteStorage) GetCollection(id int64) (*api.Collection, error) {
+	return scanCollection(s.DB.QueryRow( /*sql*/ `
+		SELECT id, name, tags, match_all, created_by
+		FROM collections
+		WHERE id = ?;
+		`, id))
+}
+
+func scanCollection(row rowScanner) (*api.Collection, error) {
+	var c api.Collection
+	var tags string
+	if err := row.Scan(&c.ID, &c.Name, &tags, &c.MatchAll, &c.CreatedBy); err != nil {
+		return nil, err
+	}
+	c.Tags = splitNonEmpty(tags, ",")
+	return &c, nil
+}
+
+// DeleteCollection removes a collection, its movies keep their tags
+// returns sql.ErrNoRows if there is no collection with id
+func (s *SQLiteStorage) DeleteCollection(id int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := scanCollection(tx.QueryRow( /*sql*/ `
+		SELECT id, name, tags, match_all, created_by
+		FROM collections
+		WHERE id = ?;
+		`, id))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM collections WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditCollectionDeleted, strconv.FormatInt(id, 10), "", before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// restoreTrashedMedia takes media out of the trash together with the entries deleted with it
+// media which is added again while in the trash is restored this way, keeping its stored data
+func restoreTrashedMedia(db execer, id string) (bool, error) {
+	_, err := db.Exec( /*sql*/ `
+		UPDATE entries
+		SET deleted_at = NULL, deleted_with_media = 0
+		WHERE media_id = ? AND deleted_with_media = 1
+			AND EXISTS (SELECT 1 FROM media WHERE id = ? AND deleted_at IS NOT NULL);
+		`, id, id)
+	if err != nil {
+		return false, err
+	}
+	res, err := db.Exec(`UPDATE media SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetTrashedMovies returns the movies in the trash, most recently deleted first
+func (s *SQLiteStorage) GetTrashedMovies() ([]*api.TrashedMovie, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT m.id, m.title, m.year, m.deleted_at,
+			(SELECT COUNT(*) FROM entries e WHERE e.media_id = m.id AND e.deleted_with_media = 1)
+		FROM media m
+		WHERE m.deleted_at IS NOT NULL
+		ORDER BY m.deleted_at DESC, m.title;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []*api.TrashedMovie{}
+	for rows.Next() {
+		var m api.TrashedMovie
+		if err := rows.Scan(&m.MediaID, &m.Title, &m.Year, &m.DeletedAt, &m.Entries); err != nil {
+			return nil, err
+		}
+		movies = append(movies, &m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// GetTrashedEntries returns the entries in the trash whose movie is not trashed, most recently deleted first
+// entries of trashed movies are restored and purged with their movie
+func (s *SQLiteStorage) GetTrashedEntries() ([]*api.TrashedEntry, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT e.id, e.media_id, m.title, e.name, e.watched, e.deleted_at
+		FROM entries e
+		JOIN media m ON m.id = e.media_id
+		WHERE e.deleted_at IS NOT NULL AND m.deleted_at IS NULL
+		ORDER BY e.deleted_at DESC, m.title, e.name;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*api.TrashedEntry{}
+	for rows.Next() {
+		var e api.TrashedEntry
+		if err := rows.Scan(&e.ID, &e.MediaID, &e.Title, &e.Name, &e.Watched, &e.DeletedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RestoreMedia takes the media out of the trash together with the entries deleted with it
+// sql.ErrNoRows is returned when the media is not in the trash
+func (s *SQLiteStorage) RestoreMedia(id string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	restored, err := restoreTrashedMedia(tx, id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return sql.ErrNoRows
+	}
+	if err := s.audit(tx, api.AuditMovieRestored, id, id, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RestoreEntry takes the entry out of the trash
+// sql.ErrNoRows is returned when the entry is not in the trash or its movie is
+func (s *SQLiteStorage) RestoreEntry(id int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var mediaID string
+	err = tx.QueryRow( /*sql*/ `
+		UPDATE entries
+		SET deleted_at = NULL
+		WHERE id = ? AND deleted_at IS NOT NULL
+			AND media_id IN (SELECT id FROM media WHERE deleted_at IS NULL)
+		RETURNING media_id;
+		`, id).Scan(&mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditEntryRestored, strconv.FormatInt(id, 10), mediaID, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeMedia removes trashed media for good, its entries, ratings, viewings and tags are removed with it
+// sql.ErrNoRows is returned when the media is not in the trash
+func (s *SQLiteStorage) PurgeMedia(id string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM media WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	if err := s.audit(tx, api.AuditMoviePurged, id, id, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeEntry removes a trashed entry for good
+// sql.ErrNoRows is returned when the entry is not in the trash
+func (s *SQLiteStorage) PurgeEntry(id int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var mediaID string
+	err = tx.QueryRow(`DELETE FROM entries WHERE id = ? AND deleted_at IS NOT NULL RETURNING media_id`, id).Scan(&mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditEntryPurged, strconv.FormatInt(id, 10), mediaID, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeTrash removes media and entries deleted before the given time for good
+// and returns how many movies and entries were removed, entries removed with their movie are not counted
+func (s *SQLiteStorage) PurgeTrash(before time.Time) (int, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// entries of purged media are removed with it by the foreign key
+	entries, err := purgeTrashed(tx, `
+		DELETE FROM entries
+		WHERE deleted_at < ? AND media_id NOT IN (SELECT id FROM media WHERE deleted_at < ?)
+		RETURNING id, media_id;
+		`, timestamp(before), timestamp(before))
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if err := s.audit(tx, api.AuditEntryPurged, e.id, e.mediaID, nil, nil); err != nil {
+			return 0, err
+		}
+	}
+	movies, err := purgeTrashed(tx, `DELETE FROM media WHERE deleted_at < ? RETURNING id, id`, timestamp(before))
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range movies {
+		if err := s.audit(tx, api.AuditMoviePurged, m.id, m.mediaID, nil, nil); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(movies) + len(entries), nil
+}
+
+type purged struct {
+	id, mediaID string
+}
+
+// purgeTrashed runs a delete returning the id and media id of every removed row
+func purgeTrashed(tx *sql.Tx, query string, args ...any) ([]purged, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var removed []purged
+	for rows.Next() {
+		var p purged
+		if err := rows.Scan(&p.id, &p.mediaID); err != nil {
+			return nil, err
+		}
+		removed = append(removed, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
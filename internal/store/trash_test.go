@@ -0,0 +1,195 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countRows returns the number of rows of the table matching the condition
+func countRows(t *testing.T, s *SQLiteStorage, table, where string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSQLiteStorage_migrateEntriesTrash(t *testing.T) {
+	s := newTestStore(t)
+	testMovie(t, s, "tt0078748", "Alien")
+	// pragmas only apply to the connection they are run on
+	s.DB.SetMaxOpenConns(1)
+	// back to the schema before the trash: entries without deleted_at removed with ON DELETE SET NULL
+	for _, stmt := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`DROP TABLE entries`,
+		`--sql
+		CREATE TABLE entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL,
+		watched INTEGER DEFAULT 0,
+		comment TEXT,
+		media_id VARCHAR(9) NOT NULL,
+		FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE SET NULL);
+		`,
+		`INSERT INTO entries (name, watched, comment, media_id) VALUES ('alice', 1, 'good', 'tt0078748')`,
+		`INSERT INTO entries (name, watched, comment, media_id) VALUES ('bob', 0, '', 'tt0000000')`,
+		`ALTER TABLE media DROP COLUMN deleted_at`,
+		`PRAGMA user_version = 6`,
+		`PRAGMA foreign_keys = ON`,
+	} {
+		if _, err := s.DB.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	if err := s.InitDatabaseTables(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := s.GetEntries("tt0078748")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "alice" || !entries[0].Watched || string(entries[0].Comment) != "good" {
+		t.Errorf("entries after migration = %+v, want the entry of alice", entries)
+	}
+	if n := countRows(t, s, "entries", "media_id = 'tt0000000'"); n != 0 {
+		t.Errorf("%d entries of removed media kept", n)
+	}
+	if err := s.DeleteMedia("tt0078748"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PurgeMedia("tt0078748"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, s, "entries", "1 = 1"); n != 0 {
+		t.Errorf("%d entries kept after their media was purged", n)
+	}
+}
+
+func TestSQLiteStorage_Trash(t *testing.T) {
+	s := newTestStore(t)
+	alien := testMovie(t, s, "tt0078748", "Alien")
+	aliens := testMovie(t, s, "tt0090605", "Aliens")
+	alone := testEntry(t, s, alien, "alice")
+	testEntry(t, s, aliens, "alice")
+
+	// an entry trashed on its own stays in the trash when its movie is restored
+	if err := s.DeleteEntry(alien.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{alien.ImdbID, aliens.ImdbID} {
+		if err := s.DeleteMedia(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DeleteMedia(alien.ImdbID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting a trashed movie = %v, want sql.ErrNoRows", err)
+	}
+	movies, err := s.GetTrashedMovies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, m := range movies {
+		counts[m.MediaID] = m.Entries
+	}
+	if counts[alien.ImdbID] != 0 || counts[aliens.ImdbID] != 1 || len(counts) != 2 {
+		t.Errorf("trashed movies with entries = %v", counts)
+	}
+	for _, id := range []string{alien.ImdbID, aliens.ImdbID} {
+		if err := s.RestoreMedia(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RestoreMedia(alien.ImdbID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("restoring a live movie = %v, want sql.ErrNoRows", err)
+	}
+	if entries, _ := s.GetEntries(alien.ImdbID); len(entries) != 0 {
+		t.Errorf("entry trashed on its own was restored with its movie")
+	}
+	if entries, _ := s.GetEntries(aliens.ImdbID); len(entries) != 1 {
+		t.Errorf("entry trashed with its movie was not restored")
+	}
+	trashed, err := s.GetTrashedEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != alone.ID {
+		t.Fatalf("trashed entries = %+v, want the entry of Alien", trashed)
+	}
+	if err := s.RestoreEntry(alone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.GetEntries(alien.ImdbID); len(entries) != 1 {
+		t.Errorf("restored entry is not listed")
+	}
+
+	if err := s.PurgeMedia(aliens.ImdbID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("purging a live movie = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.DeleteEntry(alien.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PurgeEntry(alone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteMedia(aliens.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PurgeMedia(aliens.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, s, "entries", "1 = 1"); n != 0 {
+		t.Errorf("%d entries kept after purging", n)
+	}
+	if n := countRows(t, s, "media", "id = ?", aliens.ImdbID); n != 0 {
+		t.Errorf("purged movie kept")
+	}
+}
+
+func TestSQLiteStorage_PurgeTrash(t *testing.T) {
+	s := newTestStore(t)
+	old := testMovie(t, s, "tt0078748", "Alien")
+	recent := testMovie(t, s, "tt0090605", "Aliens")
+	live := testMovie(t, s, "tt0103644", "Alien 3")
+	testEntry(t, s, old, "alice")
+	testEntry(t, s, old, "bob")
+	testEntry(t, s, recent, "alice")
+	testEntry(t, s, live, "alice")
+	for _, id := range []string{old.ImdbID, recent.ImdbID} {
+		if err := s.DeleteMedia(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DeleteEntry(live.ImdbID); err != nil {
+		t.Fatal(err)
+	}
+	month := timestamp(time.Now().AddDate(0, -1, 0))
+	for _, stmt := range []string{
+		`UPDATE media SET deleted_at = ? WHERE id = 'tt0078748'`,
+		`UPDATE entries SET deleted_at = ? WHERE media_id IN ('tt0078748', 'tt0103644')`,
+	} {
+		if _, err := s.DB.Exec(stmt, month); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := s.PurgeTrash(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the old movie and the old entry of the live movie, the entries of the old movie go with it
+	if n != 2 {
+		t.Errorf("PurgeTrash() = %d, want 2", n)
+	}
+	if got := countRows(t, s, "media", "1 = 1"); got != 2 {
+		t.Errorf("%d movies left, want the recent and the live one", got)
+	}
+	if got := countRows(t, s, "entries", "1 = 1"); got != 1 {
+		t.Errorf("%d entries left, want the one of the recent movie", got)
+	}
+}
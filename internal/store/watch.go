@@ -0,0 +1,174 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// dateLayout is used for DATE columns
+const dateLayout = time.DateOnly
+
+// CreateWatchEvent stores a viewing of a movie and marks the entry of the user as watched
+func (s *SQLiteStorage) CreateWatchEvent(e *api.WatchEvent) (*api.WatchEvent, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var watchedAt sql.NullString
+	if e.WatchedAt != nil {
+		watchedAt = sql.NullString{String: e.WatchedAt.Format(dateLayout), Valid: true}
+	}
+	res, err := tx.Exec( /*sql*/ `
+		INSERT INTO watch_events (username, media_id, watched_at, note)
+		VALUES (?, ?, ?, ?);
+		`, e.Username, e.MediaID, watchedAt, e.Note)
+	if err != nil {
+		return nil, err
+	}
+	e.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		UPDATE entries
+		SET watched = 1
+		WHERE media_id = ? AND lower(name) = lower(?);
+		`, e.MediaID, e.Username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit(tx, api.AuditWatchCreated, strconv.FormatInt(e.ID, 10), e.MediaID, nil, e); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetWatchEvents returns all viewings of a movie, latest first and undated ones last
+func (s *SQLiteStorage) GetWatchEvents(mediaID string) ([]*api.WatchEvent, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT id, username, media_id, watched_at, note
+		FROM watch_events
+		WHERE media_id = ?
+		ORDER BY watched_at IS NULL, watched_at DESC, id DESC;
+		`, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*api.WatchEvent
+	for rows.Next() {
+		event, err := scanWatchEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func scanWatchEvent(row rowScanner) (*api.WatchEvent, error) {
+	var event api.WatchEvent
+	var watchedAt sql.NullString
+	if err := row.Scan(&event.ID, &event.Username, &event.MediaID, &watchedAt, &event.Note); err != nil {
+		return nil, err
+	}
+	if watchedAt.Valid {
+		t, err := time.Parse(dateLayout, watchedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		event.WatchedAt = &t
+	}
+	return &event, nil
+}
+
+// DeleteWatchEvent removes a single viewing, the watched flag of entries is left untouched
+// sql.ErrNoRows is returned for unknown viewings
+func (s *SQLiteStorage) DeleteWatchEvent(id int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := scanWatchEvent(tx.QueryRow( /*sql*/ `
+		SELECT id, username, media_id, watched_at, note
+		FROM watch_events
+		WHERE id = ?;
+		`, id))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec( /*sql*/ `
+		DELETE FROM watch_events
+		WHERE id = ?;
+		`, id)
+	if err != nil {
+		return err
+	}
+	if err := s.audit(tx, api.AuditWatchDeleted, strconv.FormatInt(id, 10), before.MediaID, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetWatchesPerMonth counts dated viewings grouped by month, oldest month first
+// the month is returned as name in the form YYYY-MM
+func (s *SQLiteStorage) GetWatchesPerMonth() ([]*api.CountStat, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT strftime('%Y-%m', watched_at) AS month, COUNT(*)
+		FROM watch_events
+		WHERE watched_at IS NOT NULL
+		GROUP BY month
+		ORDER BY month;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCountStats(rows)
+}
+
+// GetRewatchCounts returns the titles of movies watched more than once with their number of viewings
+func (s *SQLiteStorage) GetRewatchCounts() ([]*api.CountStat, error) {
+	rows, err := s.DB.Query( /*sql*/ `
+		SELECT m.title, COUNT(w.id) AS num
+		FROM watch_events w
+		INNER JOIN media m ON m.id = w.media_id
+		WHERE m.deleted_at IS NULL
+		GROUP BY w.media_id
+		HAVING num > 1
+		ORDER BY num DESC, m.title;
+		`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCountStats(rows)
+}
+
+func scanCountStats(rows *sql.Rows) ([]*api.CountStat, error) {
+	defer rows.Close()
+	var counts []*api.CountStat
+	for rows.Next() {
+		var count api.CountStat
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
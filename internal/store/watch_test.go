@@ -0,0 +1,60 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+func TestSQLiteStorage_CreateWatchEvent(t *testing.T) {
+	s := newTestStore(t)
+	mov := testMovie(t, s, "tt0078748", "Alien")
+	testEntry(t, s, mov, "Alice")
+	testEntry(t, s, mov, "bob")
+
+	if _, err := s.CreateWatchEvent(&api.WatchEvent{Username: "alice", MediaID: mov.ImdbID}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := s.GetEntries(mov.ImdbID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"Alice": true, "bob": false}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		if e.Watched != want[e.Name] {
+			t.Errorf("entry of %s watched = %v, want %v", e.Name, e.Watched, want[e.Name])
+		}
+	}
+}
+
+func TestSQLiteStorage_MarkEntryWatched(t *testing.T) {
+	s := newTestStore(t)
+	mov := testMovie(t, s, "tt0078748", "Alien")
+	testEntry(t, s, mov, "Alice")
+	testEntry(t, s, mov, "bob")
+
+	if err := s.MarkEntryWatched(mov.ImdbID, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkEntryWatched(mov.ImdbID, "carol"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("marking the entry of a user without one returned %v, want sql.ErrNoRows", err)
+	}
+	entries, err := s.GetEntries(mov.ImdbID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"Alice": true, "bob": false}
+	for _, e := range entries {
+		if e.Watched != want[e.Name] {
+			t.Errorf("entry of %s watched = %v, want %v", e.Name, e.Watched, want[e.Name])
+		}
+	}
+	if got := lastAudit(t, s); got.Action != api.AuditEntryUpdated || got.Target != mov.ImdbID {
+		t.Errorf("got audit entry %s %s, want %s %s", got.Action, got.Target, api.AuditEntryUpdated, mov.ImdbID)
+	}
+}
@@ -0,0 +1,78 @@
+// Package trash periodically removes movies and entries which were in the trash for longer than the retention
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+// JobName identifies purge runs in the job history
+const JobName = "trash"
+
+// interval is the time between purges, deletions are kept at most this long past the retention
+const interval = time.Hour
+
+// Store is the part of the store used by the Purger
+type Store interface {
+	PurgeTrash(before time.Time) (int, error)
+	CreateJobRun(run *api.JobRun) error
+}
+
+// Purger removes everything deleted more than retention ago for good
+type Purger struct {
+	store     Store
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewPurger returns a Purger keeping trashed movies and entries for the given number of days
+func NewPurger(store Store, days int) *Purger {
+	return &Purger{
+		store:     store,
+		retention: time.Duration(days) * 24 * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// Name implements the server Job interface
+func (p *Purger) Name() string {
+	return JobName
+}
+
+// Run purges the trash right away and then once every hour until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	if p.retention <= 0 {
+		slog.Info("trash purge disabled", "job", JobName)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run := p.RunOnce(ctx)
+		slog.Info("trash purge finished", "job", JobName, "processed", run.Processed, "err", run.Error)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce removes everything deleted before the retention and records the run
+// Processed counts the removed movies and entries
+func (p *Purger) RunOnce(ctx context.Context) *api.JobRun {
+	run := &api.JobRun{Job: JobName, StartedAt: p.now()}
+	n, err := p.store.PurgeTrash(run.StartedAt.Add(-p.retention))
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.Processed = n
+	run.FinishedAt = p.now()
+	if err := p.store.CreateJobRun(run); err != nil {
+		slog.Error("could not record job run", "job", JobName, "err", err.Error())
+	}
+	return run
+}
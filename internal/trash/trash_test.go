@@ -0,0 +1,73 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+)
+
+type fakeStore struct {
+	purged int
+	err    error
+	before time.Time
+	runs   []*api.JobRun
+}
+
+func (f *fakeStore) PurgeTrash(before time.Time) (int, error) {
+	f.before = before
+	return f.purged, f.err
+}
+
+func (f *fakeStore) CreateJobRun(run *api.JobRun) error {
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func TestPurger_RunOnce(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		days          int
+		purged        int
+		err           error
+		wantBefore    time.Time
+		wantProcessed int
+		wantErr       string
+	}{
+		{
+			name:          "purges everything deleted before the retention",
+			days:          30,
+			purged:        3,
+			wantBefore:    time.Date(2026, 9, 19, 12, 0, 0, 0, time.UTC),
+			wantProcessed: 3,
+		},
+		{
+			name:       "records failed purges",
+			days:       1,
+			err:        errors.New("database is locked"),
+			wantBefore: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			wantErr:    "database is locked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{purged: tt.purged, err: tt.err}
+			p := NewPurger(store, tt.days)
+			p.now = func() time.Time { return now }
+
+			run := p.RunOnce(context.Background())
+			if !store.before.Equal(tt.wantBefore) {
+				t.Errorf("purged before %v, want %v", store.before, tt.wantBefore)
+			}
+			if run.Processed != tt.wantProcessed || run.Error != tt.wantErr {
+				t.Errorf("run = %+v, want %d processed and error %q", run, tt.wantProcessed, tt.wantErr)
+			}
+			if len(store.runs) != 1 || store.runs[0].Job != JobName {
+				t.Errorf("recorded runs = %+v, want one %s run", store.runs, JobName)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package util
 
 import (
+	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -26,20 +28,26 @@ func ResolveLogLevel(debug bool) slog.Level {
 
 type DirFiles struct {
 	Name string `json:"name,omitempty"`
+	// Path is the path of the file including root
+	Path string `json:"path,omitempty"`
 }
 
+// FindValidFiles walks root and returns all files with one of the extensions, compared case-insensitively
 func FindValidFiles(root string, ext ...string) ([]DirFiles, error) {
 	files := make([]DirFiles, 0)
-	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if slices.Contains(ext, filepath.Ext(d.Name())) {
-			file := DirFiles{Name: d.Name()}
+		if !d.IsDir() && slices.Contains(ext, strings.ToLower(filepath.Ext(d.Name()))) {
+			file := DirFiles{Name: d.Name(), Path: path}
 			files = append(files, file)
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 	return files, nil
 }
 
@@ -69,15 +77,31 @@ func Reduce[TValue, TResult any](values []TValue, initialValue TResult, fn func(
 	return result
 }
 
+// titleYearPatterns match names like "Blade Runner (1982) [1080p]" and "Blade.Runner.1982.1080p",
+// the last year wins, so years in titles like "Blade Runner 2049 (2017)" are kept
+var titleYearPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(.+)\((\d{4})\)`),
+	regexp.MustCompile(`^(.+)[ ._-]((?:19|20)\d{2})(?:[ ._\-\[(]|$)`),
+}
+
+// ExtractTitleAndYearFromPath returns title and year of a file or directory name
+// dots and underscores separating the words of a title are replaced by spaces
 func ExtractTitleAndYearFromPath(s string) (string, int, error) {
-	i := strings.LastIndex(s, "(")
-	j := len(s)
-	title := s[0 : i-1]
-	year, err := strconv.Atoi(s[i+1 : j-1])
-	if err != nil {
-		return "", 0, err
+	name := filepath.Base(s)
+	for _, pattern := range titleYearPatterns {
+		match := pattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		title := strings.NewReplacer(".", " ", "_", " ").Replace(match[1])
+		title = strings.Trim(title, " -[(")
+		year, err := strconv.Atoi(match[2])
+		if err != nil || title == "" {
+			continue
+		}
+		return title, year, nil
 	}
-	return title, year, nil
+	return "", 0, fmt.Errorf("no title and year in %q", s)
 }
 
 func SplitIMDBString(s string) []string {
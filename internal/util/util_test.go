@@ -85,7 +85,14 @@ func TestFindValidFiles(t *testing.T) {
 		{name: "Find mp4 and mkv", args: args{
 			root: tempDir,
 			ext:  []string{".mp4", ".mkv"},
-		}, want: []DirFiles{{Name: returnFileName(tempFiles[2].Name())}, {Name: returnFileName(tempFiles[1].Name())}}, wantErr: false},
+		}, want: []DirFiles{
+			{Name: returnFileName(tempFiles[2].Name()), Path: tempFiles[2].Name()},
+			{Name: returnFileName(tempFiles[1].Name()), Path: tempFiles[1].Name()},
+		}, wantErr: false},
+		{name: "Missing root", args: args{
+			root: filepath.Join(tempDir, "missing"),
+			ext:  []string{".mp4"},
+		}, want: nil, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -196,6 +203,41 @@ func TestExtractTitleAndYearFromPath(t *testing.T) {
 			want1:   0,
 			wantErr: true,
 		},
+		{
+			name:    "no parenthesis",
+			args:    args{s: "Home Video"},
+			want:    "",
+			want1:   0,
+			wantErr: true,
+		},
+		{
+			name:    "file with extension and tags",
+			args:    args{s: "/media/movies/Alien (1979)/Alien (1979) [1080p].mkv"},
+			want:    "Alien",
+			want1:   1979,
+			wantErr: false,
+		},
+		{
+			name:    "year in title",
+			args:    args{s: "Blade Runner 2049 (2017).mp4"},
+			want:    "Blade Runner 2049",
+			want1:   2017,
+			wantErr: false,
+		},
+		{
+			name:    "dotted name",
+			args:    args{s: "2001.A.Space.Odyssey.1968.1080p.BluRay.mkv"},
+			want:    "2001 A Space Odyssey",
+			want1:   1968,
+			wantErr: false,
+		},
+		{
+			name:    "number title",
+			args:    args{s: "1917.mkv"},
+			want:    "",
+			want1:   0,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
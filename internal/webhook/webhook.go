@@ -0,0 +1,217 @@
+// Package webhook posts stored events to the webhooks configured by admins
+// deliveries are queued in the database, so they survive restarts, and retried with exponential backoff
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/events"
+)
+
+// JobName identifies delivery runs in the job history
+const JobName = "webhooks"
+
+// headers sent with every delivery
+const (
+	HeaderEvent     = "X-Gomovie-Event"
+	HeaderDelivery  = "X-Gomovie-Delivery"
+	HeaderTimestamp = "X-Gomovie-Timestamp"
+	HeaderSignature = "X-Gomovie-Signature"
+)
+
+const (
+	// batchSize is the number of deliveries sent per run
+	batchSize = 50
+	// maxBackoff caps the wait between two attempts
+	maxBackoff = 6 * time.Hour
+)
+
+// Store is the part of the store used by the Dispatcher
+type Store interface {
+	GetDueDeliveries(now time.Time, limit int) ([]*api.WebhookDelivery, error)
+	UpdateDelivery(delivery *api.WebhookDelivery) error
+	CreateJobRun(run *api.JobRun) error
+}
+
+// Dispatcher sends due deliveries whenever an event is published and every poll interval for retries
+type Dispatcher struct {
+	store  Store
+	bus    *events.Bus
+	client *http.Client
+	poll   time.Duration
+	// MaxAttempts is the number of attempts before a delivery is given up
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt, it doubles with every further one
+	Backoff time.Duration
+	now     func() time.Time
+}
+
+// NewDispatcher returns a Dispatcher woken by events of bus
+func NewDispatcher(store Store, bus *events.Bus, poll time.Duration) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		bus:         bus,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		poll:        poll,
+		MaxAttempts: 8,
+		Backoff:     30 * time.Second,
+		now:         time.Now,
+	}
+}
+
+// Name implements the server Job interface
+func (d *Dispatcher) Name() string {
+	return JobName
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	published, cancel := d.bus.Subscribe()
+	defer cancel()
+	ticker := time.NewTicker(d.poll)
+	defer ticker.Stop()
+	for {
+		if run := d.RunOnce(ctx); run.Processed+run.Failed > 0 || run.Error != "" {
+			slog.Info("webhook deliveries finished", "job", JobName, "processed", run.Processed,
+				"failed", run.Failed, "err", run.Error)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-published:
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends all due deliveries, runs with deliveries are recorded
+// Processed counts delivered and Failed counts failed attempts
+func (d *Dispatcher) RunOnce(ctx context.Context) *api.JobRun {
+	run := &api.JobRun{Job: JobName, StartedAt: d.now()}
+	for {
+		deliveries, err := d.store.GetDueDeliveries(d.now(), batchSize)
+		if err != nil {
+			run.Error = err.Error()
+			break
+		}
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				break
+			}
+			if err := d.Deliver(ctx, delivery); err != nil {
+				slog.Warn("webhook delivery failed", "job", JobName, "delivery", delivery.ID, "url", delivery.URL,
+					"attempts", delivery.Attempts, "err", err.Error())
+				run.Failed++
+			} else {
+				run.Processed++
+			}
+			if err := d.store.UpdateDelivery(delivery); err != nil {
+				run.Error = err.Error()
+				break
+			}
+		}
+		if len(deliveries) < batchSize || run.Error != "" || ctx.Err() != nil {
+			break
+		}
+	}
+	run.FinishedAt = d.now()
+	if run.Processed+run.Failed == 0 && run.Error == "" {
+		return run
+	}
+	if err := d.store.CreateJobRun(run); err != nil {
+		slog.Error("could not record job run", "job", JobName, "err", err.Error())
+	}
+	return run
+}
+
+// Deliver makes one attempt to post the event of delivery and updates its status
+// a failed attempt is retried after the backoff until MaxAttempts is reached
+func (d *Dispatcher) Deliver(ctx context.Context, delivery *api.WebhookDelivery) error {
+	delivery.Attempts++
+	delivery.StatusCode = 0
+	err := d.post(ctx, delivery)
+	now := d.now()
+	switch {
+	case err == nil:
+		delivery.Status = api.DeliveryDelivered
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = api.DeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+	return err
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery *api.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gomovie-webhook")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, body))
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	delivery.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the wait after the given number of failed attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.Backoff
+	for range attempts - 1 {
+		if wait *= 2; wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
+
+// Sign returns the signature header value of a payload, receivers compute it with their copy of the secret
+// the timestamp is signed as well, so receivers can reject replayed requests
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random secret for a new webhook
+func NewSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
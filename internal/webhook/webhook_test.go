@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jhachmer/gomovie/internal/api"
+	"github.com/jhachmer/gomovie/internal/events"
+)
+
+type fakeStore struct {
+	mu         sync.Mutex
+	deliveries []*api.WebhookDelivery
+	runs       []*api.JobRun
+}
+
+func (f *fakeStore) GetDueDeliveries(now time.Time, limit int) ([]*api.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []*api.WebhookDelivery
+	for _, d := range f.deliveries {
+		if d.Status == api.DeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			copied := *d
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeStore) UpdateDelivery(delivery *api.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, d := range f.deliveries {
+		if d.ID == delivery.ID {
+			f.deliveries[i] = delivery
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) CreateJobRun(run *api.JobRun) error {
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+type request struct {
+	event     string
+	signature string
+	valid     bool
+}
+
+// receiver answers with the given status codes in turn and records the requests it got
+func receiver(t *testing.T, secret string, codes ...int) (*httptest.Server, *[]request) {
+	t.Helper()
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature := r.Header.Get(HeaderSignature)
+		requests = append(requests, request{
+			event:     r.Header.Get(HeaderEvent),
+			signature: signature,
+			valid:     signature == Sign(secret, r.Header.Get(HeaderTimestamp), body),
+		})
+		code := http.StatusOK
+		if len(codes) > 0 {
+			code, codes = codes[0], codes[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newDispatcher(store *fakeStore, clock *time.Time) *Dispatcher {
+	d := NewDispatcher(store, events.NewBus(nil), time.Minute)
+	d.MaxAttempts = 3
+	d.now = func() time.Time { return *clock }
+	return d
+}
+
+func delivery(id int64, url string) *api.WebhookDelivery {
+	return &api.WebhookDelivery{
+		ID:        id,
+		EventType: api.EventEntryCreated,
+		Status:    api.DeliveryPending,
+		URL:       url,
+		Secret:    "s3cret",
+		Event:     &api.Event{ID: id, Type: api.EventEntryCreated, MediaID: "tt0078748", Title: "Alien"},
+	}
+}
+
+func TestDispatcher_RunOnce(t *testing.T) {
+	server, requests := receiver(t, "s3cret", http.StatusOK, http.StatusInternalServerError)
+	clock := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{deliveries: []*api.WebhookDelivery{delivery(1, server.URL), delivery(2, server.URL)}}
+	d := newDispatcher(store, &clock)
+
+	run := d.RunOnce(context.Background())
+	if run.Processed != 1 || run.Failed != 1 {
+		t.Errorf("run = %+v, want one delivered and one failed", run)
+	}
+	for _, r := range *requests {
+		if !r.valid || r.event != api.EventEntryCreated {
+			t.Errorf("request = %+v, want signed %s event", r, api.EventEntryCreated)
+		}
+	}
+	delivered, retried := store.deliveries[0], store.deliveries[1]
+	if delivered.Status != api.DeliveryDelivered || delivered.DeliveredAt == nil {
+		t.Errorf("delivery 1 = %+v, want delivered", delivered)
+	}
+	if retried.Status != api.DeliveryPending || retried.StatusCode != 500 || !retried.NextAttemptAt.Equal(clock.Add(30*time.Second)) {
+		t.Errorf("delivery 2 = %+v, want retry in 30s", retried)
+	}
+
+	// nothing is due until the backoff passed, idle runs are not recorded
+	if run := d.RunOnce(context.Background()); run.Processed+run.Failed != 0 {
+		t.Errorf("run before backoff = %+v, want nothing sent", run)
+	}
+	if len(store.runs) != 1 {
+		t.Errorf("recorded runs = %d, want 1", len(store.runs))
+	}
+	clock = clock.Add(30 * time.Second)
+	if run := d.RunOnce(context.Background()); run.Processed != 1 {
+		t.Errorf("run after backoff = %+v, want the retry delivered", run)
+	}
+	if len(*requests) != 3 {
+		t.Errorf("requests = %d, want 3", len(*requests))
+	}
+}
+
+func TestDispatcher_GiveUp(t *testing.T) {
+	server, requests := receiver(t, "s3cret", 500, 500, 500, 500)
+	clock := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{deliveries: []*api.WebhookDelivery{delivery(1, server.URL)}}
+	d := newDispatcher(store, &clock)
+
+	var waits []time.Duration
+	for range 4 {
+		d.RunOnce(context.Background())
+		waits = append(waits, store.deliveries[0].NextAttemptAt.Sub(clock))
+		clock = store.deliveries[0].NextAttemptAt
+	}
+	if diff := cmp.Diff([]time.Duration{30 * time.Second, time.Minute, 0, 0}, waits); diff != "" {
+		t.Errorf("backoff mismatch (-want +got):\n%s", diff)
+	}
+	if got := store.deliveries[0]; got.Status != api.DeliveryFailed || got.Attempts != 3 {
+		t.Errorf("delivery = %+v, want failed after 3 attempts", got)
+	}
+	if len(*requests) != 3 {
+		t.Errorf("requests = %d, want 3", len(*requests))
+	}
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	d := NewDispatcher(nil, nil, time.Minute)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 20, want: maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
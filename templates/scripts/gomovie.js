@@ -76,7 +76,7 @@ function cancelEdit(entryId, originalName, originalComment, originalWatched) {
 function deleteEntry(entryId) {
     const currentUrl = window.location.href;
     const movieId = currentUrl.split('/').pop();
-    if (!confirm("Are you sure you want to delete this entry?")) {
+    if (!confirm("Move this entry to the trash?")) {
         return;
     }
 
@@ -140,3 +140,62 @@ document.addEventListener("DOMContentLoaded", function () {
         }
     }
 });
+
+// Live updates of the overview table
+// changed rows are taken from a fresh render of the current page, so they look exactly like after a reload
+// and rows of a search or collection come and go as movies start or stop matching,
+// the browser resumes the stream after the last received event by sending Last-Event-ID when it reconnects
+const liveEventTypes = ['movie.added', 'movie.deleted', 'movie.watched', 'entry.created', 'entry.updated', 'entry.deleted', 'rating.changed'];
+const pendingRows = new Set();
+let pendingTimer = null;
+
+function queueRowUpdate(imdbID) {
+    pendingRows.add(imdbID);
+    // events often come in bursts, e.g. an entry created as watched, one render covers all of them
+    clearTimeout(pendingTimer);
+    pendingTimer = setTimeout(updateRows, 300);
+}
+
+async function updateRows() {
+    const ids = Array.from(pendingRows);
+    pendingRows.clear();
+    const response = await fetch(window.location.pathname + window.location.search);
+    if (!response.ok) {
+        return;
+    }
+    const page = new DOMParser().parseFromString(await response.text(), 'text/html');
+    const tbody = document.querySelector('#moviesTable tbody');
+    ids.forEach(imdbID => {
+        const current = tbody.querySelector(`tr[data-imdbid="${imdbID}"]`);
+        const fresh = page.querySelector(`#moviesTable tr[data-imdbid="${imdbID}"]`);
+        if (!fresh) {
+            current?.remove();
+            return;
+        }
+        const row = document.importNode(fresh, true);
+        row.querySelectorAll('.delete-button').forEach(bindDeleteButton);
+        if (current) {
+            current.replaceWith(row);
+        } else {
+            tbody.appendChild(row);
+        }
+        filterRow(row);
+    });
+}
+
+document.addEventListener('DOMContentLoaded', function () {
+    if (!document.getElementById('moviesTable') || !window.EventSource) {
+        return;
+    }
+    const source = new EventSource('/events');
+    liveEventTypes.forEach(type => {
+        source.addEventListener(type, function (event) {
+            const data = JSON.parse(event.data);
+            if (type === 'movie.deleted') {
+                document.querySelector(`#moviesTable tr[data-imdbid="${data.imdb_id}"]`)?.remove();
+                return;
+            }
+            queueRowUpdate(data.imdb_id);
+        });
+    });
+});
@@ -59,3 +59,40 @@ document.addEventListener("DOMContentLoaded", function () {
         })
         .catch(error => console.error("Error checking movie:", error));
 });
+
+function deleteWatchEvent(eventId) {
+    const imdbID = window.location.href.substring(window.location.href.lastIndexOf('/') + 1);
+    if (!confirm("Are you sure you want to delete this watch?")) {
+        return;
+    }
+    fetch(`/films/${imdbID}/watch/${eventId}`, {
+        method: 'DELETE'
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Failed to delete the watch');
+            }
+            document.getElementById(`watch-${eventId}`).remove();
+        })
+        .catch(error => {
+            console.error('Error deleting the watch:', error);
+            alert('Failed to delete the watch. Please try again.');
+        });
+}
+
+function removeTag(button, tag) {
+    const imdbID = window.location.href.substring(window.location.href.lastIndexOf('/') + 1);
+    fetch(`/films/${imdbID}/tags/${encodeURIComponent(tag)}`, {
+        method: 'DELETE'
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Failed to remove the tag');
+            }
+            button.closest('.tag').remove();
+        })
+        .catch(error => {
+            console.error('Error removing the tag:', error);
+            alert('Failed to remove the tag. Please try again.');
+        });
+}
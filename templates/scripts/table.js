@@ -5,8 +5,9 @@ function sortTable(columnIndex) {
     const isAscending = table.getAttribute("data-sort-asc") === "true";
 
     rows.sort((a, b) => {
-        const cellA = a.cells[columnIndex].textContent.trim();
-        const cellB = b.cells[columnIndex].textContent.trim();
+        // cells with a data-sort attribute carry a normalised numeric value
+        const cellA = a.cells[columnIndex].dataset.sort ?? a.cells[columnIndex].textContent.trim();
+        const cellB = b.cells[columnIndex].dataset.sort ?? b.cells[columnIndex].textContent.trim();
 
         const parseValue = (value) => {
             if (value === "N/A") {
@@ -39,44 +40,103 @@ function sortTable(columnIndex) {
     table.setAttribute("data-sort-asc", !isAscending);
 }
 
+// Hides a watched row when only unwatched movies are shown
+function filterRow(row) {
+    const showNotWatchedOnly = document.getElementById('filterNotWatched').checked;
+    if (row.classList.contains('not-watched')) {
+        row.style.display = showNotWatchedOnly ? '' : 'table-row';
+    } else {
+        row.style.display = showNotWatchedOnly ? 'none' : 'table-row';
+    }
+}
+
 // Checkbox Listener to filter out already watched movies
 document.addEventListener('DOMContentLoaded', function () {
     document.getElementById('filterNotWatched').addEventListener('change', function () {
-        const showNotWatchedOnly = this.checked;
-        const rows = document.querySelectorAll('#moviesTable tbody tr');
-
-        rows.forEach(row => {
-            if (row.classList.contains('not-watched')) {
-                row.style.display = showNotWatchedOnly ? '' : 'table-row';
-            } else {
-                row.style.display = showNotWatchedOnly ? 'none' : 'table-row';
-            }
-        });
+        document.querySelectorAll('#moviesTable tbody tr').forEach(filterRow);
     });
 });
 
+function bindDeleteButton(button) {
+    button.addEventListener("click", function () {
+        const imdbID = this.getAttribute("data-imdbid");
 
-document.addEventListener("DOMContentLoaded", function () {
-    document.querySelectorAll(".delete-button").forEach(button => {
-        button.addEventListener("click", function () {
-            const imdbID = this.getAttribute("data-imdbid");
-
-            if (confirm("Are you sure you want to delete this movie?")) {
-                fetch(`/films/${imdbID}`, {
-                    method: "DELETE",
-                    headers: {
-                        "Content-Type": "application/json"
+        if (confirm("Move this movie to the trash?")) {
+            fetch(`/films/${imdbID}`, {
+                method: "DELETE",
+                headers: {
+                    "Content-Type": "application/json"
+                }
+            })
+                .then(response => {
+                    if (response.ok) {
+                        this.closest("tr")?.remove(); // Remove row from table
+                    } else {
+                        return response.json().then(data => { throw new Error(data.message || "Delete failed"); });
                     }
                 })
-                    .then(response => {
-                        if (response.ok) {
-                            this.closest("tr").remove(); // Remove row from table
-                        } else {
-                            return response.json().then(data => { throw new Error(data.message || "Delete failed"); });
-                        }
-                    })
-                    .catch(error => alert("Error: " + error.message));
-            }
-        });
+                .catch(error => alert("Error: " + error.message));
+        }
     });
+}
+
+document.addEventListener("DOMContentLoaded", function () {
+    document.querySelectorAll(".delete-button").forEach(bindDeleteButton);
 });
+
+function deleteCollection(id) {
+    if (!confirm("Delete this collection? Its movies keep their tags.")) {
+        return;
+    }
+    fetch(`/collections/${id}`, { method: 'DELETE' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            window.location.href = '/overview';
+        })
+        .catch(error => alert("Error: " + error.message));
+}
+
+function deleteSavedSearch(id) {
+    if (!confirm("Delete this saved search?")) {
+        return;
+    }
+    fetch(`/searches/${id}`, { method: 'DELETE' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            if (window.location.pathname === `/searches/${id}`) {
+                window.location.href = '/overview';
+            } else {
+                window.location.reload();
+            }
+        })
+        .catch(error => alert("Error: " + error.message));
+}
+
+function restoreFromTrash(kind, id) {
+    fetch(`/trash/${kind}/${id}/restore`, { method: 'POST' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            window.location.reload();
+        })
+        .catch(error => alert("Error: " + error.message));
+}
+
+function purgeFromTrash(kind, id) {
+    if (!confirm("Delete this for good? It cannot be restored afterwards.")) {
+        return;
+    }
+    fetch(`/trash/${kind}/${id}`, { method: 'DELETE' })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            window.location.reload();
+        })
+        .catch(error => alert("Error: " + error.message));
+}